@@ -0,0 +1,177 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package bbs implements BBS signatures, from the IRTF draft
+// draft-irtf-cfrg-bbs-signatures. A BBS signature is one short signature on
+// a vector of messages, and its holder can prove possession of it in zero
+// knowledge while revealing only some of the messages. This is the basis of
+// anonymous credentials with selective disclosure.
+//
+// Keys are bgls keys on Bls12, with the public key W = sk * g2 on G2. To sign
+// the messages m_1, ..., m_L (mapped to scalars), the signer computes
+//
+//	B = P1 + domain * Q1 + sum_i m_i * H_i
+//	A = (sk + e)^-1 * B
+//
+// where P1, Q1 and H_i are generators of G1 which nobody knows the discrete
+// logs between, domain binds the signature to the key, the generators and a
+// header, and e is a hash of the secret key, domain and the messages. The
+// signature (A, e) verifies with e(A, W + e * g2) = e(B, g2).
+//
+// This is the draft's BLS12-381-SHA-256 ciphersuite. Scalars are hashed with
+// the draft's hash_to_scalar, using RFC 9380's expand_message_xmd with
+// SHA-256, generators are hashed to G1 with RFC 9380's hash_to_curve, and
+// points are in the zcash encoding, so signatures and proofs interoperate with
+// other implementations of the ciphersuite.
+package bbs
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// apiID is the ciphersuite's api_id, for the interface which hashes messages
+// to scalars and generators to the curve.
+const (
+	apiID           = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_H2G_HM2S_"
+	seedDST         = apiID + "SIG_GENERATOR_SEED_"
+	generatorDST    = apiID + "SIG_GENERATOR_DST_"
+	generatorSeed   = apiID + "MESSAGE_GENERATOR_SEED"
+	p1GeneratorSeed = apiID + "BP_MESSAGE_GENERATOR_SEED"
+	mapMessageDST   = apiID + "MAP_MSG_TO_SCALAR_AS_HASH_"
+	hashToScalarDST = apiID + "H2S_"
+)
+
+// Generators are the points of G1 which messages are committed with.
+type Generators struct {
+	P1 Point
+	Q1 Point
+	H  []Point
+}
+
+// Signature is a BBS signature on a vector of messages.
+type Signature struct {
+	A Point
+	E *big.Int
+}
+
+// CreateGenerators creates the generators for count messages with the
+// draft's create_generators. The generators for fewer messages are a prefix
+// of these.
+func CreateGenerators(count int) *Generators {
+	gens := createGenerators([]byte(generatorSeed), count+1)
+	return &Generators{P1: createGenerators([]byte(p1GeneratorSeed), 1)[0], Q1: gens[0], H: gens[1:]}
+}
+
+// createGenerators hashes a chain of expand_message outputs, starting from
+// the seed, to G1.
+func createGenerators(seed []byte, count int) []Point {
+	v := ExpandMessageXMD(seed, []byte(seedDST), expandLen)
+	gens := make([]Point, count)
+	for i := range gens {
+		v = ExpandMessageXMD(appendUint64(append([]byte{}, v...), uint64(i+1)), []byte(seedDST), expandLen)
+		gens[i] = Bls12HashToG1(v, []byte(generatorDST))
+	}
+	return gens
+}
+
+// MessagesToScalars maps each message to a scalar.
+func MessagesToScalars(msgs [][]byte) []*big.Int {
+	scalars := make([]*big.Int, len(msgs))
+	for i, msg := range msgs {
+		scalars[i] = hashToScalar(msg, mapMessageDST)
+	}
+	return scalars
+}
+
+// calculateDomain binds a signature to the public key, the generators and the header.
+func calculateDomain(pubKey Point, gens *Generators, header []byte) *big.Int {
+	buf := appendUint64(Bls12MarshalZcashG2(pubKey), uint64(len(gens.H)))
+	buf = appendPoint(buf, gens.Q1)
+	for _, h := range gens.H {
+		buf = appendPoint(buf, h)
+	}
+	buf = appendUint64(append(buf, apiID...), uint64(len(header)))
+	return hashToScalar(append(buf, header...), hashToScalarDST)
+}
+
+// commit returns P1 + domain * Q1 + sum_i scalars[i] * H[indices[i]].
+func commit(gens *Generators, domain *big.Int, scalars []*big.Int, indices []int) Point {
+	pts := []Point{gens.P1, gens.Q1.Mul(domain)}
+	for i, index := range indices {
+		pts = append(pts, gens.H[index].Mul(scalars[i]))
+	}
+	return AggregatePoints(pts)
+}
+
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// validPublicKey reports whether the public key is on G2 and isn't the
+// identity, which the draft's octets_to_pubkey requires.
+func validPublicKey(pubKey Point) bool {
+	return pubKey != nil && !pubKey.Equals(Bls12.GetG2Infinity())
+}
+
+// Sign signs the messages and the header with the secret key. pubKey must be
+// the public key for sk, as from bgls.KeyGen with Bls12.
+func Sign(sk *big.Int, pubKey Point, header []byte, msgs [][]byte) (*Signature, error) {
+	order := Bls12.GetG1Order()
+	gens := CreateGenerators(len(msgs))
+	domain := calculateDomain(pubKey, gens, header)
+	scalars := MessagesToScalars(msgs)
+
+	buf := appendScalar(appendScalar(nil, sk), domain)
+	for _, m := range scalars {
+		buf = appendScalar(buf, m)
+	}
+	e := hashToScalar(buf, hashToScalarDST)
+	exp := new(big.Int).Add(sk, e)
+	if exp.Mod(exp, order).Sign() == 0 {
+		return nil, errors.New("sk + e is zero")
+	}
+	A := commit(gens, domain, scalars, allIndices(len(msgs))).Mul(exp.ModInverse(exp, order))
+	return &Signature{A, e}, nil
+}
+
+// Verify checks the signature on the messages and the header.
+func Verify(pubKey Point, sig *Signature, header []byte, msgs [][]byte) bool {
+	if !validPublicKey(pubKey) {
+		return false
+	}
+	gens := CreateGenerators(len(msgs))
+	domain := calculateDomain(pubKey, gens, header)
+	B := commit(gens, domain, MessagesToScalars(msgs), allIndices(len(msgs)))
+	negB := B.Mul(new(big.Int).SetInt64(-1))
+	key, _ := pubKey.Add(Bls12.GetG2().Mul(sig.E))
+	paired, _ := Bls12.PairingProduct([]Point{sig.A, negB}, []Point{key, Bls12.GetG2()})
+	return Bls12.GetGTIdentity().Equals(paired)
+}
+
+// Marshal serializes the signature as the draft's signature_to_octets.
+func (sig *Signature) Marshal() []byte {
+	return appendScalar(appendPoint(nil, sig.A), sig.E)
+}
+
+// UnmarshalSignature deserializes a signature with the draft's
+// octets_to_signature, which rejects an identity A and a zero e.
+func UnmarshalSignature(data []byte) (*Signature, bool) {
+	r := encoding.NewReader(data)
+	A, ok := readPoint(r)
+	if !ok {
+		return nil, false
+	}
+	e, ok := readScalar(r)
+	if !ok || !r.Done() {
+		return nil, false
+	}
+	return &Signature{A, e}, true
+}
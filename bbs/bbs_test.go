@@ -0,0 +1,167 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bbs
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var credential = [][]byte{
+	[]byte("name: Alice"),
+	[]byte("date of birth: 1990-01-01"),
+	[]byte("nationality: CH"),
+	[]byte("licence: B"),
+	[]byte("expires: 2030-01-01"),
+}
+
+func TestSignVerify(t *testing.T) {
+	header := []byte("issuer: canton of Zug")
+	sk, pk, _ := bgls.KeyGen(Bls12)
+	sig, err := Sign(sk, pk, header, credential)
+	assert.Nil(t, err)
+	assert.True(t, Verify(pk, sig, header, credential), "Valid signature failed verification")
+
+	again, _ := Sign(sk, pk, header, credential)
+	assert.True(t, sig.A.Equals(again.A) && sig.E.Cmp(again.E) == 0, "Signing isn't deterministic")
+
+	modified := append([][]byte{}, credential...)
+	modified[2] = []byte("nationality: FR")
+	assert.False(t, Verify(pk, sig, header, modified), "Signature verified on modified messages")
+	assert.False(t, Verify(pk, sig, header, credential[:4]), "Signature verified on fewer messages")
+	assert.False(t, Verify(pk, sig, []byte("issuer: other"), credential), "Signature verified with another header")
+	_, pk2, _ := bgls.KeyGen(Bls12)
+	assert.False(t, Verify(pk2, sig, header, credential), "Signature verified under another key")
+	assert.False(t, Verify(Bls12.GetG2Infinity(), sig, header, credential), "Signature verified under the identity")
+
+	unmarshalled, ok := UnmarshalSignature(sig.Marshal())
+	assert.True(t, ok)
+	assert.True(t, Verify(pk, unmarshalled, header, credential))
+	_, ok = UnmarshalSignature(sig.Marshal()[1:])
+	assert.False(t, ok, "Unmarshalled a truncated signature")
+	identity := (&Signature{Bls12.GetG1Infinity(), sig.E}).Marshal()
+	_, ok = UnmarshalSignature(identity)
+	assert.False(t, ok, "Unmarshalled a signature with an identity A")
+	_, ok = UnmarshalSignature((&Signature{sig.A, big.NewInt(0)}).Marshal())
+	assert.False(t, ok, "Unmarshalled a signature with a zero e")
+
+	empty, err := Sign(sk, pk, header, nil)
+	assert.Nil(t, err)
+	assert.True(t, Verify(pk, empty, header, nil), "Signature on no messages failed verification")
+}
+
+func TestGenerators(t *testing.T) {
+	gens := CreateGenerators(4)
+	prefix := CreateGenerators(2)
+	assert.True(t, gens.P1.Equals(prefix.P1) && gens.Q1.Equals(prefix.Q1))
+	assert.True(t, gens.H[1].Equals(prefix.H[1]), "Generators aren't deterministic")
+	all := append([]Point{gens.P1, gens.Q1}, gens.H...)
+	for i := range all {
+		assert.False(t, all[i].Equals(Bls12.GetG1Infinity()))
+		for j := 0; j < i; j++ {
+			assert.False(t, all[i].Equals(all[j]), "Generators repeat")
+		}
+	}
+}
+
+// The fixtures of the draft's BLS12-381-SHA-256 ciphersuite: its key pair,
+// derived with its KeyGen, the generators, and its signature on one message.
+func TestDraftFixtures(t *testing.T) {
+	keyMaterial, _ := hex.DecodeString("746869732d49532d6a7573742d616e2d546573742d494b4d2d746f2d67656e65726174652d246528724074232d6b6579")
+	keyInfo, _ := hex.DecodeString("746869732d49532d736f6d652d6b65792d6d657461646174612d746f2d62652d757365642d696e2d746573742d6b65792d67656e")
+	keyInput := append(append(append([]byte{}, keyMaterial...), 0, byte(len(keyInfo))), keyInfo...)
+	sk := hashToScalar(keyInput, apiID+"KEYGEN_DST_")
+	assert.Equal(t, "60e55110f76883a13d030b2f6bd11883422d5abde717569fc0731f51237169fc", hex.EncodeToString(appendScalar(nil, sk)))
+	pkBytes, _ := hex.DecodeString("a820f230f6ae38503b86c70dc50b61c58a77e45c39ab25c0652bbaa8fa136f2851bd4781c9dcde39fc9d1d52c9e60268061e7d7632171d91aa8d460acee0e96f1e7c4cfb12d3ff9ab5d5dc91c277db75c845d649ef3c4f63aebc364cd55ded0c")
+	pk, ok := Bls12UnmarshalZcashG2(pkBytes)
+	assert.True(t, ok)
+	assert.True(t, pk.Equals(Bls12.GetG2().Mul(sk)), "Public key doesn't match the secret key")
+
+	gens := CreateGenerators(1)
+	assert.Equal(t, "a8ce256102840821a3e94ea9025e4662b205762f9776b3a766c872b948f1fd225e7c59698588e70d11406d161b4e28c9",
+		hex.EncodeToString(Bls12MarshalZcashG1(gens.P1)))
+	assert.Equal(t, "a9ec65b70a7fbe40c874c9eb041c2cb0a7af36ccec1bea48fa2ba4c2eb67ef7f9ecb17ed27d38d27cdeddff44c8137be",
+		hex.EncodeToString(Bls12MarshalZcashG1(gens.Q1)))
+	assert.Equal(t, "98cd5313283aaf5db1b3ba8611fe6070d19e605de4078c38df36019fbaad0bd28dd090fd24ed27f7f4d22d5ff5dea7d4",
+		hex.EncodeToString(Bls12MarshalZcashG1(gens.H[0])))
+
+	header, _ := hex.DecodeString("11223344556677889900aabbccddeeff")
+	msg, _ := hex.DecodeString("9872ad089e452c7b6e283dfac2a80d58e8d0ff71cc4d5e310a1debdda4a45f02")
+	expected := "88c0eb3bc1d97610c3a66d8a3a73f260f95a3028bccf7fff7d9851e2acd9f3f32fdf58a5b34d12df8177adf37aa318a2" +
+		"0f72be7d37a8e8d8441d1bc0bc75543c681bf061ce7e7f6091fe78c1cb8af103"
+	sig, err := Sign(sk, pk, header, [][]byte{msg})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, hex.EncodeToString(sig.Marshal()))
+	sigBytes, _ := hex.DecodeString(expected)
+	decoded, ok := UnmarshalSignature(sigBytes)
+	assert.True(t, ok)
+	assert.True(t, Verify(pk, decoded, header, [][]byte{msg}), "Draft signature failed verification")
+	assert.False(t, Verify(pk, decoded, nil, [][]byte{msg}), "Draft signature verified without its header")
+}
+
+func TestSelectiveDisclosure(t *testing.T) {
+	header := []byte("issuer: canton of Zug")
+	ph := []byte("verifier nonce 7f3a")
+	sk, pk, _ := bgls.KeyGen(Bls12)
+	sig, _ := Sign(sk, pk, header, credential)
+
+	for _, disclosed := range [][]int{nil, {2}, {4, 0}, {0, 1, 2, 3, 4}} {
+		proof, err := ProofGen(pk, sig, header, ph, credential, disclosed)
+		assert.Nil(t, err)
+		disclosedMsgs := make([][]byte, len(disclosed))
+		for i, index := range disclosed {
+			disclosedMsgs[i] = credential[index]
+		}
+		assert.True(t, ProofVerify(pk, proof, header, ph, disclosed, disclosedMsgs),
+			"Valid proof failed verification")
+		assert.Equal(t, len(credential)-len(disclosed), len(proof.MHat))
+		unmarshalled, ok := UnmarshalProof(proof.Marshal())
+		assert.True(t, ok)
+		assert.True(t, ProofVerify(pk, unmarshalled, header, ph, disclosed, disclosedMsgs))
+		assert.False(t, ProofVerify(pk, proof, header, []byte("replayed"), disclosed, disclosedMsgs),
+			"Proof verified with another presentation header")
+		assert.False(t, ProofVerify(pk, proof, []byte("forged"), ph, disclosed, disclosedMsgs),
+			"Proof verified with another header")
+	}
+
+	disclosed := []int{1, 3}
+	disclosedMsgs := [][]byte{credential[1], credential[3]}
+	proof, _ := ProofGen(pk, sig, header, ph, credential, disclosed)
+	assert.False(t, ProofVerify(pk, proof, header, ph, disclosed, [][]byte{[]byte("date of birth: 2010-01-01"), credential[3]}),
+		"Proof verified for a modified disclosed message")
+	assert.False(t, ProofVerify(pk, proof, header, ph, []int{1, 2}, disclosedMsgs),
+		"Proof verified with a disclosed message at another index")
+	assert.False(t, ProofVerify(pk, proof, header, ph, []int{1, 1}, disclosedMsgs),
+		"Proof verified with a repeated index")
+	_, pk2, _ := bgls.KeyGen(Bls12)
+	assert.False(t, ProofVerify(pk2, proof, header, ph, disclosed, disclosedMsgs), "Proof verified under another key")
+	assert.False(t, ProofVerify(Bls12.GetG2Infinity(), proof, header, ph, disclosed, disclosedMsgs),
+		"Proof verified under the identity")
+
+	// Proofs of the same signature share no values.
+	other, _ := ProofGen(pk, sig, header, ph, credential, disclosed)
+	assert.False(t, proof.Abar.Equals(other.Abar) || proof.Bbar.Equals(other.Bbar) || proof.D.Equals(other.D),
+		"Proofs are linkable")
+
+	tampered := *proof
+	tampered.EHat = new(big.Int).Add(proof.EHat, big.NewInt(1))
+	assert.False(t, ProofVerify(pk, &tampered, header, ph, disclosed, disclosedMsgs), "Tampered proof verified")
+	tampered = *proof
+	tampered.Abar, tampered.Bbar = Bls12.GetG1Infinity(), Bls12.GetG1Infinity()
+	assert.False(t, ProofVerify(pk, &tampered, header, ph, disclosed, disclosedMsgs), "Proof with an identity Abar verified")
+	_, ok := UnmarshalProof(tampered.Marshal())
+	assert.False(t, ok, "Unmarshalled a proof with an identity Abar")
+
+	_, err := ProofGen(pk, sig, header, ph, credential, []int{5})
+	assert.NotNil(t, err, "Created a proof disclosing a message which doesn't exist")
+	_, ok = UnmarshalProof(append(proof.Marshal(), 0))
+	assert.False(t, ok, "Unmarshalled a proof with trailing data")
+	_, ok = UnmarshalProof(proof.Marshal()[:3*pointSize+3*scalarSize])
+	assert.False(t, ok, "Unmarshalled a proof without a challenge")
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bbs
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// expandLen is the ciphersuite's expand_len, ceil((ceil(log2(r)) + k) / 8)
+// for k = 128.
+const expandLen = 48
+
+// pointSize and scalarSize are the ciphersuite's octet_point_length and
+// octet_scalar_length.
+const (
+	pointSize  = Bls12ZcashG1Size
+	scalarSize = 32
+)
+
+// hashToScalar is the draft's hash_to_scalar, which reduces expandLen bytes
+// of expand_message_xmd output modulo the group order.
+func hashToScalar(data []byte, dst string) *big.Int {
+	s := new(big.Int).SetBytes(ExpandMessageXMD(data, []byte(dst), expandLen))
+	return s.Mod(s, Bls12.GetG1Order())
+}
+
+// The append functions implement the draft's serialize: integers are
+// I2OSP(n, 8), points on G1 are in the zcash encoding, and scalars are
+// I2OSP(s, 32).
+
+func appendUint64(buf []byte, n uint64) []byte {
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], n)
+	return append(buf, encoded[:]...)
+}
+
+func appendPoint(buf []byte, pt Point) []byte {
+	return append(buf, Bls12MarshalZcashG1(pt)...)
+}
+
+func appendScalar(buf []byte, s *big.Int) []byte {
+	return encoding.AppendScalar(buf, Bls12, s)
+}
+
+// readPoint reads a zcash encoded point on G1, which mustn't be the identity.
+func readPoint(r *encoding.Reader) (Point, bool) {
+	b := r.Bytes(pointSize)
+	if !r.OK() {
+		return nil, false
+	}
+	pt, ok := Bls12UnmarshalZcashG1(b)
+	return pt, ok && !pt.Equals(Bls12.GetG1Infinity())
+}
+
+// readScalar reads a scalar, which must be nonzero and less than the group
+// order.
+func readScalar(r *encoding.Reader) (*big.Int, bool) {
+	s := r.Scalar(Bls12)
+	return s, r.OK() && s.Sign() != 0
+}
@@ -0,0 +1,231 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bbs
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Proof is a zero knowledge proof of possession of a signature, which reveals
+// only the disclosed messages. A fresh proof is unlinkable to the signature
+// and to other proofs of it.
+//
+// The prover randomizes the signature with r1 and r2, and publishes
+//
+//	D = r2 * B, Abar = r1 * r2 * A, Bbar = r1 * D - e * Abar
+//
+// so that Bbar = sk * Abar, which the verifier checks with a pairing. The rest
+// of the proof shows, with the Fiat-Shamir heuristic, that the prover knows e,
+// r1, r3 = r2^-1 and the undisclosed messages such that
+//
+//	Bbar = r1 * D - e * Abar
+//	P1 + domain * Q1 + sum_disclosed m_i * H_i = r3 * D - sum_undisclosed m_j * H_j
+type Proof struct {
+	Abar      Point
+	Bbar      Point
+	D         Point
+	EHat      *big.Int
+	R1Hat     *big.Int
+	R3Hat     *big.Int
+	MHat      []*big.Int
+	Challenge *big.Int
+}
+
+// ProofGen creates a proof of possession of the signature on the messages and
+// the header, which discloses the messages at the given indices. The
+// presentation header ph is bound to the proof, e.g. a verifier's nonce.
+func ProofGen(pubKey Point, sig *Signature, header []byte, ph []byte,
+	msgs [][]byte, disclosed []int) (*Proof, error) {
+	disclosed, ok := sortedIndices(disclosed, len(msgs))
+	if !ok {
+		return nil, errors.New("disclosed indices must be distinct message indices")
+	}
+	undisclosed := complement(disclosed, len(msgs))
+	order := Bls12.GetG1Order()
+	gens := CreateGenerators(len(msgs))
+	domain := calculateDomain(pubKey, gens, header)
+	scalars := MessagesToScalars(msgs)
+
+	// r1, r2, eTilde, r1Tilde, r3Tilde, then one mTilde per undisclosed message.
+	random := make([]*big.Int, 5+len(undisclosed))
+	for i := range random {
+		var err error
+		if random[i], err = encoding.RandomNonzeroScalar(Bls12); err != nil {
+			return nil, err
+		}
+	}
+	r1, r2, eTilde, r1Tilde, r3Tilde, mTilde := random[0], random[1], random[2], random[3], random[4], random[5:]
+
+	B := commit(gens, domain, scalars, allIndices(len(msgs)))
+	D := B.Mul(r2)
+	Abar := sig.A.Mul(new(big.Int).Mul(r1, r2))
+	Bbar, _ := D.Mul(r1).Add(Abar.Mul(new(big.Int).Sub(order, sig.E)))
+	T1, _ := Abar.Mul(eTilde).Add(D.Mul(r1Tilde))
+	T2 := D.Mul(r3Tilde)
+	for i, j := range undisclosed {
+		T2, _ = T2.Add(gens.H[j].Mul(mTilde[i]))
+	}
+
+	disclosedScalars := make([]*big.Int, len(disclosed))
+	for i, index := range disclosed {
+		disclosedScalars[i] = scalars[index]
+	}
+	c := challenge(Abar, Bbar, D, T1, T2, disclosed, disclosedScalars, domain, ph)
+
+	// response returns tilde + sign * secret * c mod order.
+	response := func(tilde *big.Int, secret *big.Int, sign int64) *big.Int {
+		s := new(big.Int).Mul(secret, c)
+		s.Mul(s, big.NewInt(sign))
+		s.Add(s, tilde)
+		return s.Mod(s, order)
+	}
+	r3 := new(big.Int).ModInverse(r2, order)
+	proof := &Proof{
+		Abar: Abar, Bbar: Bbar, D: D,
+		EHat:      response(eTilde, sig.E, 1),
+		R1Hat:     response(r1Tilde, r1, -1),
+		R3Hat:     response(r3Tilde, r3, -1),
+		MHat:      make([]*big.Int, len(undisclosed)),
+		Challenge: c,
+	}
+	for i, j := range undisclosed {
+		proof.MHat[i] = response(mTilde[i], scalars[j], 1)
+	}
+	return proof, nil
+}
+
+// ProofVerify checks a proof of possession of a signature under the public key
+// on the header, and on messages which include disclosedMsgs at the indices
+// disclosed. The total number of messages is the number disclosed plus the
+// number hidden by the proof.
+func ProofVerify(pubKey Point, proof *Proof, header []byte, ph []byte,
+	disclosed []int, disclosedMsgs [][]byte) bool {
+	if len(disclosed) != len(disclosedMsgs) || !validPublicKey(pubKey) {
+		return false
+	}
+	count := len(disclosed) + len(proof.MHat)
+	perm := make([]int, len(disclosed))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.Slice(perm, func(a, b int) bool { return disclosed[perm[a]] < disclosed[perm[b]] })
+	indices, ok := sortedIndices(disclosed, count)
+	if !ok || proof.Abar.Equals(Bls12.GetG1Infinity()) {
+		return false
+	}
+	scalars := make([]*big.Int, len(indices))
+	for i, o := range perm {
+		scalars[i] = hashToScalar(disclosedMsgs[o], mapMessageDST)
+	}
+	undisclosed := complement(indices, count)
+	gens := CreateGenerators(count)
+	domain := calculateDomain(pubKey, gens, header)
+
+	c := proof.Challenge
+	T1 := AggregatePoints([]Point{proof.Bbar.Mul(c), proof.Abar.Mul(proof.EHat), proof.D.Mul(proof.R1Hat)})
+	pts := []Point{commit(gens, domain, scalars, indices).Mul(c), proof.D.Mul(proof.R3Hat)}
+	for i, j := range undisclosed {
+		pts = append(pts, gens.H[j].Mul(proof.MHat[i]))
+	}
+	T2 := AggregatePoints(pts)
+	if c.Cmp(challenge(proof.Abar, proof.Bbar, proof.D, T1, T2, indices, scalars, domain, ph)) != 0 {
+		return false
+	}
+
+	negBbar := proof.Bbar.Mul(new(big.Int).SetInt64(-1))
+	paired, ok := Bls12.PairingProduct([]Point{proof.Abar, negBbar}, []Point{pubKey, Bls12.GetG2()})
+	return ok && Bls12.GetGTIdentity().Equals(paired)
+}
+
+// challenge hashes the disclosed messages and the proof's commitments.
+func challenge(Abar, Bbar, D, T1, T2 Point, disclosed []int,
+	scalars []*big.Int, domain *big.Int, ph []byte) *big.Int {
+	buf := appendUint64(nil, uint64(len(disclosed)))
+	for i, index := range disclosed {
+		buf = appendScalar(appendUint64(buf, uint64(index)), scalars[i])
+	}
+	for _, pt := range []Point{Abar, Bbar, D, T1, T2} {
+		buf = appendPoint(buf, pt)
+	}
+	buf = appendUint64(appendScalar(buf, domain), uint64(len(ph)))
+	return hashToScalar(append(buf, ph...), hashToScalarDST)
+}
+
+// sortedIndices returns a sorted copy of the indices, and whether they are
+// distinct and less than n.
+func sortedIndices(indices []int, n int) ([]int, bool) {
+	sorted := append([]int{}, indices...)
+	sort.Ints(sorted)
+	for i, index := range sorted {
+		if index < 0 || index >= n || (i > 0 && sorted[i-1] == index) {
+			return nil, false
+		}
+	}
+	return sorted, true
+}
+
+// complement returns the indices less than n which aren't in sorted.
+func complement(sorted []int, n int) []int {
+	rest := make([]int, 0, n-len(sorted))
+	for i, j := 0, 0; i < n; i++ {
+		if j < len(sorted) && sorted[j] == i {
+			j++
+			continue
+		}
+		rest = append(rest, i)
+	}
+	return rest
+}
+
+// Marshal serializes the proof as the draft's proof_to_octets.
+func (proof *Proof) Marshal() []byte {
+	var buf []byte
+	for _, pt := range []Point{proof.Abar, proof.Bbar, proof.D} {
+		buf = appendPoint(buf, pt)
+	}
+	for _, s := range append(append([]*big.Int{proof.EHat, proof.R1Hat, proof.R3Hat}, proof.MHat...), proof.Challenge) {
+		buf = appendScalar(buf, s)
+	}
+	return buf
+}
+
+// UnmarshalProof deserializes a proof with the draft's octets_to_proof, which
+// rejects identity points and zero scalars. The number of hidden messages is
+// given by the length.
+func UnmarshalProof(data []byte) (*Proof, bool) {
+	scalars := len(data) - 3*pointSize
+	if scalars < 4*scalarSize || scalars%scalarSize != 0 {
+		return nil, false
+	}
+	r := encoding.NewReader(data)
+	pts := make([]Point, 3)
+	for i := range pts {
+		var ok bool
+		if pts[i], ok = readPoint(r); !ok {
+			return nil, false
+		}
+	}
+	values := make([]*big.Int, scalars/scalarSize)
+	for i := range values {
+		var ok bool
+		if values[i], ok = readScalar(r); !ok {
+			return nil, false
+		}
+	}
+	if !r.Done() {
+		return nil, false
+	}
+	n := len(values)
+	return &Proof{
+		Abar: pts[0], Bbar: pts[1], D: pts[2],
+		EHat: values[0], R1Hat: values[1], R3Hat: values[2],
+		MHat:      values[3 : n-1],
+		Challenge: values[n-1],
+	}, true
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// A proof of knowledge of a signature shows that the prover holds a valid
+// signature (sigma, r) on some message m under a public key, revealing none
+// of sigma, r or m. The prover randomizes sigma as sigma' = rho * sigma, so
+// that verification becomes
+//
+//	e(sigma', U) = gT^rho * e(sigma', g2)^-m * e(sigma', V)^-r
+//
+// which is a statement about discrete logs in GT. The prover proves knowledge
+// of (rho, m, r) with a Schnorr proof, made non interactive with Fiat-Shamir.
+// sigma' is uniformly random, so the proof reveals nothing about the signature.
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+	"golang.org/x/crypto/blake2b"
+)
+
+const proofDomain = "BGLS-BB-signature-proof"
+
+// SignatureProof is a non interactive zero knowledge proof of knowledge of a
+// signature on a hidden message.
+type SignatureProof struct {
+	Sigma     Point
+	Challenge *big.Int
+	ZRho      *big.Int
+	ZM        *big.Int
+	ZR        *big.Int
+}
+
+// ProveSignature creates a proof that the prover knows a signature on a hidden
+// message under pk. The proof is bound to context, which the verifier should
+// choose, e.g. as a nonce, to prevent the proof being replayed.
+func ProveSignature(curve CurveSystem, pk Pubkey, sig Signature, msg *big.Int, context []byte) (SignatureProof, error) {
+	order := curve.GetG1Order()
+	// rho, then the nonces for rho, m and r.
+	scalars := make([]*big.Int, 4)
+	for i := range scalars {
+		s, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return SignatureProof{}, err
+		}
+		if s.Sign() == 0 {
+			return ProveSignature(curve, pk, sig, msg, context)
+		}
+		scalars[i] = s
+	}
+	rho, kRho, kM, kR := scalars[0], scalars[1], scalars[2], scalars[3]
+
+	sigma := sig.Sigma.Mul(rho)
+	bases, ok := proofBases(curve, pk, sigma)
+	if !ok {
+		return SignatureProof{}, errors.New("signature isn't on G1")
+	}
+	t := proofCommitment(curve, bases, kRho, kM, kR)
+	c := proofChallenge(curve, pk, sigma, t, context)
+
+	// response returns k + c * s mod order.
+	response := func(k *big.Int, s *big.Int) *big.Int {
+		z := new(big.Int).Mul(c, s)
+		z.Add(z, k)
+		return z.Mod(z, order)
+	}
+	return SignatureProof{sigma, c, response(kRho, rho), response(kM, msg), response(kR, sig.R)}, nil
+}
+
+// VerifySignatureProof checks a proof that the prover knows a signature on
+// some message under pk, created with the same context.
+func VerifySignatureProof(curve CurveSystem, pk Pubkey, proof SignatureProof, context []byte) bool {
+	order := curve.GetG1Order()
+	for _, s := range []*big.Int{proof.Challenge, proof.ZRho, proof.ZM, proof.ZR} {
+		if s == nil || s.Sign() < 0 || s.Cmp(order) >= 0 {
+			return false
+		}
+	}
+	if proof.Sigma.Equals(curve.GetG1Infinity()) {
+		return false
+	}
+	bases, ok := proofBases(curve, pk, proof.Sigma)
+	if !ok {
+		return false
+	}
+	// t = gT^zRho * e(sigma', g2)^-zM * e(sigma', V)^-zR * e(sigma', U)^-c
+	t := proofCommitment(curve, bases, proof.ZRho, proof.ZM, proof.ZR)
+	t, _ = t.Add(bases[3].Mul(new(big.Int).Sub(order, proof.Challenge)))
+	return proof.Challenge.Cmp(proofChallenge(curve, pk, proof.Sigma, t, context)) == 0
+}
+
+// proofBases returns gT, e(sigma, g2), e(sigma, V) and e(sigma, U).
+func proofBases(curve CurveSystem, pk Pubkey, sigma Point) ([]PointT, bool) {
+	bases := []PointT{curve.GetGT()}
+	for _, q := range []Point{curve.GetG2(), pk.V, pk.U} {
+		b, ok := curve.Pair(sigma, q)
+		if !ok {
+			return nil, false
+		}
+		bases = append(bases, b)
+	}
+	return bases, true
+}
+
+// proofCommitment returns gT^rho * e(sigma, g2)^-m * e(sigma, V)^-r.
+func proofCommitment(curve CurveSystem, bases []PointT, rho *big.Int, m *big.Int, r *big.Int) PointT {
+	order := curve.GetG1Order()
+	t := bases[0].Mul(rho)
+	t, _ = t.Add(bases[1].Mul(new(big.Int).Sub(order, m)))
+	t, _ = t.Add(bases[2].Mul(new(big.Int).Sub(order, r)))
+	return t
+}
+
+func proofChallenge(curve CurveSystem, pk Pubkey, sigma Point, t PointT, context []byte) *big.Int {
+	h, _ := blake2b.New512(nil)
+	h.Write([]byte(proofDomain))
+	for _, b := range [][]byte{pk.U.Marshal(), pk.V.Marshal(), sigma.Marshal(), t.Marshal(), context} {
+		h.Write(encoding.AppendLengthPrefixed(nil, b))
+	}
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, curve.GetG1Order())
+}
+
+// Marshal serializes the proof as the marshalled randomized signature,
+// followed by the challenge and responses as fixed length integers.
+func (proof SignatureProof) Marshal(curve CurveSystem) []byte {
+	out := proof.Sigma.Marshal()
+	for _, s := range []*big.Int{proof.Challenge, proof.ZRho, proof.ZM, proof.ZR} {
+		out = encoding.AppendScalar(out, curve, s)
+	}
+	return out
+}
+
+// UnmarshalSignatureProof deserializes a proof created with Marshal.
+func UnmarshalSignatureProof(curve CurveSystem, data []byte) (SignatureProof, bool) {
+	size := encoding.ScalarSize(curve)
+	scalarsLen := 4 * size
+	if len(data) < scalarsLen {
+		return SignatureProof{}, false
+	}
+	split := len(data) - scalarsLen
+	sigma, ok := curve.UnmarshalG1(append([]byte{}, data[:split]...))
+	if !ok {
+		return SignatureProof{}, false
+	}
+	scalars := make([]*big.Int, 4)
+	for i := range scalars {
+		start := split + i*size
+		scalars[i] = new(big.Int).SetBytes(data[start : start+size])
+		if scalars[i].Cmp(curve.GetG1Order()) >= 0 {
+			return SignatureProof{}, false
+		}
+	}
+	return SignatureProof{sigma, scalars[0], scalars[1], scalars[2], scalars[3]}, true
+}
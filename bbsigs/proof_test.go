@@ -0,0 +1,51 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureProof(t *testing.T) {
+	context := []byte("verifier nonce 1")
+	for _, curve := range curves {
+		sk, pk := KeyGen(curve)
+		msg := big.NewInt(42)
+		sig := Sign(curve, sk, msg)
+		proof, err := ProveSignature(curve, pk, sig, msg, context)
+		assert.Nil(t, err)
+		assert.True(t, VerifySignatureProof(curve, pk, proof, context), "Valid signature proof failed verification")
+		assert.False(t, proof.Sigma.Equals(sig.Sigma), "Proof revealed the signature")
+
+		other, _ := ProveSignature(curve, pk, sig, msg, context)
+		assert.False(t, proof.Sigma.Equals(other.Sigma), "Proofs of one signature are linkable")
+
+		assert.False(t, VerifySignatureProof(curve, pk, proof, []byte("verifier nonce 2")),
+			"Signature proof verified with another context")
+		_, pk2 := KeyGen(curve)
+		assert.False(t, VerifySignatureProof(curve, pk2, proof, context), "Signature proof verified under another key")
+
+		// A signature on another message doesn't prove knowledge of a signature on msg.
+		forged, _ := ProveSignature(curve, pk, Sign(curve, sk, big.NewInt(43)), msg, context)
+		assert.False(t, VerifySignatureProof(curve, pk, forged, context), "Proof verified for the wrong message")
+		forged, _ = ProveSignature(curve, pk, Signature{curve.GetG1().Mul(big.NewInt(2)), sig.R}, msg, context)
+		assert.False(t, VerifySignatureProof(curve, pk, forged, context), "Proof verified for an invalid signature")
+
+		tampered := proof
+		tampered.ZM = new(big.Int).Add(proof.ZM, big.NewInt(1))
+		assert.False(t, VerifySignatureProof(curve, pk, tampered, context), "Tampered signature proof verified")
+		tampered = proof
+		tampered.Sigma = curve.GetG1Infinity()
+		assert.False(t, VerifySignatureProof(curve, pk, tampered, context), "Signature proof with identity sigma verified")
+
+		unmarshalled, ok := UnmarshalSignatureProof(curve, proof.Marshal(curve))
+		assert.True(t, ok)
+		assert.True(t, VerifySignatureProof(curve, pk, unmarshalled, context))
+		_, ok = UnmarshalSignatureProof(curve, proof.Marshal(curve)[1:])
+		assert.False(t, ok, "Unmarshalled a truncated signature proof")
+	}
+}
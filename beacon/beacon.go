@@ -0,0 +1,158 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package beacon verifies rounds of a drand style randomness beacon, where a
+// threshold committee signs the round number, and the randomness is the hash
+// of the signature. The committee's key is from a DKG, such as the dkg
+// package, and the round signatures are combined from partial signatures with
+// bgls.ThresholdCombineSignatures.
+//
+// In a chained beacon, each round's message is
+// SHA-256(previous signature || round), so verifying a round also commits to
+// the whole chain before it. In an unchained beacon, the message is
+// SHA-256(round), so future messages are known in advance, which timelock
+// encryption relies on. The round is encoded as a big endian uint64.
+//
+// drand's default schemes sign on G2 with the group key on G1, and its newer
+// schemes sign on G1 with the key on G2, as bgls does. Either way the
+// randomness is SHA-256 of the signature.
+//
+// Signatures and group keys are in the compressed zcash encoding, and
+// messages are hashed onto the signature group with the hash to curve of
+// RFC 9380 under the scheme's tag, as drand does, so rounds from a real drand
+// network verify.
+package beacon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// Scheme describes how a beacon's messages are built and signed.
+type Scheme struct {
+	// ID is the scheme's name in drand's chain info.
+	ID string
+	// Chained is true if each message includes the previous signature.
+	Chained bool
+	// SigOnG1 is true if signatures are on G1 and the group key is on G2.
+	SigOnG1 bool
+	// DST is the domain separation tag which the scheme hashes messages with.
+	DST []byte
+}
+
+// drand's schemes. UnchainedOnG1 hashes onto G1 with the tag for G2, which
+// was a mistake in drand that UnchainedG1RFC9380 fixes.
+var (
+	PedersenBLSChained   = &Scheme{"pedersen-bls-chained", true, false, []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")}
+	PedersenBLSUnchained = &Scheme{"pedersen-bls-unchained", false, false, []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")}
+	UnchainedOnG1        = &Scheme{"bls-unchained-on-g1", false, true, []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")}
+	UnchainedG1RFC9380   = &Scheme{"bls-unchained-g1-rfc9380", false, true, []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_")}
+)
+
+// SchemeByID returns the scheme with the given ID, or false if it is unknown.
+func SchemeByID(id string) (*Scheme, bool) {
+	for _, scheme := range []*Scheme{PedersenBLSChained, PedersenBLSUnchained, UnchainedOnG1, UnchainedG1RFC9380} {
+		if scheme.ID == id {
+			return scheme, true
+		}
+	}
+	return nil, false
+}
+
+// Beacon is one round of a beacon.
+type Beacon struct {
+	Round     uint64
+	Signature []byte
+	// PreviousSignature is the signature of the previous round. It is only
+	// used by chained schemes.
+	PreviousSignature []byte
+}
+
+// Message returns the message which is signed in the given round. The
+// previous signature is ignored by unchained schemes.
+func (s *Scheme) Message(round uint64, previousSignature []byte) []byte {
+	h := sha256.New()
+	if s.Chained {
+		h.Write(previousSignature)
+	}
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], round)
+	h.Write(encoded[:])
+	return h.Sum(nil)
+}
+
+// Hasher returns the scheme's hash onto its signature group, which is the
+// hash to curve of RFC 9380 with the scheme's tag.
+func (s *Scheme) Hasher() bgls.Hasher {
+	if s.SigOnG1 {
+		return bgls.HashFunc(func(msg []byte) Point { return Bls12HashToG1(msg, s.DST) })
+	}
+	return bgls.HashFunc(func(msg []byte) Point { return Bls12HashToG2(msg, s.DST) })
+}
+
+// UnmarshalGroupKey decodes a zcash encoded group key, as in drand's chain
+// info. The key is on G2 if the scheme signs on G1, and on G1 otherwise.
+func (s *Scheme) UnmarshalGroupKey(data []byte) (Point, bool) {
+	if s.SigOnG1 {
+		return Bls12UnmarshalZcashG2(data)
+	}
+	return Bls12UnmarshalZcashG1(data)
+}
+
+// MarshalSignature returns the zcash encoding of a round's signature, which
+// is what a Beacon holds.
+func (s *Scheme) MarshalSignature(sig Point) []byte {
+	if s.SigOnG1 {
+		return Bls12MarshalZcashG1(sig)
+	}
+	return Bls12MarshalZcashG2(sig)
+}
+
+// Randomness returns the beacon's randomness, which is SHA-256 of its
+// signature. The beacon must be verified first.
+func (b *Beacon) Randomness() []byte {
+	randomness := sha256.Sum256(b.Signature)
+	return randomness[:]
+}
+
+// Verify checks the beacon's signature under the group key. The group key is
+// on G2 if the scheme signs on G1, and on G1 otherwise.
+func Verify(scheme *Scheme, groupKey Point, b *Beacon) bool {
+	curve := Bls12
+	msg := scheme.Message(b.Round, b.PreviousSignature)
+	if scheme.SigOnG1 {
+		sig, ok := Bls12UnmarshalZcashG1(b.Signature)
+		return ok && bgls.VerifySingleSignatureCustHash(curve, sig, groupKey, msg, scheme.Hasher())
+	}
+	sig, ok := Bls12UnmarshalZcashG2(b.Signature)
+	if !ok {
+		return false
+	}
+	// e(pk, H(m)) = e(g1, sig)
+	negG1 := curve.GetG1().Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{groupKey, negG1}, []Point{scheme.Hasher().Hash(msg), sig})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// VerifyChain checks a sequence of consecutive rounds. Every beacon must
+// verify, and in a chained scheme, each beacon's previous signature must be
+// the signature of the beacon before it.
+func VerifyChain(scheme *Scheme, groupKey Point, beacons []*Beacon) bool {
+	for i, b := range beacons {
+		if i > 0 {
+			prev := beacons[i-1]
+			if b.Round != prev.Round+1 || (scheme.Chained && !bytes.Equal(b.PreviousSignature, prev.Signature)) {
+				return false
+			}
+		}
+		if !Verify(scheme, groupKey, b) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package beacon
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessages(t *testing.T) {
+	// The expected digests are SHA-256 of the big endian round, preceded by
+	// the previous signature in chained schemes, computed independently.
+	prev, _ := hex.DecodeString("00112233")
+	vectors := []struct {
+		scheme   *Scheme
+		round    uint64
+		expected string
+	}{
+		{PedersenBLSUnchained, 1, "cd2662154e6d76b2b2b92e70c0cac3ccf534f9b74eb5b89819ec509083d00a50"},
+		{UnchainedG1RFC9380, 367, "a86d7ad3144467d51dd5a90879f2f7f32003a5f08cb288b40969c5ef326bf2fc"},
+		{PedersenBLSChained, 2, "0dec181cac8bbf0f1b9480a28633a2dbd99b64113c2b43414b8b2eaa872b75e0"},
+	}
+	for _, v := range vectors {
+		assert.Equal(t, v.expected, hex.EncodeToString(v.scheme.Message(v.round, prev)), v.scheme.ID)
+	}
+	b := &Beacon{Signature: prev}
+	assert.Equal(t, "aafa373bf008a855815ecb37d8bd52f6a8157cb5833c58edde6d530dbcf3f25d", hex.EncodeToString(b.Randomness()))
+
+	for _, id := range []string{"pedersen-bls-chained", "pedersen-bls-unchained", "bls-unchained-on-g1", "bls-unchained-g1-rfc9380"} {
+		scheme, ok := SchemeByID(id)
+		assert.True(t, ok)
+		assert.Equal(t, id, scheme.ID)
+	}
+	_, ok := SchemeByID("unknown")
+	assert.False(t, ok)
+}
+
+// Real rounds of drand's mainnet, with the group keys from their chain info.
+// The default chain is pedersen-bls-chained, and its first round links to the
+// chain's genesis seed. Quicknet is bls-unchained-g1-rfc9380.
+var drandRounds = []struct {
+	scheme     *Scheme
+	groupKey   string
+	round      uint64
+	previous   string
+	signature  string
+	randomness string
+}{
+	{
+		PedersenBLSChained,
+		"868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31",
+		1,
+		"176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a",
+		"8d61d9100567de44682506aea1a7a6fa6e5491cd27a0a0ed349ef6910ac5ac20ff7bc3e09d7c046566c9f7f3c6f3b10104990e7cb424998203d8f7de586fb7fa5f60045417a432684f85093b06ca91c769f0e7ca19268375e659c2a2352b4655",
+		"101297f1ca7dc44ef6088d94ad5fb7ba03455dc33d53ddb412bbc4564ed986ec",
+	},
+	{
+		UnchainedG1RFC9380,
+		"83cf0f2896adee7eb8b5f01fcad3912212c437e0073e911fb90022d3e760183c8c4b450b6a0a6c3ac6a5776a2d1064510d1fec758c921cc22b0e17e63aaf4bcb5ed66304de9cf809bd274ca73bab4af5a6e9c76a4bc09e76eae8991ef5ece45a",
+		1000,
+		"",
+		"b44679b9a59af2ec876b1a6b1ad52ea9b1615fc3982b19576350f93447cb1125e342b73a8dd2bacbe47e4b6b63ed5e39",
+		"fe290beca10872ef2fb164d2aa4442de4566183ec51c56ff3cd603d930e54fdd",
+	},
+}
+
+func TestDrandRounds(t *testing.T) {
+	for _, v := range drandRounds {
+		keyBytes, _ := hex.DecodeString(v.groupKey)
+		groupKey, ok := v.scheme.UnmarshalGroupKey(keyBytes)
+		assert.True(t, ok, v.scheme.ID)
+		previous, _ := hex.DecodeString(v.previous)
+		signature, _ := hex.DecodeString(v.signature)
+		b := &Beacon{Round: v.round, Signature: signature, PreviousSignature: previous}
+		assert.True(t, Verify(v.scheme, groupKey, b), "drand round failed verification")
+		assert.Equal(t, v.randomness, hex.EncodeToString(b.Randomness()))
+
+		wrongRound := &Beacon{Round: v.round + 1, Signature: signature, PreviousSignature: previous}
+		assert.False(t, Verify(v.scheme, groupKey, wrongRound), "drand round verified as the next round")
+		_, ok = v.scheme.UnmarshalGroupKey(signature)
+		assert.False(t, ok, "Signature decoded as a group key")
+	}
+	// The tag is part of the hash, so quicknet's round doesn't verify under
+	// the older G1 scheme which shares its message format.
+	v := drandRounds[1]
+	keyBytes, _ := hex.DecodeString(v.groupKey)
+	groupKey, _ := UnchainedOnG1.UnmarshalGroupKey(keyBytes)
+	signature, _ := hex.DecodeString(v.signature)
+	assert.False(t, Verify(UnchainedOnG1, groupKey, &Beacon{Round: v.round, Signature: signature}),
+		"Beacon verified with the wrong domain separation tag")
+}
+
+func TestG1Beacons(t *testing.T) {
+	threshold, n := 3, 5
+	shares, vvec, _ := bgls.ThresholdKeyGen(Bls12, threshold, n)
+	for _, scheme := range []*Scheme{UnchainedOnG1, UnchainedG1RFC9380} {
+		hash := scheme.Hasher()
+		beacons := make([]*Beacon, 4)
+		for i := range beacons {
+			round := uint64(i + 1)
+			msg := scheme.Message(round, nil)
+			partials := make([]Point, threshold)
+			indices := make([]int, threshold)
+			for j := 0; j < threshold; j++ {
+				partials[j] = bgls.SignCustHash(shares[j+1].Value, msg, hash)
+				indices[j] = shares[j+1].Index
+			}
+			sig, ok := bgls.ThresholdCombineSignatures(Bls12, partials, indices)
+			assert.True(t, ok)
+			beacons[i] = &Beacon{Round: round, Signature: scheme.MarshalSignature(sig)}
+			assert.True(t, Verify(scheme, vvec[0], beacons[i]), "Valid beacon failed verification")
+		}
+		assert.True(t, VerifyChain(scheme, vvec[0], beacons), "Valid rounds failed verification")
+
+		wrongRound := &Beacon{Round: 5, Signature: beacons[3].Signature}
+		assert.False(t, Verify(scheme, vvec[0], wrongRound), "Beacon verified for the wrong round")
+		assert.False(t, VerifyChain(scheme, vvec[0], []*Beacon{beacons[0], beacons[2]}),
+			"Non-consecutive rounds verified")
+		_, otherKey, _ := bgls.KeyGen(Bls12)
+		assert.False(t, Verify(scheme, otherKey, beacons[0]), "Beacon verified under the wrong key")
+		assert.False(t, Verify(scheme, vvec[0], &Beacon{Round: 1, Signature: []byte{1, 2, 3}}),
+			"Malformed signature verified")
+		assert.False(t, Verify(scheme, vvec[0], &Beacon{Round: 1, Signature: Bls12.GetG1().Mul(big.NewInt(2)).Marshal()}),
+			"Uncompressed signature verified")
+	}
+}
+
+func TestG2Beacons(t *testing.T) {
+	sk, _, _ := bgls.KeyGen(Bls12)
+	groupKey := Bls12.GetG1().Mul(sk)
+	for _, scheme := range []*Scheme{PedersenBLSChained, PedersenBLSUnchained} {
+		previous := []byte("genesis seed")
+		beacons := make([]*Beacon, 4)
+		for i := range beacons {
+			round := uint64(i + 1)
+			sig := bgls.SignCustHash(sk, scheme.Message(round, previous), scheme.Hasher())
+			beacons[i] = &Beacon{Round: round, Signature: scheme.MarshalSignature(sig), PreviousSignature: previous}
+			previous = beacons[i].Signature
+		}
+		assert.True(t, VerifyChain(scheme, groupKey, beacons), "Valid rounds failed verification")
+		assert.Equal(t, 32, len(beacons[0].Randomness()))
+
+		relinked := *beacons[2]
+		relinked.PreviousSignature = beacons[0].Signature
+		chain := []*Beacon{beacons[0], beacons[1], &relinked}
+		if scheme.Chained {
+			assert.False(t, Verify(scheme, groupKey, &relinked), "Chained beacon verified with the wrong link")
+			assert.False(t, VerifyChain(scheme, groupKey, chain), "Broken chain verified")
+		} else {
+			assert.True(t, VerifyChain(scheme, groupKey, chain), "Unchained beacon depends on the previous round")
+		}
+	}
+}
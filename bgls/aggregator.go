@@ -0,0 +1,308 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// An Aggregator incrementally aggregates signatures as they arrive, e.g. from
+// a gossip network, instead of aggregating them all at once. Each signer is
+// identified by its public key, and only its first signature is kept.
+// Signatures aren't verified as they are added. If the final aggregate fails
+// to verify, FindInvalidSignatures or FindInvalidMultiSignatureShares can be
+// used to find the faulty signers, who can then be removed.
+//
+// All four defenses against the rogue public key attack are supported. For
+// Kosk, Distinct Message and ASM signatures, a running sum of the signatures
+// is kept, and removing a signer subtracts its signature. For HAE, the
+// aggregation exponents depend on the final set of keys, so the signatures are
+// only scaled and summed when a snapshot is taken.
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// AggregationMode is the defense against the rogue public key attack that is
+// used by an Aggregator.
+type AggregationMode byte
+
+const (
+	// KoskMode aggregates kosk signatures, created with KoskSign.
+	KoskMode AggregationMode = iota
+	// DistinctMsgMode aggregates distinct message signatures, created with DistinctMsgSign.
+	DistinctMsgMode
+	// HAEMode aggregates standard signatures with hashed aggregation exponents.
+	HAEMode
+	// AsmMode aggregates accountable-subgroup multisignature shares, created
+	// with AmsCreateSignatureShare.
+	AsmMode
+)
+
+// Aggregator accumulates signatures and their signers' keys. It is safe for concurrent use.
+type Aggregator struct {
+	curve     CurveSystem
+	mode      AggregationMode
+	group     *AsmGroup
+	mu        sync.Mutex
+	entries   []aggregatorEntry
+	index     map[string]int
+	aggSig    Point
+	finalized bool
+}
+
+type aggregatorEntry struct {
+	key Point
+	sig Point
+	msg []byte
+}
+
+// Aggregate is a snapshot of an Aggregator. It holds the aggregate signature,
+// and the keys and messages of the signers in the order they were added.
+type Aggregate struct {
+	Mode AggregationMode
+	Sig  Point
+	Keys []Point
+	Msgs [][]byte
+	// AggKey is the sum of Keys.
+	AggKey Point
+	// Signers are the sorted indices of the signers in the ASM group.
+	// This is only set in AsmMode.
+	Signers []int
+	// Apk is the ASM group's aggregate public key. This is only set in AsmMode.
+	Apk Point
+}
+
+// NewAggregator creates an Aggregator for the Kosk, Distinct Message or HAE modes.
+// It returns nil for AsmMode, use NewAsmAggregator instead.
+func NewAggregator(curve CurveSystem, mode AggregationMode) *Aggregator {
+	if mode == AsmMode {
+		return nil
+	}
+	return newAggregator(curve, mode, nil)
+}
+
+// NewAsmAggregator creates an Aggregator for ASM signature shares, from the
+// group of public keys which the membership keys were created for. It returns
+// nil if the group is empty.
+func NewAsmAggregator(curve CurveSystem, group []Point) *Aggregator {
+	return newAggregator(curve, AsmMode, group)
+}
+
+func newAggregator(curve CurveSystem, mode AggregationMode, group []Point) *Aggregator {
+	a := &Aggregator{curve: curve, mode: mode, index: make(map[string]int), aggSig: curve.GetG1Infinity()}
+	if mode == AsmMode {
+		if a.group = NewAsmGroup(curve, group); a.group == nil {
+			return nil
+		}
+	}
+	return a
+}
+
+// Add adds a signer's signature on msg. It returns false if the signer has
+// already been added, if the Aggregator has been finalized, or in AsmMode, if
+// the key isn't in the group.
+func (a *Aggregator) Add(key Point, sig Point, msg []byte) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.finalized {
+		return false
+	}
+	signer := string(key.Marshal())
+	if _, ok := a.index[signer]; ok {
+		return false
+	}
+	if a.mode == AsmMode && a.groupIndex(key) < 0 {
+		return false
+	}
+	a.index[signer] = len(a.entries)
+	a.entries = append(a.entries, aggregatorEntry{key, sig, append([]byte{}, msg...)})
+	if a.mode != HAEMode {
+		a.aggSig, _ = a.aggSig.Add(sig)
+	}
+	return true
+}
+
+// Remove subtracts a signer's signature from the aggregate, e.g. once the
+// signer has been found to be faulty. It returns false if the signer isn't present.
+// Signers can be removed after the Aggregator is finalized.
+func (a *Aggregator) Remove(key Point) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	signer := string(key.Marshal())
+	i, ok := a.index[signer]
+	if !ok {
+		return false
+	}
+	if a.mode != HAEMode {
+		a.aggSig, _ = a.aggSig.Add(a.entries[i].sig.Mul(new(big.Int).SetInt64(-1)))
+	}
+	a.entries = append(a.entries[:i], a.entries[i+1:]...)
+	delete(a.index, signer)
+	for j := i; j < len(a.entries); j++ {
+		a.index[string(a.entries[j].key.Marshal())] = j
+	}
+	return true
+}
+
+// Contains returns true if the signer has been added.
+func (a *Aggregator) Contains(key Point) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.index[string(key.Marshal())]
+	return ok
+}
+
+// Len returns the number of signers.
+func (a *Aggregator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.entries)
+}
+
+// Snapshot returns the aggregate of the signatures added so far. The
+// Aggregator can continue to accept signatures.
+func (a *Aggregator) Snapshot() *Aggregate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.snapshot()
+}
+
+// Finalize returns the aggregate of the signatures added so far, and stops the
+// Aggregator from accepting any more signatures.
+func (a *Aggregator) Finalize() *Aggregate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.finalized = true
+	return a.snapshot()
+}
+
+func (a *Aggregator) snapshot() *Aggregate {
+	agg := &Aggregate{Mode: a.mode, Keys: make([]Point, len(a.entries)), Msgs: make([][]byte, len(a.entries))}
+	sigs := make([]Point, len(a.entries))
+	for i, entry := range a.entries {
+		agg.Keys[i] = entry.key
+		agg.Msgs[i] = entry.msg
+		sigs[i] = entry.sig
+	}
+	agg.AggKey = a.curve.GetG2Infinity()
+	if len(a.entries) > 0 {
+		agg.AggKey = AggregateKeys(agg.Keys)
+	}
+	agg.Sig = a.aggSig.Copy()
+	if a.mode == HAEMode && len(a.entries) > 0 {
+		agg.Sig = AggregateSignaturesWithHAE(sigs, agg.Keys)
+	}
+	if a.mode == AsmMode && a.group != nil {
+		agg.Apk = a.group.Apk()
+		agg.Signers = make([]int, len(a.entries))
+		for i, entry := range a.entries {
+			agg.Signers[i] = a.groupIndex(entry.key)
+		}
+		sort.Ints(agg.Signers)
+	}
+	return agg
+}
+
+// groupIndex returns the index of key in the ASM group, or -1 if it isn't present.
+func (a *Aggregator) groupIndex(key Point) int {
+	if a.group == nil {
+		return -1
+	}
+	return a.group.Index(key)
+}
+
+// Verify verifies the aggregate signature, using the verification method for its mode.
+// When every signer signed the same message, the faster multi signature
+// verification is used.
+func (agg *Aggregate) Verify(curve CurveSystem) bool {
+	if len(agg.Keys) == 0 || len(agg.Keys) != len(agg.Msgs) {
+		return false
+	}
+	sameMsg := len(distinctMessages(agg.Msgs)) == 1
+	switch agg.Mode {
+	case KoskMode:
+		if sameMsg {
+			return KoskVerifyMultiSignature(curve, agg.Sig, agg.Keys, agg.Msgs[0])
+		}
+		return KoskVerifyAggregateSignature(curve, agg.Sig, agg.Keys, agg.Msgs)
+	case DistinctMsgMode:
+		return DistinctMsgVerifyAggregateSignature(curve, agg.Sig, agg.Keys, agg.Msgs)
+	case HAEMode:
+		if sameMsg {
+			return VerifyMultiSignatureWithHAE(curve, agg.Sig, agg.Keys, agg.Msgs[0])
+		}
+		return VerifyAggregateSignatureWithHAE(curve, agg.Sig, agg.Keys, agg.Msgs)
+	case AsmMode:
+		if !sameMsg {
+			return false
+		}
+		return AmsVerifySignature(curve, agg.Apk, agg.Signers, agg.AggKey, agg.Sig, agg.Msgs[0])
+	}
+	return false
+}
+
+func distinctMessages(msgs [][]byte) [][]byte {
+	distinct, _ := groupMessages(msgs)
+	return distinct
+}
+
+// Marshal serializes the Aggregator's state, including every individual
+// signature, so that aggregation can be resumed with UnmarshalAggregator.
+func (a *Aggregator) Marshal() []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	finalized := byte(0)
+	if a.finalized {
+		finalized = 1
+	}
+	buf := []byte{byte(a.mode), finalized}
+	if a.group == nil {
+		buf = encoding.AppendUint32(buf, 0)
+	} else {
+		buf = encoding.AppendUint32(buf, uint32(a.group.Size()))
+		for _, key := range a.group.keys {
+			buf = encoding.AppendLengthPrefixed(buf, key.Marshal())
+		}
+	}
+	buf = encoding.AppendUint32(buf, uint32(len(a.entries)))
+	for _, entry := range a.entries {
+		buf = encoding.AppendLengthPrefixed(buf, entry.key.Marshal())
+		buf = encoding.AppendLengthPrefixed(buf, entry.sig.Marshal())
+		buf = encoding.AppendLengthPrefixed(buf, entry.msg)
+	}
+	return buf
+}
+
+// UnmarshalAggregator restores an Aggregator which was serialized with Marshal.
+func UnmarshalAggregator(curve CurveSystem, data []byte) (*Aggregator, bool) {
+	r := encoding.NewReader(data)
+	mode := AggregationMode(r.Byte())
+	finalized := r.Byte()
+	if mode > AsmMode || finalized > 1 {
+		return nil, false
+	}
+	group := make([]Point, 0)
+	for i, n := uint32(0), r.Uint32(); i < n && r.OK(); i++ {
+		group = append(group, r.G2(curve))
+	}
+	if !r.OK() || (mode == AsmMode) != (len(group) > 0) {
+		return nil, false
+	}
+	a := newAggregator(curve, mode, group)
+	for i, n := uint32(0), r.Uint32(); i < n && r.OK(); i++ {
+		key := r.G2(curve)
+		sig := r.G1(curve)
+		msg := r.LengthPrefixed()
+		if !r.OK() || !a.Add(key, sig, msg) {
+			return nil, false
+		}
+	}
+	if !r.Done() {
+		return nil, false
+	}
+	a.finalized = finalized == 1
+	return a, true
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregatorModes(t *testing.T) {
+	signFuncs := map[AggregationMode]func(CurveSystem, *big.Int, []byte) Point{
+		KoskMode:        KoskSign,
+		DistinctMsgMode: DistinctMsgSign,
+		HAEMode:         Sign,
+	}
+	for _, curve := range curves {
+		for mode, sign := range signFuncs {
+			N := 5
+			msg := make([]byte, 32)
+			rand.Read(msg)
+			sks := make([]*big.Int, N)
+			pubkeys := make([]Point, N)
+			for i := 0; i < N; i++ {
+				sks[i], pubkeys[i], _ = KeyGen(curve)
+			}
+			agg := NewAggregator(curve, mode)
+			for i := 0; i < N; i++ {
+				assert.True(t, agg.Add(pubkeys[i], sign(curve, sks[i], msg), msg))
+				assert.True(t, agg.Snapshot().Verify(curve), "Snapshot failed to verify")
+			}
+			assert.False(t, agg.Add(pubkeys[0], sign(curve, sks[0], msg), msg),
+				"Aggregator accepted a duplicate signer")
+			assert.Equal(t, N, agg.Len())
+
+			// A faulty signer is found and subtracted
+			otherMsg := make([]byte, 32)
+			rand.Read(otherMsg)
+			sk, vk, _ := KeyGen(curve)
+			assert.True(t, agg.Add(vk, sign(curve, sk, otherMsg), msg))
+			assert.False(t, agg.Snapshot().Verify(curve), "Aggregate with a faulty signer verified")
+			assert.True(t, agg.Remove(vk))
+			assert.False(t, agg.Remove(vk))
+			assert.False(t, agg.Contains(vk))
+			assert.True(t, agg.Snapshot().Verify(curve), "Aggregate failed after removing the faulty signer")
+
+			// Aggregation of different messages
+			assert.True(t, agg.Add(vk, sign(curve, sk, otherMsg), otherMsg))
+			aggregate := agg.Finalize()
+			assert.True(t, aggregate.Verify(curve), "Aggregate of different messages failed to verify")
+			assert.Equal(t, N+1, len(aggregate.Keys))
+			assert.False(t, agg.Add(pubkeys[0], sign(curve, sks[0], msg), msg),
+				"Finalized aggregator accepted a signature")
+
+			restored, ok := UnmarshalAggregator(curve, agg.Marshal())
+			assert.True(t, ok, "Unmarshalling the aggregator failed")
+			assert.True(t, restored.Finalize().Sig.Equals(aggregate.Sig), "Restored aggregator differs")
+			_, ok = UnmarshalAggregator(curve, agg.Marshal()[1:])
+			assert.False(t, ok, "Unmarshalling a truncated aggregator succeeded")
+		}
+	}
+}
+
+func TestAsmAggregator(t *testing.T) {
+	numKeys := 6
+	for _, curve := range curves {
+		pubkeys := make([]Point, numKeys)
+		sks := make([]*big.Int, numKeys)
+		for i := 0; i < numKeys; i++ {
+			sks[i], pubkeys[i], _ = KeyGen(curve)
+		}
+		group := NewAsmGroup(curve, pubkeys)
+		mks := createMembershipKeys(t, group, sks)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		agg := NewAsmAggregator(curve, pubkeys)
+		assert.Nil(t, NewAggregator(curve, AsmMode))
+		// Signatures arrive out of order
+		for _, i := range []int{4, 1, 3} {
+			assert.True(t, agg.Add(pubkeys[i], group.CreateSignatureShare(sks[i], mks[i], msg), msg))
+		}
+		_, outsider, _ := KeyGen(curve)
+		assert.False(t, agg.Add(outsider, curve.GetG1(), msg), "Key outside of the group was accepted")
+		aggregate := agg.Snapshot()
+		assert.Equal(t, []int{1, 3, 4}, aggregate.Signers)
+		assert.True(t, aggregate.Verify(curve), "ASM aggregate failed to verify")
+		assert.True(t, agg.Remove(pubkeys[3]))
+		aggregate = agg.Finalize()
+		assert.Equal(t, []int{1, 4}, aggregate.Signers)
+		assert.True(t, aggregate.Verify(curve), "ASM aggregate failed to verify after removal")
+
+		restored, ok := UnmarshalAggregator(curve, agg.Marshal())
+		assert.True(t, ok)
+		assert.True(t, restored.Snapshot().Verify(curve), "Restored ASM aggregate failed to verify")
+
+		// Corrupt the first group key, which follows the mode, finalized flag,
+		// group size and the key's length prefix.
+		corrupted := agg.Marshal()
+		keyLen := len(pubkeys[0].Marshal())
+		for i := 10; i < 10+keyLen; i++ {
+			corrupted[i] = 0xff
+		}
+		_, ok = UnmarshalAggregator(curve, corrupted)
+		assert.False(t, ok, "Unmarshalled an ASM aggregator with a corrupt group key")
+
+		// An ASM aggregator needs a group, and other modes don't have one.
+		assert.Nil(t, NewAsmAggregator(curve, nil))
+		empty := []byte{byte(AsmMode), 0, 0, 0, 0, 0, 0, 0, 0, 0}
+		_, ok = UnmarshalAggregator(curve, empty)
+		assert.False(t, ok, "Unmarshalled an ASM aggregator with an empty group")
+		_, ok = UnmarshalAggregator(curve, empty[:6])
+		assert.False(t, ok, "Unmarshalled a truncated ASM aggregator")
+		withGroup := append([]byte{byte(KoskMode)}, agg.Marshal()[1:]...)
+		_, ok = UnmarshalAggregator(curve, withGroup)
+		assert.False(t, ok, "Unmarshalled a group for an aggregator which isn't in AsmMode")
+	}
+}
@@ -4,9 +4,9 @@
 package bgls
 
 import (
+	"context"
 	"crypto/rand"
 	"math/big"
-	"sync"
 
 	. "github.com/Project-Arda/bgls/curves" // nolint: golint
 )
@@ -44,27 +44,27 @@ func LoadPublicKey(curve CurveSystem, sk *big.Int) Point {
 
 // Sign creates a standard BLS signature on a message with a private key
 func Sign(curve CurveSystem, sk *big.Int, msg []byte) Point {
-	return SignCustHash(sk, msg, curve.HashToG1)
+	return SignCustHash(sk, msg, DefaultHasher(curve))
 }
 
 // SignCustHash creates a standard BLS signature on a message with a private key,
-// using a supplied function to hash onto the curve where signatures lie.
-func SignCustHash(sk *big.Int, msg []byte, hash func([]byte) Point) Point {
-	h := hash(msg)
+// using a supplied Hasher to hash onto the curve where signatures lie.
+func SignCustHash(sk *big.Int, msg []byte, hash Hasher) Point {
+	h := hash.Hash(msg)
 	i := h.Mul(sk)
 	return i
 }
 
 // VerifySingleSignature checks that a single standard BLS signature is valid
 func VerifySingleSignature(curve CurveSystem, sig Point, pubKey Point, msg []byte) bool {
-	return VerifySingleSignatureCustHash(curve, sig, pubKey, msg, curve.HashToG1)
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, msg, DefaultHasher(curve))
 }
 
 // VerifySingleSignatureCustHash checks that a single standard BLS signature is
-// valid, using the supplied hash function to hash onto the curve where signatures lie.
+// valid, using the supplied Hasher to hash onto the curve where signatures lie.
 func VerifySingleSignatureCustHash(curve CurveSystem, sig Point, pubkey Point,
-	msg []byte, hash func([]byte) Point) bool {
-	h := hash(msg).Mul(new(big.Int).SetInt64(-1))
+	msg []byte, hash Hasher) bool {
+	h := hash.Hash(msg).Mul(new(big.Int).SetInt64(-1))
 	paired, _ := curve.PairingProduct([]Point{h, sig}, []Point{pubkey, curve.GetG2()})
 	return curve.GetGTIdentity().Equals(paired)
 }
@@ -80,42 +80,168 @@ func (a *AggSig) Verify(curve CurveSystem) bool {
 // If duplicate messages should be allowed, one of the protections against the
 // rogue public-key attack should be used. See doc.go for more details.
 func VerifyAggregateSignature(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
-	return verifyAggSig(curve, aggsig, keys, msgs, false)
+	return VerifyAggregateSignatureCustHash(curve, aggsig, keys, msgs, DefaultHasher(curve))
+}
+
+// VerifyAggregateSignatureCustHash verifies that the aggregated signature proves
+// that all messages were signed by the associated keys, using the supplied
+// Hasher to hash onto the curve where signatures lie. This will fail if there
+// are duplicate messages.
+func VerifyAggregateSignatureCustHash(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, hash Hasher) bool {
+	return verifyAggSig(curve, aggsig, keys, msgs, false, hash)
+}
+
+// VerifyAggregateSignatureContext is VerifyAggregateSignature, but stops early
+// and returns ctx.Err() if ctx is cancelled.
+func VerifyAggregateSignatureContext(ctx context.Context, curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) (bool, error) {
+	return verifyAggSigContext(ctx, curve, aggsig, keys, msgs, false, DefaultHasher(curve))
 }
 
 // verifyMultiSignature checks that the aggregate signature correctly proves
 // that a single message has been signed by a set of keys. This is
 // vulnerable to the rogue public attack, so one of the defense mechanisms should be used.
-func verifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
-	vs := AggregatePoints(keys)
-	return VerifySingleSignature(curve, aggsig, vs, msg)
+func verifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []byte, hash Hasher) bool {
+	ok, _ := verifyMultiSignatureContext(context.Background(), curve, aggsig, keys, msg, hash)
+	return ok
+}
+
+func verifyMultiSignatureContext(ctx context.Context, curve CurveSystem, aggsig Point, keys []Point,
+	msg []byte, hash Hasher) (bool, error) {
+	vs, err := AggregatePointsContext(ctx, keys)
+	if err != nil || vs == nil {
+		return false, err
+	}
+	return VerifySingleSignatureCustHash(curve, aggsig, vs, msg, hash), ctx.Err()
+}
+
+func verifyAggSig(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, allowDuplicates bool, hash Hasher) bool {
+	ok, _ := verifyAggSigContext(context.Background(), curve, aggsig, keys, msgs, allowDuplicates, hash)
+	return ok
 }
 
-func verifyAggSig(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, allowDuplicates bool) bool {
+func verifyAggSigContext(ctx context.Context, curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool, hash Hasher) (bool, error) {
 	if len(keys) != len(msgs) {
-		return false
+		return false, ctx.Err()
 	}
 	if !allowDuplicates {
 		if containsDuplicateMessage(msgs) {
-			return false
+			return false, ctx.Err()
 		}
 	}
-	pts1 := make([]Point, len(keys)+1)
-	pts2 := make([]Point, len(keys)+1)
-	var wg sync.WaitGroup
-	wg.Add(len(msgs))
-	for i := 0; i < len(msgs); i++ {
-		go concurrentHash(curve, i, pts1, msgs[i], &wg)
-		pts2[i] = keys[i]
+	distinctMsgs, msgIndex := groupMessages(msgs)
+	hashes, err := hashMessages(ctx, hash, distinctMsgs)
+	if err != nil {
+		return false, err
 	}
-	wg.Wait()
-	pts1[len(keys)] = aggsig.Mul(new(big.Int).SetInt64(-1))
-	pts2[len(keys)] = curve.GetG2()
-	aggPt, ok := curve.PairingProduct(pts1, pts2)
+	pts1, pts2, err := groupPairings(ctx, keys, hashes, msgIndex, nil)
+	if err != nil {
+		return false, err
+	}
+	pts1 = append(pts1, aggsig.Mul(new(big.Int).SetInt64(-1)))
+	pts2 = append(pts2, curve.GetG2())
+	aggPt, ok, err := PairingProductContext(ctx, curve, pts1, pts2)
 	if ok {
-		return aggPt.Equals(curve.GetGTIdentity())
+		return aggPt.Equals(curve.GetGTIdentity()), err
 	}
-	return ok
+	return ok, err
+}
+
+// hashMessages hashes each of the messages in parallel, using the Executor.
+func hashMessages(ctx context.Context, hash Hasher, msgs [][]byte) ([]Point, error) {
+	hashes := make([]Point, len(msgs))
+	err := GetExecutor().Run(ctx, len(msgs), func(i int) {
+		hashes[i] = hash.Hash(msgs[i])
+	})
+	return hashes, err
+}
+
+// groupMessages returns the distinct messages, and the index into the distinct
+// messages for each of the provided messages.
+func groupMessages(msgs [][]byte) (distinctMsgs [][]byte, msgIndex []int) {
+	indices := make(map[string]int)
+	msgIndex = make([]int, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		index, ok := indices[string(msgs[i])]
+		if !ok {
+			index = len(distinctMsgs)
+			indices[string(msgs[i])] = index
+			distinctMsgs = append(distinctMsgs, msgs[i])
+		}
+		msgIndex[i] = index
+	}
+	return
+}
+
+// groupPairings returns the points to pair to compute
+// prod_i e(exps[i] * hashes[msgIndex[i]], keys[i]), with as few pairings as possible.
+// If there are fewer distinct messages than distinct keys, the keys signing
+// the same message are summed, and paired with that message's hash. Otherwise
+// the hashes of all messages signed by the same key are summed, and paired with
+// that key. If exps is nil, every exponent is taken to be one.
+func groupPairings(ctx context.Context, keys []Point, hashes []Point, msgIndex []int,
+	exps []*big.Int) (pts1 []Point, pts2 []Point, err error) {
+	// Only use the hashes of messages which are actually signed
+	usedMsgs := make(map[int]int)
+	usedHashes := make([]Point, 0)
+	usedMsgIndex := make([]int, len(keys))
+	for i := 0; i < len(keys); i++ {
+		index, ok := usedMsgs[msgIndex[i]]
+		if !ok {
+			index = len(usedHashes)
+			usedMsgs[msgIndex[i]] = index
+			usedHashes = append(usedHashes, hashes[msgIndex[i]])
+		}
+		usedMsgIndex[i] = index
+	}
+	keyIndices := make(map[string]int)
+	keyIndex := make([]int, len(keys))
+	distinctKeys := make([]Point, 0)
+	for i := 0; i < len(keys); i++ {
+		marshalled := string(keys[i].MarshalUncompressed())
+		index, ok := keyIndices[marshalled]
+		if !ok {
+			index = len(distinctKeys)
+			keyIndices[marshalled] = index
+			distinctKeys = append(distinctKeys, keys[i])
+		}
+		keyIndex[i] = index
+	}
+	if len(usedHashes) <= len(distinctKeys) {
+		scaledKeys, err := ScalePointsContext(ctx, keys, exps)
+		if err != nil {
+			return nil, nil, err
+		}
+		keySums, err := sumGroups(ctx, scaledKeys, usedMsgIndex, len(usedHashes))
+		return usedHashes, keySums, err
+	}
+	msgHashes := make([]Point, len(keys))
+	for i := 0; i < len(keys); i++ {
+		msgHashes[i] = usedHashes[usedMsgIndex[i]]
+	}
+	scaledHashes, err := ScalePointsContext(ctx, msgHashes, exps)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashSums, err := sumGroups(ctx, scaledHashes, keyIndex, len(distinctKeys))
+	return hashSums, distinctKeys, err
+}
+
+// sumGroups returns the sum of the points in each of the numGroups groups,
+// where groupIndex[i] is the group which pts[i] belongs to.
+func sumGroups(ctx context.Context, pts []Point, groupIndex []int, numGroups int) ([]Point, error) {
+	groups := make([][]Point, numGroups)
+	for i := 0; i < len(pts); i++ {
+		groups[groupIndex[i]] = append(groups[groupIndex[i]], pts[i])
+	}
+	sums := make([]Point, numGroups)
+	for i := 0; i < numGroups; i++ {
+		var err error
+		if sums[i], err = AggregatePointsContext(ctx, groups[i]); err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
 }
 
 // AggregateSignatures aggregates an array of signatures into one aggsig.
@@ -124,16 +250,22 @@ func AggregateSignatures(sigs []Point) Point {
 	return AggregatePoints(sigs)
 }
 
+// AggregateSignaturesContext aggregates an array of signatures into one aggsig.
+// It stops early and returns ctx.Err() if ctx is cancelled.
+func AggregateSignaturesContext(ctx context.Context, sigs []Point) (Point, error) {
+	return AggregatePointsContext(ctx, sigs)
+}
+
 // AggregateKeys sums an array of public keys into one key.
 // This wrapper only exists so end-users don't have to use the method from curve
 func AggregateKeys(keys []Point) Point {
 	return AggregatePoints(keys)
 }
 
-// concurrentHash hashes the message and sends the result down the channel.
-func concurrentHash(curve CurveSystem, i int, pts []Point, msg []byte, wg *sync.WaitGroup) {
-	pts[i] = curve.HashToG1(msg)
-	wg.Done()
+// AggregateKeysContext sums an array of public keys into one key.
+// It stops early and returns ctx.Err() if ctx is cancelled.
+func AggregateKeysContext(ctx context.Context, keys []Point) (Point, error) {
+	return AggregatePointsContext(ctx, keys)
 }
 
 func containsDuplicateMessage(msgs [][]byte) bool {
@@ -4,6 +4,7 @@
 package bgls
 
 import (
+	"context"
 	"crypto/rand"
 	"math/big"
 	"os"
@@ -76,6 +77,95 @@ func TestAggregation(t *testing.T) {
 	}
 }
 
+func TestAggregationGroupsKeysAndMessages(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 3, 32
+		sks := make([]*big.Int, N)
+		vks := make([]Point, N)
+		distinctMsgs := make([][]byte, N+1)
+		for i := 0; i < N; i++ {
+			sks[i], vks[i], _ = KeyGen(curve)
+		}
+		for i := 0; i < N+1; i++ {
+			distinctMsgs[i] = make([]byte, Size)
+			rand.Read(distinctMsgs[i])
+		}
+		// Every key signs every message
+		keys := make([]Point, 0)
+		msgs := make([][]byte, 0)
+		sigs := make([]Point, 0)
+		for i := 0; i < N; i++ {
+			for j := 0; j < N+1; j++ {
+				keys = append(keys, vks[i])
+				msgs = append(msgs, distinctMsgs[j])
+				sigs = append(sigs, KoskSign(curve, sks[i], distinctMsgs[j]))
+			}
+		}
+		aggSig := AggregateSignatures(sigs)
+		assert.True(t, KoskVerifyAggregateSignature(curve, aggSig, keys, msgs),
+			"Aggregate signature with repeated keys and messages failed")
+		assert.False(t, KoskVerifyAggregateSignature(curve, aggSig, keys[1:], msgs[1:]),
+			"Aggregate signature succeeded with a missing signer")
+
+		hashes := make([]Point, N+1)
+		for i := 0; i < N+1; i++ {
+			hashes[i] = curve.HashToG1(distinctMsgs[i])
+		}
+		msgIndex := make([]int, len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			msgIndex[i] = i % (N + 1)
+		}
+		pts1, pts2, _ := groupPairings(context.Background(), keys, hashes, msgIndex, nil)
+		assert.Equal(t, N, len(pts1), "Keys were not grouped")
+		assert.Equal(t, N, len(pts2), "Keys were not grouped")
+		// The first key signing every message
+		pts1, _, _ = groupPairings(context.Background(), keys[:N+1], hashes, msgIndex[:N+1], nil)
+		assert.Equal(t, 1, len(pts1), "Keys were not grouped")
+		// Every key signing the first message
+		pts1, _, _ = groupPairings(context.Background(), vks, hashes, make([]int, N), nil)
+		assert.Equal(t, 1, len(pts1), "Messages were not grouped")
+	}
+}
+
+func TestAggregationContext(t *testing.T) {
+	defer SetExecutor(GetExecutor())
+	SetExecutor(SerialExecutor)
+	for _, curve := range curves {
+		N, Size := 4, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			pubkeys[i] = vk
+		}
+		ctx := context.Background()
+		aggSig, err := AggregateSignaturesContext(ctx, sigs)
+		assert.Nil(t, err)
+		ok, err := VerifyAggregateSignatureContext(ctx, curve, aggSig, pubkeys, msgs)
+		assert.True(t, ok, "Aggregate signature verification with a context failed")
+		assert.Nil(t, err)
+		haeSig, err := AggregateSignaturesWithHAEContext(ctx, sigs, pubkeys)
+		assert.Nil(t, err)
+		ok, _ = VerifyAggregateSignatureWithHAEContext(ctx, curve, haeSig, pubkeys, msgs)
+		assert.True(t, ok, "HAE aggregate signature verification with a context failed")
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = AggregateSignaturesContext(cancelled, sigs)
+		assert.Equal(t, context.Canceled, err)
+		ok, err = VerifyAggregateSignatureContext(cancelled, curve, aggSig, pubkeys, msgs)
+		assert.False(t, ok, "Verification succeeded after cancellation")
+		assert.Equal(t, context.Canceled, err)
+		ok, err = KoskVerifyMultiSignatureContext(cancelled, curve, aggSig, pubkeys, msgs[0])
+		assert.False(t, ok, "Verification succeeded after cancellation")
+		assert.Equal(t, context.Canceled, err)
+	}
+}
+
 func BenchmarkKeygen(b *testing.B) {
 	b.ResetTimer()
 	curve := Altbn128
@@ -0,0 +1,128 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/bits"
+
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Bitfield is a subset of the members of a group of fixed size, such as the
+// signers of an accountable-subgroup multisignature. Bit i is set if member i
+// is in the subset.
+type Bitfield struct {
+	size int
+	bits []byte
+}
+
+// NewBitfield creates an empty Bitfield for a group of the given size.
+func NewBitfield(size int) *Bitfield {
+	if size < 0 {
+		size = 0
+	}
+	return &Bitfield{size, make([]byte, (size+7)/8)}
+}
+
+// BitfieldFromIndices creates a Bitfield with the given members set. It returns
+// false if an index is out of range.
+func BitfieldFromIndices(size int, indices []int) (*Bitfield, bool) {
+	b := NewBitfield(size)
+	for _, i := range indices {
+		if !b.Set(i) {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+// Size returns the size of the group.
+func (b *Bitfield) Size() int {
+	return b.size
+}
+
+// Set adds member i to the subset. It returns false if i is out of range.
+func (b *Bitfield) Set(i int) bool {
+	if i < 0 || i >= b.size {
+		return false
+	}
+	b.bits[i/8] |= 1 << uint(i%8)
+	return true
+}
+
+// Unset removes member i from the subset. It returns false if i is out of range.
+func (b *Bitfield) Unset(i int) bool {
+	if i < 0 || i >= b.size {
+		return false
+	}
+	b.bits[i/8] &^= 1 << uint(i%8)
+	return true
+}
+
+// IsSet returns true if member i is in the subset.
+func (b *Bitfield) IsSet(i int) bool {
+	if i < 0 || i >= b.size {
+		return false
+	}
+	return b.bits[i/8]&(1<<uint(i%8)) != 0
+}
+
+// Count returns the number of members in the subset.
+func (b *Bitfield) Count() int {
+	count := 0
+	for _, x := range b.bits {
+		count += bits.OnesCount8(x)
+	}
+	return count
+}
+
+// Indices returns the members in the subset, in increasing order.
+func (b *Bitfield) Indices() []int {
+	indices := make([]int, 0, b.Count())
+	for i := 0; i < b.size; i++ {
+		if b.IsSet(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Copy returns a copy of the Bitfield.
+func (b *Bitfield) Copy() *Bitfield {
+	return &Bitfield{b.size, append([]byte{}, b.bits...)}
+}
+
+// Equals returns true if both Bitfields have the same size and members.
+func (b *Bitfield) Equals(other *Bitfield) bool {
+	if b.size != other.size {
+		return false
+	}
+	for i := range b.bits {
+		if b.bits[i] != other.bits[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal serializes the Bitfield as the big endian uint32 size of the group,
+// followed by the bits, with member 0 in the lowest bit of the first byte.
+func (b *Bitfield) Marshal() []byte {
+	return append(encoding.AppendUint32(nil, uint32(b.size)), b.bits...)
+}
+
+// UnmarshalBitfield deserializes a Bitfield created with Marshal. It rejects
+// encodings with bits set beyond the size of the group.
+func UnmarshalBitfield(data []byte) (*Bitfield, bool) {
+	r := encoding.NewReader(data)
+	size := int(r.Uint32())
+	if !r.OK() || r.Len() != (size+7)/8 {
+		return nil, false
+	}
+	b := &Bitfield{size, r.Bytes(r.Len())}
+	if size%8 != 0 && b.bits[len(b.bits)-1]>>uint(size%8) != 0 {
+		return nil, false
+	}
+	return b, true
+}
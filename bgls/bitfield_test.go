@@ -0,0 +1,39 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitfield(t *testing.T) {
+	b, ok := BitfieldFromIndices(11, []int{10, 0, 3})
+	assert.True(t, ok)
+	assert.Equal(t, 11, b.Size())
+	assert.Equal(t, 3, b.Count())
+	assert.Equal(t, []int{0, 3, 10}, b.Indices())
+	assert.True(t, b.IsSet(3))
+	assert.False(t, b.IsSet(4))
+	assert.False(t, b.Set(11), "Set an index out of range")
+	assert.False(t, b.IsSet(-1))
+
+	c := b.Copy()
+	assert.True(t, c.Unset(3))
+	assert.False(t, b.Equals(c))
+	assert.True(t, b.IsSet(3), "Copy shares state with the original")
+
+	decoded, ok := UnmarshalBitfield(b.Marshal())
+	assert.True(t, ok)
+	assert.True(t, b.Equals(decoded))
+	data := b.Marshal()
+	data[len(data)-1] |= 0x80
+	_, ok = UnmarshalBitfield(data)
+	assert.False(t, ok, "Bitfield with a bit beyond its size was unmarshalled")
+	_, ok = UnmarshalBitfield(data[:len(data)-1])
+	assert.False(t, ok, "Truncated bitfield was unmarshalled")
+	_, ok = BitfieldFromIndices(4, []int{4})
+	assert.False(t, ok)
+}
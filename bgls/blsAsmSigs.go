@@ -3,52 +3,383 @@
 
 package bgls
 
+// Implementation of accountable-subgroup multisignatures (https://eprint.iacr.org/2018/483.pdf)
+// The acronyms for these schemes are based upon the acronyms that were used inside the paper.
+//
+// ASM lets any subset of a fixed group of n public keys create a multisignature,
+// which the verifier checks against the group's aggregate public key and the
+// subset of signers, so every signer is held accountable. The group's aggregate
+// public key is apk = sum_i a_i * pk_i, where a_i are the hashed aggregation
+// exponents of the group's keys (see blsHAE.go).
+//
+// Each member first needs a membership key. Member i sends member j the
+// membership key share a_i * sk_i * H2(apk, j), and member j sums the shares it
+// receives into its membership key mk_j = sk_apk * H2(apk, j), where sk_apk is
+// the discrete log of apk. Anyone can check a membership key share against
+// pk_i, and a membership key against apk, with a pairing. A member's signature
+// share on m is sk_j * H0(m) + mk_j, and the shares of the signers S are
+// summed into the aggregate signature. Along with the aggregate key
+// pk_S = sum_{j in S} pk_j, this is verified with
+//     e(aggSig, g2) = e(H0(m), pk_S) * e(sum_{j in S} H2(apk, j), apk)
+//
+// AsmGroup caches apk, the aggregation exponents, and the hashes of the
+// signers' indices, so it should be created once per group. The signers are
+// represented as a Bitfield, and an AsmSignature holds the aggregate signature
+// and signers. The verifier computes pk_S from the signers itself, since a
+// signer holding mk_j could otherwise pick pk_S = x * g2 and sign
+// x * H0(m) + mk_j for any m without sk_j.
+//
+// The compact scheme (MSP / AMSP in the paper) doesn't need membership keys,
+// but isn't accountable to a subset chosen after key setup. Member i signs
+// AmspGetMessage(apk, m) = apk || m, and scales its signature by a_i. The
+// shares of the signers S are summed, and verified as a standard signature
+// under sum_{j in S} a_j * pk_j.
+
 import (
-	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"errors"
 	"math/big"
-	"strconv"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
 )
 
-// Implementation of accountable-subgroup multisignatures (https://eprint.iacr.org/2018/483.pdf)
-// The acronyms for these schemes are based upon the acronyms that were used inside the paper.
+// AsmGroup is a group of public keys, any subset of which can create
+// accountable-subgroup multisignatures. It is safe for concurrent use.
+type AsmGroup struct {
+	curve CurveSystem
+	keys  []Point
+	exps  []*big.Int
+	apk   Point
+	index map[string]int
+
+	mu       sync.Mutex
+	h2Hashes []Point
+}
 
-// TODO: write comments
+// MembershipKeyShare is the share of member To's membership key, created by member From.
+type MembershipKeyShare struct {
+	From  int
+	To    int
+	Share Point
+}
+
+// MembershipKey is the membership key of the member with the given index.
+type MembershipKey struct {
+	Index int
+	Key   Point
+}
+
+// AsmSignature is an accountable-subgroup multisignature, along with its signers.
+type AsmSignature struct {
+	Sig     Point
+	Signers *Bitfield
+}
+
+// NewAsmGroup creates the group from its members' public keys. The order of
+// the keys determines each member's index. It returns nil if there are no keys.
+func NewAsmGroup(curve CurveSystem, pubkeys []Point) *AsmGroup {
+	if len(pubkeys) == 0 {
+		return nil
+	}
+	g := &AsmGroup{curve: curve, keys: make([]Point, len(pubkeys)), index: make(map[string]int),
+		h2Hashes: make([]Point, len(pubkeys))}
+	for i, key := range pubkeys {
+		g.keys[i] = key.Copy()
+		g.index[string(key.Marshal())] = i
+	}
+	g.exps = hashPubKeysToExponents(g.keys)
+	g.apk = AggregatePoints(ScalePoints(g.keys, g.exps))
+	return g
+}
+
+// Size returns the number of members of the group.
+func (g *AsmGroup) Size() int {
+	return len(g.keys)
+}
+
+// Apk returns the group's aggregate public key.
+func (g *AsmGroup) Apk() Point {
+	return g.apk.Copy()
+}
+
+// Key returns the public key of the member with the given index.
+func (g *AsmGroup) Key(index int) Point {
+	return g.keys[index].Copy()
+}
+
+// Exponent returns the hashed aggregation exponent of the member with the given index.
+func (g *AsmGroup) Exponent(index int) *big.Int {
+	return new(big.Int).Set(g.exps[index])
+}
+
+// Index returns the index of the member with the given public key, or -1 if
+// it isn't a member.
+func (g *AsmGroup) Index(pubkey Point) int {
+	if i, ok := g.index[string(pubkey.Marshal())]; ok {
+		return i
+	}
+	return -1
+}
+
+// h2 returns H2(apk, index), caching the result.
+func (g *AsmGroup) h2(index int) Point {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.h2Hashes[index] == nil {
+		g.h2Hashes[index] = getAmsH2(g.curve, g.apk)(amsIndexMessage(index))
+	}
+	return g.h2Hashes[index].Copy()
+}
+
+// CreateMembershipKeyShares returns the membership key shares which the member
+// with the given index and secret key sends to every member, including itself.
+func (g *AsmGroup) CreateMembershipKeyShares(sk *big.Int, index int) ([]MembershipKeyShare, error) {
+	if index < 0 || index >= len(g.keys) {
+		return nil, errors.New("index isn't in the group")
+	}
+	scaledSk := new(big.Int).Mul(sk, g.exps[index])
+	scaledSk.Mod(scaledSk, g.curve.GetG1Order())
+	shares := make([]MembershipKeyShare, len(g.keys))
+	for j := range shares {
+		shares[j] = MembershipKeyShare{index, j, g.h2(j).Mul(scaledSk)}
+	}
+	return shares, nil
+}
+
+// VerifyMembershipKeyShare checks that the share was created by the secret key
+// of member share.From, for member share.To.
+func (g *AsmGroup) VerifyMembershipKeyShare(share MembershipKeyShare) bool {
+	if share.From < 0 || share.From >= len(g.keys) || share.To < 0 || share.To >= len(g.keys) {
+		return false
+	}
+	scaledKey := g.keys[share.From].Mul(g.exps[share.From])
+	return g.pairingCheck([]Point{share.Share, g.h2(share.To)}, []Point{g.curve.GetG2(), scaledKey})
+}
+
+// AggregateMembershipKeyShares combines the shares which the member with the
+// given index received from every member into its membership key. The shares
+// can be in any order. It returns false unless there is exactly one share
+// from each member, addressed to this member. Shares aren't verified, but the
+// result can be checked with VerifyMembershipKey.
+func (g *AsmGroup) AggregateMembershipKeyShares(index int, shares []MembershipKeyShare) (*MembershipKey, bool) {
+	if index < 0 || index >= len(g.keys) || len(shares) != len(g.keys) {
+		return nil, false
+	}
+	seen := NewBitfield(len(g.keys))
+	pts := make([]Point, len(shares))
+	for i, share := range shares {
+		if share.To != index || seen.IsSet(share.From) || !seen.Set(share.From) {
+			return nil, false
+		}
+		pts[i] = share.Share
+	}
+	return &MembershipKey{index, AggregatePoints(pts)}, true
+}
+
+// VerifyMembershipKey checks that the membership key is valid for the group,
+// i.e. that e(mk, g2) = e(H2(apk, index), apk).
+func (g *AsmGroup) VerifyMembershipKey(mk *MembershipKey) bool {
+	if mk == nil || mk.Index < 0 || mk.Index >= len(g.keys) {
+		return false
+	}
+	return g.pairingCheck([]Point{mk.Key, g.h2(mk.Index)}, []Point{g.curve.GetG2(), g.apk})
+}
 
+// CreateSignatureShare creates the signature share of the member holding the
+// secret key and membership key.
+func (g *AsmGroup) CreateSignatureShare(sk *big.Int, mk *MembershipKey, msg []byte) Point {
+	return AmsCreateSignatureShare(g.curve, sk, mk.Key, msg)
+}
+
+// VerifySignatureShare checks a signature share from the member with the given index.
+func (g *AsmGroup) VerifySignatureShare(index int, share Point, msg []byte) bool {
+	if index < 0 || index >= len(g.keys) {
+		return false
+	}
+	return g.pairingCheck([]Point{share, getAmsH0(g.curve)(msg), g.h2(index)},
+		[]Point{g.curve.GetG2(), g.keys[index], g.apk})
+}
+
+// CombineSignatureShares combines the signature shares of the members with
+// the given indices into an AsmSignature. It returns false if an index is out
+// of range or repeated.
+func (g *AsmGroup) CombineSignatureShares(indices []int, shares []Point) (*AsmSignature, bool) {
+	signers, ok := g.signerBitfield(indices, shares)
+	if !ok {
+		return nil, false
+	}
+	return &AsmSignature{AggregatePoints(shares), signers}, true
+}
+
+// Verify checks an AsmSignature on msg. The signers must be a non-empty subset
+// of the group.
+func (g *AsmGroup) Verify(sig *AsmSignature, msg []byte) bool {
+	if sig == nil || sig.Signers == nil || sig.Signers.Size() != len(g.keys) || sig.Signers.Count() == 0 {
+		return false
+	}
+	indices := sig.Signers.Indices()
+	hashes := make([]Point, len(indices))
+	for i, index := range indices {
+		hashes[i] = g.h2(index)
+	}
+	return g.pairingCheck([]Point{sig.Sig, getAmsH0(g.curve)(msg), AggregatePoints(hashes)},
+		[]Point{g.curve.GetG2(), g.AggKey(sig.Signers), g.apk})
+}
+
+// AggKey returns the aggregate key of the signers, the sum of their public
+// keys, as passed to AmsVerifySignature. The signers must be a subset of the group.
+func (g *AsmGroup) AggKey(signers *Bitfield) Point {
+	indices := signers.Indices()
+	keys := make([]Point, len(indices))
+	for i, index := range indices {
+		keys[i] = g.keys[index]
+	}
+	return AggregatePoints(keys)
+}
+
+// CompactMessage returns the message which members sign in the compact scheme.
+func (g *AsmGroup) CompactMessage(msg []byte) []byte {
+	return append(g.apk.MarshalUncompressed(), msg...)
+}
+
+// CompactSign creates the compact signature share on msg of the member with
+// the given index and secret key.
+func (g *AsmGroup) CompactSign(sk *big.Int, index int, msg []byte) Point {
+	return Sign(g.curve, sk, g.CompactMessage(msg)).Mul(g.exps[index])
+}
+
+// CompactCombine combines the compact signature shares of the members with the
+// given indices.
+func (g *AsmGroup) CompactCombine(indices []int, shares []Point) (*AsmSignature, bool) {
+	signers, ok := g.signerBitfield(indices, shares)
+	if !ok {
+		return nil, false
+	}
+	return &AsmSignature{AggregatePoints(shares), signers}, true
+}
+
+// CompactVerify checks a compact signature on msg, under the aggregate key
+// sum_j a_j * pk_j of the signers.
+func (g *AsmGroup) CompactVerify(sig *AsmSignature, msg []byte) bool {
+	if sig == nil || sig.Signers == nil || sig.Signers.Size() != len(g.keys) || sig.Signers.Count() == 0 {
+		return false
+	}
+	return VerifySingleSignature(g.curve, sig.Sig, g.compactAggKey(sig.Signers), g.CompactMessage(msg))
+}
+
+func (g *AsmGroup) compactAggKey(signers *Bitfield) Point {
+	if signers.Count() == len(g.keys) {
+		return g.apk.Copy()
+	}
+	indices := signers.Indices()
+	keys := make([]Point, len(indices))
+	exps := make([]*big.Int, len(indices))
+	for i, index := range indices {
+		keys[i] = g.keys[index]
+		exps[i] = g.exps[index]
+	}
+	return AggregatePoints(ScalePoints(keys, exps))
+}
+
+// signerBitfield returns the Bitfield of the indices, checking that there is
+// one share per signer.
+func (g *AsmGroup) signerBitfield(indices []int, shares []Point) (*Bitfield, bool) {
+	if len(indices) != len(shares) || len(indices) == 0 {
+		return nil, false
+	}
+	signers := NewBitfield(len(g.keys))
+	for _, index := range indices {
+		if signers.IsSet(index) || !signers.Set(index) {
+			return nil, false
+		}
+	}
+	return signers, true
+}
+
+// pairingCheck returns true if the first pair's pairing equals the product of
+// the remaining pairs' pairings.
+func (g *AsmGroup) pairingCheck(pts1 []Point, pts2 []Point) bool {
+	pts1 = append([]Point{pts1[0].Mul(new(big.Int).SetInt64(-1))}, pts1[1:]...)
+	aggPt, ok := g.curve.PairingProduct(pts1, pts2)
+	return ok && aggPt.Equals(g.curve.GetGTIdentity())
+}
+
+// Marshal serializes the aggregate signature and signers.
+func (sig *AsmSignature) Marshal() []byte {
+	buf := encoding.AppendLengthPrefixed(nil, sig.Sig.Marshal())
+	return encoding.AppendLengthPrefixed(buf, sig.Signers.Marshal())
+}
+
+// UnmarshalAsmSignature deserializes an AsmSignature created with Marshal.
+func UnmarshalAsmSignature(curve CurveSystem, data []byte) (*AsmSignature, bool) {
+	r := encoding.NewReader(data)
+	sig := r.G1(curve)
+	signersData := r.LengthPrefixed()
+	if !r.Done() {
+		return nil, false
+	}
+	signers, ok := UnmarshalBitfield(signersData)
+	if !ok {
+		return nil, false
+	}
+	return &AsmSignature{sig, signers}, true
+}
+
+// AmsCreateMembershipKeyShares returns the membership key shares which the
+// signer with the secret key and index curIndex sends to every member of the
+// group. If shares are created for several groups, or by several members,
+// use AsmGroup instead, which caches the aggregation exponents.
 func AmsCreateMembershipKeyShares(curve CurveSystem, sk *big.Int, curIndex int, pubkeys []Point) []Point {
 	t := hashPubKeysToExponents(pubkeys)
 	apk := AggregatePoints(ScalePoints(pubkeys, t))
 	return AmsCreateMembershipKeySharesKnownExp(curve, sk, apk, t[curIndex], len(pubkeys))
 }
 
+// AmsCreateMembershipKeySharesKnownExp is AmsCreateMembershipKeyShares, given
+// the group's aggregate public key and the signer's aggregation exponent.
 func AmsCreateMembershipKeySharesKnownExp(curve CurveSystem, sk *big.Int, apk Point, exp *big.Int, numSigners int) []Point {
 	shares := make([]Point, numSigners, numSigners)
 	for i := 0; i < numSigners; i++ {
-		shares[i] = SignCustHash(sk, []byte(strconv.Itoa(i)), getAmsH2(curve, apk))
+		shares[i] = SignCustHash(sk, amsIndexMessage(i), HashFunc(getAmsH2(curve, apk)))
 		shares[i] = shares[i].Mul(exp)
 	}
 	return shares
 }
 
+// AmsAggregateMembershipKeyShares sums the membership key shares which a
+// member received from every member of the group into its membership key.
 func AmsAggregateMembershipKeyShares(curve CurveSystem, shares []Point) Point {
 	return AggregatePoints(shares)
 }
 
+// AmsCreateSignatureShare creates a signer's signature share on msg.
 func AmsCreateSignatureShare(curve CurveSystem, sk *big.Int, membershipKey Point, msg []byte) Point {
-	sig := SignCustHash(sk, msg, getAmsH0(curve))
+	sig := SignCustHash(sk, msg, HashFunc(getAmsH0(curve)))
 	sig, _ = sig.Add(membershipKey)
 	return sig
 }
 
+// AmsCombineSignatureShares returns the aggregate key and aggregate signature
+// of the signers' public keys and signature shares.
 func AmsCombineSignatureShares(pubkeys []Point, sigs []Point) (aggKey Point, aggSig Point) {
 	aggKey = AggregatePoints(pubkeys)
 	aggSig = AggregateSignatures(sigs)
 	return
 }
 
+// AmsVerifySignature verifies an aggregate signature on msg, from the signers
+// with the given indices in the group with aggregate public key apk. aggKey
+// must be the sum of the signers' public keys, computed by the verifier:
+// anyone with a membership key can forge a signature under an aggregate key
+// of their choosing. AsmGroup.Verify computes it from the signers.
 func AmsVerifySignature(curve CurveSystem, apk Point, signers []int, aggKey Point, aggSig Point, msg []byte) bool {
-	aggMsg := getAmsH2(curve, apk)([]byte(strconv.Itoa(signers[0])))
+	if len(signers) == 0 {
+		return false
+	}
+	aggMsg := getAmsH2(curve, apk)(amsIndexMessage(signers[0]))
 	for i := 1; i < len(signers); i++ {
-		aggMsg, _ = aggMsg.Add(getAmsH2(curve, apk)([]byte(strconv.Itoa(signers[i]))))
+		aggMsg, _ = aggMsg.Add(getAmsH2(curve, apk)(amsIndexMessage(signers[i])))
 	}
 	aggPt, ok := curve.PairingProduct([]Point{getAmsH0(curve)(msg), aggMsg, aggSig.Mul(new(big.Int).SetInt64(-1))},
 		[]Point{aggKey, apk, curve.GetG2()})
@@ -58,6 +389,8 @@ func AmsVerifySignature(curve CurveSystem, apk Point, signers []int, aggKey Poin
 	return ok
 }
 
+// AmsVerifySignatureWithSetCheck is AmsVerifySignature, but first checks that
+// the set of signers is acceptable, e.g. that it is large enough.
 func AmsVerifySignatureWithSetCheck(curve CurveSystem, check func([]int) bool, apk Point, signers []int, aggKey Point, aggSig Point, msg []byte) bool {
 	if check(signers) == false {
 		return false
@@ -65,11 +398,18 @@ func AmsVerifySignatureWithSetCheck(curve CurveSystem, check func([]int) bool, a
 	return AmsVerifySignature(curve, apk, signers, aggKey, aggSig, msg)
 }
 
+// AmspGetMessage returns the message which members of the group sign in the
+// compact scheme. AsmGroup.CompactMessage avoids recomputing apk.
 func AmspGetMessage(curve CurveSystem, pubkeys []Point, msg []byte) []byte {
 	apk := getAggregatePubKey(curve, pubkeys)
 	return append(apk.MarshalUncompressed(), msg...)
 }
 
+// amsIndexMessage encodes a member's index as the input to H2, as a big endian uint32.
+func amsIndexMessage(index int) []byte {
+	return encoding.AppendUint32(nil, uint32(index))
+}
+
 func getAmsH0(curve CurveSystem) func(msg []byte) Point {
 	return func(msg []byte) Point {
 		msg2 := append([]byte{0}, msg...)
@@ -5,11 +5,11 @@ package bgls
 
 import (
 	"crypto/rand"
-	. "github.com/Project-Arda/bgls/curves" // nolint: golint
-	"github.com/stretchr/testify/assert"
 	"math/big"
-	"strconv"
 	"testing"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAmsConsistency(t *testing.T) {
@@ -21,24 +21,27 @@ func TestAmsConsistency(t *testing.T) {
 		for i := 0; i < numKeys; i++ {
 			secretKeys[i], pubkeys[i], _ = KeyGen(curve)
 		}
-		mkShares := make([][]Point, numKeys, numKeys)
+		// received[j] holds the shares which member j receives.
+		received := make([][]Point, numKeys, numKeys)
 		exps := hashPubKeysToExponents(pubkeys)
 		apk := AggregatePoints(ScalePoints(pubkeys, exps))
+		group := NewAsmGroup(curve, pubkeys)
+		assert.True(t, apk.Equals(group.Apk()))
 		for i := 0; i < numKeys; i++ {
-			mkShares[i] = AmsCreateMembershipKeyShares(curve, secretKeys[i], i, pubkeys)
+			mkShares := AmsCreateMembershipKeyShares(curve, secretKeys[i], i, pubkeys)
 			// Test that using the more efficient algorithm yields the same result.
-			assert.Equal(t, mkShares[i], AmsCreateMembershipKeySharesKnownExp(curve, secretKeys[i], apk, exps[i], numKeys))
+			assert.Equal(t, mkShares, AmsCreateMembershipKeySharesKnownExp(curve, secretKeys[i], apk, exps[i], numKeys))
+			groupShares, err := group.CreateMembershipKeyShares(secretKeys[i], i)
+			assert.Nil(t, err)
+			for j := 0; j < numKeys; j++ {
+				assert.True(t, mkShares[j].Equals(groupShares[j].Share), "AsmGroup created a different share")
+				received[j] = append(received[j], mkShares[j])
+			}
 		}
-		mkShares = reorganizeMembershipKeyShares(mkShares)
 		membershipKeys := make([]Point, numKeys, numKeys)
 		for i := 0; i < numKeys; i++ {
-			membershipKeys[i] = AmsAggregateMembershipKeyShares(curve, mkShares[i])
-			amsH2 := getAmsH2(curve, apk)
-			pt1, ok1 := curve.Pair(membershipKeys[i], curve.GetG2())
-			pt2, ok2 := curve.Pair(amsH2([]byte(strconv.Itoa(i))), apk)
-			assert.True(t, ok1)
-			assert.True(t, ok2)
-			assert.True(t, pt1.Equals(pt2))
+			membershipKeys[i] = AmsAggregateMembershipKeyShares(curve, received[i])
+			assert.True(t, group.VerifyMembershipKey(&MembershipKey{i, membershipKeys[i]}))
 		}
 
 		// Now generate signatures. Generate a signature on 8 people. For simplicity,
@@ -56,18 +59,137 @@ func TestAmsConsistency(t *testing.T) {
 		assert.True(t, AmsVerifySignature(curve, apk, set, aggKey, aggSig, msg))
 		assert.True(t, AmsVerifySignatureWithSetCheck(curve, func(set []int) bool { return len(set) > 5 },
 			apk, set, aggKey, aggSig, msg))
+		assert.False(t, AmsVerifySignature(curve, apk, set[1:], aggKey, aggSig, msg),
+			"Signature verified with the wrong set of signers")
 	}
 }
 
-// Each of these rows is all the shares a given signer makes. This reorganizes it
-// to be all of the shares a signer should receive. Basically transposes the matrix.
-func reorganizeMembershipKeyShares(incomingMkShares [][]Point) [][]Point {
-	trueMkSet := make([][]Point, len(incomingMkShares), len(incomingMkShares))
-	for i := 0; i < len(incomingMkShares); i++ {
-		trueMkSet[i] = make([]Point, len(incomingMkShares), len(incomingMkShares))
-		for j := 0; j < len(incomingMkShares); j++ {
-			trueMkSet[i][j] = incomingMkShares[j][i]
+// createMembershipKeys runs the membership key setup for the group, and
+// returns every member's membership key.
+func createMembershipKeys(t *testing.T, group *AsmGroup, sks []*big.Int) []*MembershipKey {
+	received := make([][]MembershipKeyShare, group.Size())
+	// Members send their shares in reverse order, to check that order doesn't matter.
+	for i := group.Size() - 1; i >= 0; i-- {
+		shares, err := group.CreateMembershipKeyShares(sks[i], i)
+		assert.Nil(t, err)
+		for _, share := range shares {
+			received[share.To] = append(received[share.To], share)
+		}
+	}
+	mks := make([]*MembershipKey, group.Size())
+	for i := range mks {
+		var ok bool
+		mks[i], ok = group.AggregateMembershipKeyShares(i, received[i])
+		assert.True(t, ok)
+		assert.True(t, group.VerifyMembershipKey(mks[i]), "Valid membership key failed verification")
+	}
+	return mks
+}
+
+func TestAsmGroup(t *testing.T) {
+	numKeys := 7
+	for _, curve := range curves {
+		pubkeys := make([]Point, numKeys)
+		sks := make([]*big.Int, numKeys)
+		for i := 0; i < numKeys; i++ {
+			sks[i], pubkeys[i], _ = KeyGen(curve)
+		}
+		group := NewAsmGroup(curve, pubkeys)
+		assert.Equal(t, 3, group.Index(pubkeys[3]))
+		_, outsider, _ := KeyGen(curve)
+		assert.Equal(t, -1, group.Index(outsider))
+
+		// A bad membership key share is caught, both alone and once aggregated.
+		shares, _ := group.CreateMembershipKeyShares(sks[0], 0)
+		for _, share := range shares {
+			assert.True(t, group.VerifyMembershipKeyShare(share))
+		}
+		badShare := shares[2]
+		badShare.From = 1
+		assert.False(t, group.VerifyMembershipKeyShare(badShare), "Share verified for the wrong sender")
+		mks := createMembershipKeys(t, group, sks)
+		assert.False(t, group.VerifyMembershipKey(&MembershipKey{1, mks[2].Key}),
+			"Membership key verified for the wrong member")
+		received := make([]MembershipKeyShare, numKeys)
+		for i := range received {
+			received[i] = MembershipKeyShare{i, 4, mks[4].Key}
+		}
+		_, ok := group.AggregateMembershipKeyShares(4, append(received[:numKeys-1], received[0]))
+		assert.False(t, ok, "Membership key shares with a repeated sender were aggregated")
+
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		signers := []int{5, 0, 3, 2}
+		sigShares := make([]Point, len(signers))
+		for i, index := range signers {
+			sigShares[i] = group.CreateSignatureShare(sks[index], mks[index], msg)
+			assert.True(t, group.VerifySignatureShare(index, sigShares[i], msg))
+		}
+		assert.False(t, group.VerifySignatureShare(1, sigShares[0], msg), "Share verified for the wrong signer")
+		sig, ok := group.CombineSignatureShares(signers, sigShares)
+		assert.True(t, ok)
+		assert.Equal(t, []int{0, 2, 3, 5}, sig.Signers.Indices())
+		assert.True(t, group.Verify(sig, msg), "ASM signature failed verification")
+		assert.True(t, AmsVerifySignature(curve, group.Apk(), sig.Signers.Indices(), group.AggKey(sig.Signers), sig.Sig, msg))
+
+		decoded, ok := UnmarshalAsmSignature(curve, sig.Marshal())
+		assert.True(t, ok)
+		assert.True(t, group.Verify(decoded, msg), "Unmarshalled ASM signature failed verification")
+		_, ok = UnmarshalAsmSignature(curve, sig.Marshal()[:10])
+		assert.False(t, ok)
+
+		// Claiming a different set of signers fails.
+		decoded.Signers.Set(1)
+		assert.False(t, group.Verify(decoded, msg), "ASM signature verified with an extra signer")
+		decoded.Signers.Unset(1)
+		decoded.Signers.Unset(5)
+		assert.False(t, group.Verify(decoded, msg), "ASM signature verified with a missing signer")
+		_, ok = group.CombineSignatureShares([]int{0, 0}, sigShares[:2])
+		assert.False(t, ok, "Shares with a repeated signer were combined")
+
+		// Member 4 can't use its membership key to sign alone under an aggregate
+		// key x * g2 of its choosing, without sk_4.
+		x, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		forged, _ := getAmsH0(curve)(msg).Mul(x).Add(mks[4].Key)
+		signers4 := NewBitfield(numKeys)
+		signers4.Set(4)
+		assert.False(t, group.Verify(&AsmSignature{forged, signers4}, msg),
+			"ASM signature forged with a membership key verified")	}
+}
+
+func TestCompactAsm(t *testing.T) {
+	numKeys := 6
+	for _, curve := range curves {
+		pubkeys := make([]Point, numKeys)
+		sks := make([]*big.Int, numKeys)
+		for i := 0; i < numKeys; i++ {
+			sks[i], pubkeys[i], _ = KeyGen(curve)
+		}
+		group := NewAsmGroup(curve, pubkeys)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		assert.Equal(t, AmspGetMessage(curve, pubkeys, msg), group.CompactMessage(msg))
+
+		for _, signers := range [][]int{{0, 1, 2, 3, 4, 5}, {4, 1}} {
+			shares := make([]Point, len(signers))
+			for i, index := range signers {
+				shares[i] = group.CompactSign(sks[index], index, msg)
+			}
+			sig, ok := group.CompactCombine(signers, shares)
+			assert.True(t, ok)
+			assert.True(t, group.CompactVerify(sig, msg), "Compact signature failed verification")
+			decoded, ok := UnmarshalAsmSignature(curve, sig.Marshal())
+			assert.True(t, ok)
+			assert.True(t, group.CompactVerify(decoded, msg))
+			decoded.Signers.Unset(signers[0])
+			assert.False(t, group.CompactVerify(decoded, msg), "Compact signature verified with a missing signer")
+		}
+		// A full group compact signature is a standard signature under apk.
+		shares := make([]Point, numKeys)
+		for i := range shares {
+			shares[i] = Sign(curve, sks[i], AmspGetMessage(curve, pubkeys, msg)).Mul(group.Exponent(i))
 		}
+		assert.True(t, VerifySingleSignature(curve, AggregateSignatures(shares), group.Apk(),
+			AmspGetMessage(curve, pubkeys, msg)))
 	}
-	return trueMkSet
 }
@@ -0,0 +1,160 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// Blind BLS signatures let a user get a signature on a message which the
+// signer never sees. The user hashes the message to h = HashToG1(m), picks a
+// random scalar r, and sends the blinded point r * h. Since h is a random
+// group element and r is uniform, the blinded point is uniform and
+// independent of the message. The signer multiplies it by their secret key,
+// exactly as SignCustHash does with a hashed message, and returns
+// sk * r * h. The user multiplies this by r^-1, which gives sk * h, the
+// ordinary BLS signature on m. The signer can't link it to the blinded point
+// which they signed.
+//
+// In a partially blind signature, the signer and user agree on public
+// metadata, such as an expiry date, which the signature is bound to. The
+// metadata is hashed to a scalar c, and the signer signs the blinded point
+// with (sk + c)^-1 rather than sk. The unblinded signature on m is
+// (sk + c)^-1 * h, which verifies under pk + c * g2 with
+// e(sig, pk + c * g2) = e(h, g2). Unlike a signer key derived linearly from
+// the metadata, a signature under one piece of metadata can't be converted
+// into a signature under another.
+//
+// Threshold blind signatures are blind signatures from a group key which was
+// split with Shamir secret sharing. Each share holder signs the blinded point
+// with their share, the user checks each partial signature against the
+// verification vector, and interpolates t of them into sk * r * h, before
+// unblinding it.
+
+import (
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// partiallyBlindDomain separates the hash of the public metadata from any
+// other hash of the same bytes.
+const partiallyBlindDomain = "BGLS-partially-blind-info"
+
+// Blind hashes msg onto G1 and blinds it. It returns the blinded point, which
+// is sent to the signer, and the blinding factor, which the user keeps to
+// unblind the signature.
+func Blind(curve CurveSystem, msg []byte) (Point, *big.Int, error) {
+	return BlindCustHash(curve, msg, DefaultHasher(curve))
+}
+
+// BlindCustHash is Blind, using the supplied Hasher to hash onto G1.
+func BlindCustHash(curve CurveSystem, msg []byte, hash Hasher) (Point, *big.Int, error) {
+	r, err := encoding.RandomNonzeroScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hash.Hash(msg).Mul(r), r, nil
+}
+
+// SignBlinded signs a blinded point with the secret key. It returns false if
+// the blinded point is the identity, since no message hashes to it.
+func SignBlinded(curve CurveSystem, sk *big.Int, blinded Point) (Point, bool) {
+	if blinded.Equals(curve.GetG1Infinity()) {
+		return nil, false
+	}
+	return blinded.Mul(sk), true
+}
+
+// VerifyBlindSignature checks that blindSig is the signature on the blinded
+// point under pubKey, so that the user can detect a bad signature before
+// unblinding it.
+func VerifyBlindSignature(curve CurveSystem, blindSig Point, pubKey Point, blinded Point) bool {
+	negBlinded := blinded.Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{negBlinded, blindSig}, []Point{pubKey, curve.GetG2()})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// Unblind removes the blinding factor from a blind signature. If blindSig is
+// valid, the result is the BLS signature on the blinded message, which
+// verifies with VerifySingleSignature.
+func Unblind(curve CurveSystem, blindSig Point, r *big.Int) Point {
+	return blindSig.Mul(new(big.Int).ModInverse(r, curve.GetG1Order()))
+}
+
+// hashInfoToScalar hashes the public metadata of a partially blind signature
+// to a scalar. The 512 bit digest is reduced modulo the group order, so the
+// scalar's bias is negligible.
+func hashInfoToScalar(curve CurveSystem, info []byte) *big.Int {
+	digest := blake2b.Sum512(encoding.AppendLengthPrefixed([]byte(partiallyBlindDomain), info))
+	c := new(big.Int).SetBytes(digest[:])
+	return c.Mod(c, curve.GetG1Order())
+}
+
+// PartiallyBlindPublicKey returns the key which partially blind signatures
+// with the given public metadata verify under, pk + H(info) * g2.
+func PartiallyBlindPublicKey(curve CurveSystem, pubKey Point, info []byte) Point {
+	infoKey, _ := pubKey.Add(curve.GetG2().Mul(hashInfoToScalar(curve, info)))
+	return infoKey
+}
+
+// SignPartiallyBlinded signs a blinded point, bound to the public metadata
+// info. It returns false if the blinded point is the identity, or if
+// sk + H(info) is zero, which happens with negligible probability.
+func SignPartiallyBlinded(curve CurveSystem, sk *big.Int, info []byte, blinded Point) (Point, bool) {
+	order := curve.GetG1Order()
+	exp := new(big.Int).Add(sk, hashInfoToScalar(curve, info))
+	if exp.Mod(exp, order).Sign() == 0 {
+		return nil, false
+	}
+	return SignBlinded(curve, exp.ModInverse(exp, order), blinded)
+}
+
+// VerifyPartiallyBlinded checks that blindSig is the partially blind
+// signature with metadata info on the blinded point, before it is unblinded.
+func VerifyPartiallyBlinded(curve CurveSystem, blindSig Point, pubKey Point, info []byte,
+	blinded Point) bool {
+	return verifyPartiallyBlind(curve, blindSig, PartiallyBlindPublicKey(curve, pubKey, info), blinded)
+}
+
+// VerifyPartiallyBlindSignature checks that sig is an unblinded partially
+// blind signature on msg, bound to the public metadata info.
+func VerifyPartiallyBlindSignature(curve CurveSystem, sig Point, pubKey Point, info []byte, msg []byte) bool {
+	return VerifyPartiallyBlindSignatureCustHash(curve, sig, pubKey, info, msg, DefaultHasher(curve))
+}
+
+// VerifyPartiallyBlindSignatureCustHash is VerifyPartiallyBlindSignature,
+// using the supplied Hasher to hash onto G1.
+func VerifyPartiallyBlindSignatureCustHash(curve CurveSystem, sig Point, pubKey Point, info []byte,
+	msg []byte, hash Hasher) bool {
+	return verifyPartiallyBlind(curve, sig, PartiallyBlindPublicKey(curve, pubKey, info), hash.Hash(msg))
+}
+
+// verifyPartiallyBlind checks that e(sig, infoKey) = e(h, g2).
+func verifyPartiallyBlind(curve CurveSystem, sig Point, infoKey Point, h Point) bool {
+	negH := h.Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{sig, negH}, []Point{infoKey, curve.GetG2()})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// ThresholdVerifyBlindPartialSignature checks that sig is a valid partial
+// signature on the blinded point, from the share with the given index.
+func ThresholdVerifyBlindPartialSignature(curve CurveSystem, sig Point, vvec []Point, index int,
+	blinded Point) bool {
+	if index < 1 || len(vvec) == 0 {
+		return false
+	}
+	return VerifyBlindSignature(curve, sig, GetSharePublicKey(curve, vvec, index), blinded)
+}
+
+// ThresholdUnblind combines partial signatures on a blinded point from the
+// shares with the given indices, and unblinds the result. If at least t valid
+// partial signatures are provided, it is the group's signature on the
+// blinded message. It returns false if the indices are invalid.
+func ThresholdUnblind(curve CurveSystem, sigs []Point, indices []int, r *big.Int) (Point, bool) {
+	blindSig, ok := ThresholdCombineSignatures(curve, sigs, indices)
+	if !ok {
+		return nil, false
+	}
+	return Unblind(curve, blindSig, r), true
+}
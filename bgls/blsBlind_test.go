@@ -0,0 +1,99 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlindSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk, pk, _ := KeyGen(curve)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+
+		blinded, r, err := Blind(curve, msg)
+		assert.Nil(t, err)
+		assert.False(t, blinded.Equals(curve.HashToG1(msg)), "Blinded point is the message hash")
+		otherBlinded, _, _ := Blind(curve, msg)
+		assert.False(t, blinded.Equals(otherBlinded), "Blinding the same message twice gave the same point")
+
+		blindSig, ok := SignBlinded(curve, sk, blinded)
+		assert.True(t, ok)
+		assert.True(t, VerifyBlindSignature(curve, blindSig, pk, blinded), "Valid blind signature failed verification")
+		sig := Unblind(curve, blindSig, r)
+		assert.True(t, VerifySingleSignature(curve, sig, pk, msg), "Unblinded signature failed verification")
+		assert.True(t, sig.Equals(Sign(curve, sk, msg)), "Unblinded signature isn't the BLS signature")
+
+		_, otherPk, _ := KeyGen(curve)
+		assert.False(t, VerifyBlindSignature(curve, blindSig, otherPk, blinded), "Blind signature verified under the wrong key")
+		wrongR := new(big.Int).Add(r, big.NewInt(1))
+		assert.False(t, VerifySingleSignature(curve, Unblind(curve, blindSig, wrongR), pk, msg),
+			"Signature unblinded with the wrong factor verified")
+		_, ok = SignBlinded(curve, sk, curve.GetG1Infinity())
+		assert.False(t, ok, "Signed the identity")
+	}
+}
+
+func TestPartiallyBlindSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk, pk, _ := KeyGen(curve)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		info := []byte("expires 2019-01-01")
+		otherInfo := []byte("expires 2099-01-01")
+
+		blinded, r, _ := Blind(curve, msg)
+		blindSig, ok := SignPartiallyBlinded(curve, sk, info, blinded)
+		assert.True(t, ok)
+		assert.True(t, VerifyPartiallyBlinded(curve, blindSig, pk, info, blinded),
+			"Valid partially blind signature failed verification before unblinding")
+		assert.False(t, VerifyPartiallyBlinded(curve, blindSig, pk, otherInfo, blinded),
+			"Partially blind signature verified with the wrong metadata before unblinding")
+
+		sig := Unblind(curve, blindSig, r)
+		assert.True(t, VerifyPartiallyBlindSignature(curve, sig, pk, info, msg),
+			"Valid partially blind signature failed verification")
+		assert.False(t, VerifyPartiallyBlindSignature(curve, sig, pk, otherInfo, msg),
+			"Partially blind signature verified with the wrong metadata")
+		assert.False(t, VerifyPartiallyBlindSignature(curve, sig, pk, info, append(msg, 0)),
+			"Partially blind signature verified on the wrong message")
+		assert.False(t, VerifySingleSignature(curve, sig, pk, msg),
+			"Partially blind signature verified as a plain signature")
+		assert.True(t, PartiallyBlindPublicKey(curve, pk, info).Equals(
+			PartiallyBlindPublicKey(curve, pk, append([]byte{}, info...))))
+	}
+}
+
+func TestThresholdBlindSignatures(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		shares, vvec, _ := ThresholdKeyGen(curve, threshold, n)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		blinded, r, _ := Blind(curve, msg)
+
+		partials := make([]Point, n)
+		for i, share := range shares {
+			partials[i], _ = SignBlinded(curve, share.Value, blinded)
+			assert.True(t, ThresholdVerifyBlindPartialSignature(curve, partials[i], vvec, share.Index, blinded),
+				"Valid blind partial signature failed verification")
+		}
+		assert.False(t, ThresholdVerifyBlindPartialSignature(curve, partials[0], vvec, 2, blinded),
+			"Blind partial signature verified for the wrong share")
+
+		sig, ok := ThresholdUnblind(curve, partials[1:4], []int{2, 3, 4}, r)
+		assert.True(t, ok)
+		assert.True(t, VerifySingleSignature(curve, sig, vvec[0], msg), "Threshold blind signature failed verification")
+		sig, _ = ThresholdUnblind(curve, partials[:2], []int{1, 2}, r)
+		assert.False(t, VerifySingleSignature(curve, sig, vvec[0], msg), "Too few partial signatures verified")
+		_, ok = ThresholdUnblind(curve, partials[:2], []int{1, 1}, r)
+		assert.False(t, ok, "Combined partial signatures with repeated indices")
+	}
+}
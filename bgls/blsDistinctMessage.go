@@ -13,6 +13,7 @@ package bgls
 // DistinctMsgVerifySingleSignature, DistinctMsgVerifyAggregateSignature
 
 import (
+	"context"
 	"math/big"
 
 	. "github.com/Project-Arda/bgls/curves" // nolint: golint
@@ -21,14 +22,14 @@ import (
 // DistinctMsgSign creates a signature on a message with a private key, with
 // prepending the public key to the message.
 func DistinctMsgSign(curve CurveSystem, sk *big.Int, m []byte) Point {
-	return DistinctMsgSignCustHash(curve, sk, m, curve.HashToG1)
+	return DistinctMsgSignCustHash(curve, sk, m, DefaultHasher(curve))
 }
 
 // DistinctMsgSignCustHash creates a signature on a message with a private key, using
-// a supplied function to hash to g1.
-func DistinctMsgSignCustHash(curve CurveSystem, sk *big.Int, msg []byte, hash func([]byte) Point) Point {
+// a supplied Hasher to hash to g1.
+func DistinctMsgSignCustHash(curve CurveSystem, sk *big.Int, msg []byte, hash Hasher) Point {
 	m := append(LoadPublicKey(curve, sk).MarshalUncompressed(), msg...)
-	h := hash(m)
+	h := hash.Hash(m)
 	i := h.Mul(sk)
 	return i
 }
@@ -43,8 +44,16 @@ func DistinctMsgVerifySingleSignature(curve CurveSystem, sig Point, pubkey Point
 // the provided set of public key / msg pairs, when the messages are signed using
 // the 'Distinct Message' method.
 func DistinctMsgVerifyAggregateSignature(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	ok, _ := DistinctMsgVerifyAggregateSignatureContext(context.Background(), curve, aggsig, keys, msgs)
+	return ok
+}
+
+// DistinctMsgVerifyAggregateSignatureContext is DistinctMsgVerifyAggregateSignature,
+// but stops early and returns ctx.Err() if ctx is cancelled.
+func DistinctMsgVerifyAggregateSignatureContext(ctx context.Context, curve CurveSystem, aggsig Point,
+	keys []Point, msgs [][]byte) (bool, error) {
 	if len(keys) != len(msgs) {
-		return false
+		return false, ctx.Err()
 	}
 	prependedMsgs := make([][]byte, len(msgs))
 	for i := 0; i < len(msgs); i++ {
@@ -53,5 +62,5 @@ func DistinctMsgVerifyAggregateSignature(curve CurveSystem, aggsig Point, keys [
 	// Use true for allow duplicates even though duplicates aren't allowed
 	// This is because the prepending ensures that there are no duplicates,
 	// So setting this to true skips that check.
-	return verifyAggSig(curve, aggsig, keys, prependedMsgs, true)
+	return verifyAggSigContext(ctx, curve, aggsig, keys, prependedMsgs, true, DefaultHasher(curve))
 }
@@ -0,0 +1,174 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// Fault localization finds which signatures are invalid when a batch or an
+// aggregate fails to verify. Each check is a randomized batch verification
+// over a subset of the signatures: every signature is scaled by a fresh random
+// exponent before being summed, so that invalid signatures cannot cancel each
+// other out. If a subset fails, it is split in half and each half is checked
+// recursively. If the left half passes, the right half is known to be invalid
+// and its top level check is skipped.
+//
+// With b invalid signatures out of n, this takes O(b log(n/b)) batch checks,
+// instead of the n checks that verifying every signature individually would
+// require. When all signatures are on the same message (the partial signatures
+// of a multi signature), every batch check costs two pairings regardless of
+// the subset size, so the total number of pairings only grows with the number
+// of invalid signatures.
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sort"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// FindInvalidSignatures returns the indices of the (key, message, signature)
+// triples for which the signature is not a valid standard BLS signature.
+// ok is false if the number of keys, messages and signatures differ.
+func FindInvalidSignatures(curve CurveSystem, sigs []Point, keys []Point, msgs [][]byte) (invalid []int, ok bool) {
+	return FindInvalidSignaturesCustHash(curve, sigs, keys, msgs, DefaultHasher(curve))
+}
+
+// FindInvalidSignaturesCustHash returns the indices of the invalid
+// (key, message, signature) triples, using the supplied Hasher to hash
+// onto the curve where signatures lie.
+func FindInvalidSignaturesCustHash(curve CurveSystem, sigs []Point, keys []Point,
+	msgs [][]byte, hash Hasher) (invalid []int, ok bool) {
+	if len(sigs) != len(keys) || len(sigs) != len(msgs) {
+		return nil, false
+	}
+	distinctMsgs, msgIndex := groupMessages(msgs)
+	hashes, _ := hashMessages(context.Background(), hash, distinctMsgs)
+	check := func(indices []int) bool {
+		return batchVerifyTriples(curve, sigs, keys, hashes, msgIndex, indices)
+	}
+	return localizeFaults(len(sigs), check), true
+}
+
+// KoskFindInvalidSignatures returns the indices of the (key, message, signature)
+// triples for which the signature is not a valid kosk signature.
+func KoskFindInvalidSignatures(curve CurveSystem, sigs []Point, keys []Point, msgs [][]byte) (invalid []int, ok bool) {
+	newMsgs := make([][]byte, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		newMsgs[i] = append([]byte{1}, msgs[i]...)
+	}
+	return FindInvalidSignatures(curve, sigs, keys, newMsgs)
+}
+
+// FindInvalidMultiSignatureShares returns the indices of the partial
+// signatures which are not valid standard BLS signatures on msg from the
+// corresponding key. This is intended to be used before aggregating
+// partial signatures into a multi signature, or after a multi signature fails
+// to verify. Each batch check costs two pairings.
+// ok is false if the number of keys and signatures differ.
+func FindInvalidMultiSignatureShares(curve CurveSystem, sigs []Point, keys []Point, msg []byte) (invalid []int, ok bool) {
+	return FindInvalidMultiSignatureSharesCustHash(curve, sigs, keys, msg, DefaultHasher(curve))
+}
+
+// FindInvalidMultiSignatureSharesCustHash returns the indices of the invalid
+// partial signatures on msg, using the supplied Hasher to hash onto the
+// curve where signatures lie.
+func FindInvalidMultiSignatureSharesCustHash(curve CurveSystem, sigs []Point, keys []Point,
+	msg []byte, hash Hasher) (invalid []int, ok bool) {
+	if len(sigs) != len(keys) {
+		return nil, false
+	}
+	h := hash.Hash(msg)
+	check := func(indices []int) bool {
+		return batchVerifySameMessage(curve, sigs, keys, h, indices)
+	}
+	return localizeFaults(len(sigs), check), true
+}
+
+// KoskFindInvalidMultiSignatureShares returns the indices of the partial
+// signatures which are not valid kosk signatures on msg from the corresponding key.
+func KoskFindInvalidMultiSignatureShares(curve CurveSystem, sigs []Point, keys []Point, msg []byte) (invalid []int, ok bool) {
+	return FindInvalidMultiSignatureShares(curve, sigs, keys, append([]byte{1}, msg...))
+}
+
+// localizeFaults bisects the indices [0, n) using the provided batch check,
+// and returns the sorted indices which fail the check on their own.
+func localizeFaults(n int, check func([]int) bool) []int {
+	indices := make([]int, n)
+	for i := 0; i < n; i++ {
+		indices[i] = i
+	}
+	invalid := bisectFaults(indices, check, false)
+	sort.Ints(invalid)
+	return invalid
+}
+
+// bisectFaults returns the members of indices which are invalid. If
+// knownInvalid is set, the check on the entire set of indices is skipped.
+func bisectFaults(indices []int, check func([]int) bool, knownInvalid bool) []int {
+	if len(indices) == 0 {
+		return nil
+	}
+	if !knownInvalid && check(indices) {
+		return nil
+	}
+	if len(indices) == 1 {
+		return indices
+	}
+	mid := len(indices) / 2
+	left := bisectFaults(indices[:mid], check, false)
+	// If the left half is entirely valid, the fault must be in the right half.
+	right := bisectFaults(indices[mid:], check, len(left) == 0)
+	return append(append(make([]int, 0, len(left)+len(right)), left...), right...)
+}
+
+// randomizedExponents returns n uniformly random exponents, used to
+// prevent invalid signatures from cancelling each other out in a batch.
+func randomizedExponents(curve CurveSystem, n int) []*big.Int {
+	exps := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		exps[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+	}
+	return exps
+}
+
+// batchVerifyTriples checks that prod e(r_i H(m_i), pk_i) = e(sum r_i sig_i, g2)
+// for the signatures at the given indices. hashes are the hashes of the distinct
+// messages, and msgIndex maps each signature to its message's hash.
+func batchVerifyTriples(curve CurveSystem, sigs []Point, keys []Point, hashes []Point,
+	msgIndex []int, indices []int) bool {
+	subSigs := make([]Point, len(indices))
+	subKeys := make([]Point, len(indices))
+	subMsgIndex := make([]int, len(indices))
+	for i, index := range indices {
+		subSigs[i] = sigs[index]
+		subKeys[i] = keys[index]
+		subMsgIndex[i] = msgIndex[index]
+	}
+	exps := randomizedExponents(curve, len(indices))
+	aggSig := AggregatePoints(ScalePoints(subSigs, exps))
+	pts1, pts2, _ := groupPairings(context.Background(), subKeys, hashes, subMsgIndex, exps)
+	pts1 = append(pts1, aggSig.Mul(new(big.Int).SetInt64(-1)))
+	pts2 = append(pts2, curve.GetG2())
+	aggPt, ok := curve.PairingProduct(pts1, pts2)
+	if ok {
+		return aggPt.Equals(curve.GetGTIdentity())
+	}
+	return ok
+}
+
+// batchVerifySameMessage checks that e(H(m), sum r_i pk_i) = e(sum r_i sig_i, g2)
+// for the signatures at the given indices.
+func batchVerifySameMessage(curve CurveSystem, sigs []Point, keys []Point, h Point, indices []int) bool {
+	subSigs := make([]Point, len(indices))
+	subKeys := make([]Point, len(indices))
+	for i, index := range indices {
+		subSigs[i] = sigs[index]
+		subKeys[i] = keys[index]
+	}
+	exps := randomizedExponents(curve, len(indices))
+	aggSig := AggregatePoints(ScalePoints(subSigs, exps))
+	aggKey := AggregatePoints(ScalePoints(subKeys, exps))
+	return VerifySingleSignatureCustHash(curve, aggSig, aggKey, nil,
+		HashFunc(func([]byte) Point { return h }))
+}
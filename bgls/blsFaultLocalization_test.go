@@ -0,0 +1,100 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindInvalidSignatures(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 9, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			pubkeys[i] = vk
+		}
+		invalid, ok := FindInvalidSignatures(curve, sigs, pubkeys, msgs)
+		assert.True(t, ok)
+		assert.Empty(t, invalid, "Valid signatures were reported as invalid")
+
+		// Corrupt two signatures such that their sum is unchanged, so that
+		// the aggregate signature still verifies.
+		sigs[2], _ = sigs[2].Add(curve.GetG1())
+		sigs[7], _ = sigs[7].Add(curve.GetG1().Mul(new(big.Int).SetInt64(-1)))
+		assert.True(t, VerifyAggregateSignature(curve, AggregateSignatures(sigs), pubkeys, msgs))
+		invalid, ok = FindInvalidSignatures(curve, sigs, pubkeys, msgs)
+		assert.True(t, ok)
+		assert.Equal(t, []int{2, 7}, invalid, "Cancelling invalid signatures weren't found")
+
+		sigs[0] = Sign(curve, big.NewInt(1), msgs[0])
+		invalid, _ = FindInvalidSignatures(curve, sigs, pubkeys, msgs)
+		assert.Equal(t, []int{0, 2, 7}, invalid)
+
+		_, ok = FindInvalidSignatures(curve, sigs, pubkeys[:N-1], msgs)
+		assert.False(t, ok, "Fault localization succeeded with mismatched lengths")
+	}
+}
+
+func TestFindInvalidMultiSignatureShares(t *testing.T) {
+	for _, curve := range curves {
+		N := 16
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		koskSigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msg)
+			koskSigs[i] = KoskSign(curve, sk, msg)
+			pubkeys[i] = vk
+		}
+		invalid, ok := FindInvalidMultiSignatureShares(curve, sigs, pubkeys, msg)
+		assert.True(t, ok)
+		assert.Empty(t, invalid)
+		invalid, _ = KoskFindInvalidMultiSignatureShares(curve, koskSigs, pubkeys, msg)
+		assert.Empty(t, invalid)
+
+		// A kosk signature is not a valid standard signature
+		sigs[5] = koskSigs[5]
+		sigs[15] = koskSigs[14]
+		invalid, _ = FindInvalidMultiSignatureShares(curve, sigs, pubkeys, msg)
+		assert.Equal(t, []int{5, 15}, invalid)
+		koskSigs[0] = koskSigs[1]
+		invalid, _ = KoskFindInvalidMultiSignatureShares(curve, koskSigs, pubkeys, msg)
+		assert.Equal(t, []int{0}, invalid)
+
+		_, ok = FindInvalidMultiSignatureShares(curve, sigs, pubkeys[1:], msg)
+		assert.False(t, ok, "Fault localization succeeded with mismatched lengths")
+	}
+}
+
+func TestBisectFaultsCheckCount(t *testing.T) {
+	N := 1024
+	bad := map[int]bool{3: true, 700: true}
+	checks := 0
+	check := func(indices []int) bool {
+		checks++
+		for _, i := range indices {
+			if bad[i] {
+				return false
+			}
+		}
+		return true
+	}
+	assert.Equal(t, []int{3, 700}, localizeFaults(N, check))
+	// Each bad entry costs at most 2 checks per level of the bisection tree
+	assert.True(t, checks <= 1+2*2*10, "Fault localization used too many checks")
+}
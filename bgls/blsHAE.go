@@ -25,6 +25,7 @@ package bgls
 // VerifyMultiSignatureWithHAE, VerifyAggregateSignatureWithHAE
 
 import (
+	"context"
 	"crypto/rand"
 	"math/big"
 
@@ -37,19 +38,40 @@ import (
 // hashed exponents derived from the pubkeys to protect against the rogue
 // public key attack.
 func AggregateSignaturesWithHAE(sigs []Point, pubkeys []Point) Point {
+	aggsig, _ := AggregateSignaturesWithHAEContext(context.Background(), sigs, pubkeys)
+	return aggsig
+}
+
+// AggregateSignaturesWithHAEContext is AggregateSignaturesWithHAE, but stops
+// early and returns ctx.Err() if ctx is cancelled.
+func AggregateSignaturesWithHAEContext(ctx context.Context, sigs []Point, pubkeys []Point) (Point, error) {
 	if len(pubkeys) != len(sigs) {
-		return nil
+		return nil, ctx.Err()
 	}
 	t := hashPubKeysToExponents(pubkeys)
-	newsigs := ScalePoints(sigs, t)
-	return AggregatePoints(newsigs)
+	newsigs, err := ScalePointsContext(ctx, sigs, t)
+	if err != nil {
+		return nil, err
+	}
+	return AggregatePointsContext(ctx, newsigs)
 }
 
 // VerifyAggregateSignatureWithHAE verifies signatures of different messages aggregated with HAE.
 func VerifyAggregateSignatureWithHAE(curve CurveSystem, aggsig Point, pubkeys []Point, msgs [][]byte) bool {
+	ok, _ := VerifyAggregateSignatureWithHAEContext(context.Background(), curve, aggsig, pubkeys, msgs)
+	return ok
+}
+
+// VerifyAggregateSignatureWithHAEContext is VerifyAggregateSignatureWithHAE,
+// but stops early and returns ctx.Err() if ctx is cancelled.
+func VerifyAggregateSignatureWithHAEContext(ctx context.Context, curve CurveSystem, aggsig Point,
+	pubkeys []Point, msgs [][]byte) (bool, error) {
 	t := hashPubKeysToExponents(pubkeys)
-	newkeys := ScalePoints(pubkeys, t)
-	return verifyAggSig(curve, aggsig, newkeys, msgs, true)
+	newkeys, err := ScalePointsContext(ctx, pubkeys, t)
+	if err != nil {
+		return false, err
+	}
+	return verifyAggSigContext(ctx, curve, aggsig, newkeys, msgs, true, DefaultHasher(curve))
 }
 
 // VerifyMultiSignatureWithHAE verifies signatures of the same message aggregated with HAE.
@@ -57,6 +79,18 @@ func VerifyMultiSignatureWithHAE(curve CurveSystem, aggsig Point, pubkeys []Poin
 	return VerifySingleSignature(curve, aggsig, getAggregatePubKey(curve, pubkeys), msg)
 }
 
+// VerifyMultiSignatureWithHAEContext is VerifyMultiSignatureWithHAE, but stops
+// early and returns ctx.Err() if ctx is cancelled.
+func VerifyMultiSignatureWithHAEContext(ctx context.Context, curve CurveSystem, aggsig Point,
+	pubkeys []Point, msg []byte) (bool, error) {
+	t := hashPubKeysToExponents(pubkeys)
+	scaledKeys, err := ScalePointsContext(ctx, pubkeys, t)
+	if err != nil {
+		return false, err
+	}
+	return verifyMultiSignatureContext(ctx, curve, aggsig, scaledKeys, msg, DefaultHasher(curve))
+}
+
 // VerifyBatchMultiSignatureWithHAE verifies multiple MultiSignatures
 // are valid, in time faster than verifying each multisignature individually.
 func VerifyBatchMultiSignatureWithHAE(curve CurveSystem, aggsigs []Point, aggpubkeys []Point, msgs [][]byte, allowDups bool) bool {
@@ -68,7 +102,7 @@ func VerifyBatchMultiSignatureWithHAE(curve CurveSystem, aggsigs []Point, aggpub
 		ScalePoints(aggsigs, t)
 	}
 	aggsig := AggregateSignatures(aggsigs)
-	return verifyAggSig(curve, aggsig, aggpubkeys, msgs, true)
+	return verifyAggSig(curve, aggsig, aggpubkeys, msgs, true, DefaultHasher(curve))
 }
 
 func getAggregatePubKey(curve CurveSystem, pubkeys []Point) Point {
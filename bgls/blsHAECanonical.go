@@ -0,0 +1,248 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// Canonical HAE is BLS with hashed aggregation exponents, where the keys are
+// sorted before they're hashed to exponents. Keys are sorted by their
+// uncompressed marshal, so the exponents only depend on the set of signers,
+// and not the order in which their signatures were collected. Signers are
+// addressed by their public key, so signatures and keys can be passed in any
+// order, as long as each signature is at the same position as its key. A key
+// can't sign twice.
+//
+// An HAE multisignature can't be re-aggregated into a multisignature over a
+// larger set, since every exponent depends on the whole set. Instead, a
+// CanonicalHAEMultiSig keeps the disjoint signer sets which it was combined
+// from, along with each set's HAE multisignature. Simply summing the sets'
+// aggregate keys would reopen the rogue key attack: a set's exponents only
+// depend on that set, so an attacker could submit many sets of their own and
+// search for a combination that cancels an honest set's key. So the sets'
+// aggregate keys are themselves weighted with HAE exponents, from hashing all
+// of them in canonical order, and the combined signature is the sum of the
+// sets' multisignatures scaled by the same exponents. Both the sets and the
+// keys within them are kept in canonical order, so combining is commutative
+// and associative.
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// CanonicalHAEMultiSig is a multisignature on a single message, combined from
+// HAE multisignatures over disjoint sets of signers.
+type CanonicalHAEMultiSig struct {
+	// Sig is the sum of the groups' multisignatures, each scaled by the
+	// group's exponent in the combination.
+	Sig Point
+	// Groups are the sets of signers whose HAE multisignatures were combined,
+	// each in canonical order.
+	Groups [][]Point
+	// GroupSigs are the groups' HAE multisignatures, which are needed to
+	// rescale them when combining further.
+	GroupSigs []Point
+}
+
+// SortPubKeys returns a copy of the keys in canonical order.
+func SortPubKeys(pubkeys []Point) []Point {
+	sorted, _, _ := sortPubKeys(pubkeys)
+	return sorted
+}
+
+// sortPubKeys sorts the keys, and returns the original position of each
+// sorted key. It also returns false if a key is repeated.
+func sortPubKeys(pubkeys []Point) ([]Point, []int, bool) {
+	marshalled := make([][]byte, len(pubkeys))
+	order := make([]int, len(pubkeys))
+	for i, key := range pubkeys {
+		marshalled[i] = key.MarshalUncompressed()
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bytes.Compare(marshalled[order[a]], marshalled[order[b]]) < 0
+	})
+	sorted := make([]Point, len(pubkeys))
+	distinct := true
+	for i, j := range order {
+		sorted[i] = pubkeys[j]
+		if i > 0 && bytes.Equal(marshalled[j], marshalled[order[i-1]]) {
+			distinct = false
+		}
+	}
+	return sorted, order, distinct
+}
+
+// canonicalExponents returns the exponent of each key, in the keys' given
+// order, from hashing them in canonical order.
+func canonicalExponents(pubkeys []Point) ([]*big.Int, bool) {
+	sorted, order, ok := sortPubKeys(pubkeys)
+	if !ok {
+		return nil, false
+	}
+	sortedExps := hashPubKeysToExponents(sorted)
+	exps := make([]*big.Int, len(pubkeys))
+	for i, j := range order {
+		exps[j] = sortedExps[i]
+	}
+	return exps, true
+}
+
+// getCanonicalAggregatePubKey returns sum_i t_i * pk_i, with the exponents
+// from hashing the keys in canonical order.
+func getCanonicalAggregatePubKey(pubkeys []Point) (Point, bool) {
+	exps, ok := canonicalExponents(pubkeys)
+	if !ok || len(pubkeys) == 0 {
+		return nil, false
+	}
+	return AggregatePoints(ScalePoints(pubkeys, exps)), true
+}
+
+// AggregateSignaturesWithCanonicalHAE aggregates the signatures of the keys
+// at the same positions, with exponents from hashing the keys in canonical
+// order. It returns false if a key is repeated.
+func AggregateSignaturesWithCanonicalHAE(sigs []Point, pubkeys []Point) (Point, bool) {
+	if len(sigs) != len(pubkeys) || len(sigs) == 0 {
+		return nil, false
+	}
+	exps, ok := canonicalExponents(pubkeys)
+	if !ok {
+		return nil, false
+	}
+	return AggregatePoints(ScalePoints(sigs, exps)), true
+}
+
+// VerifyMultiSignatureWithCanonicalHAE verifies an HAE multisignature on msg
+// from the keys, which may be in any order.
+func VerifyMultiSignatureWithCanonicalHAE(curve CurveSystem, aggsig Point, pubkeys []Point, msg []byte) bool {
+	apk, ok := getCanonicalAggregatePubKey(pubkeys)
+	if !ok {
+		return false
+	}
+	return VerifySingleSignature(curve, aggsig, apk, msg)
+}
+
+// VerifyAggregateSignatureWithCanonicalHAE verifies signatures of different
+// messages aggregated with canonical HAE. The keys and messages may be in any
+// order, as long as each message is at the same position as its key.
+func VerifyAggregateSignatureWithCanonicalHAE(curve CurveSystem, aggsig Point, pubkeys []Point, msgs [][]byte) bool {
+	exps, ok := canonicalExponents(pubkeys)
+	if !ok {
+		return false
+	}
+	return verifyAggSig(curve, aggsig, ScalePoints(pubkeys, exps), msgs, true, DefaultHasher(curve))
+}
+
+// NewCanonicalHAEMultiSig aggregates the signatures on a single message into
+// a CanonicalHAEMultiSig with one group of signers. It returns false if a key
+// is repeated.
+func NewCanonicalHAEMultiSig(sigs []Point, pubkeys []Point) (*CanonicalHAEMultiSig, bool) {
+	aggsig, ok := AggregateSignaturesWithCanonicalHAE(sigs, pubkeys)
+	if !ok {
+		return nil, false
+	}
+	return newCanonicalHAEMultiSig([][]Point{SortPubKeys(pubkeys)}, []Point{aggsig})
+}
+
+// CombineCanonicalHAEMultiSigs combines two multisignatures on the same
+// message, over disjoint sets of signers, into a multisignature over their
+// union. It returns false if a key signed both multisignatures, or a group is empty.
+func CombineCanonicalHAEMultiSigs(a *CanonicalHAEMultiSig, b *CanonicalHAEMultiSig) (*CanonicalHAEMultiSig, bool) {
+	if len(a.Groups) != len(a.GroupSigs) || len(b.Groups) != len(b.GroupSigs) {
+		return nil, false
+	}
+	groups := append(append([][]Point{}, a.Groups...), b.Groups...)
+	groupSigs := append(append([]Point{}, a.GroupSigs...), b.GroupSigs...)
+	return newCanonicalHAEMultiSig(groups, groupSigs)
+}
+
+// newCanonicalHAEMultiSig sorts the groups, and sums their multisignatures
+// scaled by the groups' exponents.
+func newCanonicalHAEMultiSig(groups [][]Point, groupSigs []Point) (*CanonicalHAEMultiSig, bool) {
+	for _, group := range groups {
+		if len(group) == 0 {
+			return nil, false
+		}
+	}
+	m := &CanonicalHAEMultiSig{Groups: groups, GroupSigs: groupSigs}
+	if _, _, ok := sortPubKeys(m.Signers()); !ok {
+		return nil, false
+	}
+	sort.Sort(byFirstKey(*m))
+	exps, ok := groupExponents(m.Groups)
+	if !ok {
+		return nil, false
+	}
+	m.Sig = AggregatePoints(ScalePoints(m.GroupSigs, exps))
+	return m, true
+}
+
+// byFirstKey sorts a multisignature's groups, and their signatures alongside
+// them, by each group's first key.
+type byFirstKey CanonicalHAEMultiSig
+
+func (m byFirstKey) Len() int { return len(m.Groups) }
+
+func (m byFirstKey) Less(i, j int) bool {
+	return bytes.Compare(m.Groups[i][0].MarshalUncompressed(), m.Groups[j][0].MarshalUncompressed()) < 0
+}
+
+func (m byFirstKey) Swap(i, j int) {
+	m.Groups[i], m.Groups[j] = m.Groups[j], m.Groups[i]
+	m.GroupSigs[i], m.GroupSigs[j] = m.GroupSigs[j], m.GroupSigs[i]
+}
+
+// groupAggregatePubKeys returns each group's canonical HAE aggregate key.
+func groupAggregatePubKeys(groups [][]Point) ([]Point, bool) {
+	apks := make([]Point, len(groups))
+	for i, group := range groups {
+		apk, ok := getCanonicalAggregatePubKey(group)
+		if !ok {
+			return nil, false
+		}
+		apks[i] = apk
+	}
+	return apks, true
+}
+
+// groupExponents returns each group's exponent in a combination, from hashing
+// all of the groups' aggregate keys in canonical order, so that every
+// exponent depends on every group.
+func groupExponents(groups [][]Point) ([]*big.Int, bool) {
+	apks, ok := groupAggregatePubKeys(groups)
+	if !ok {
+		return nil, false
+	}
+	return canonicalExponents(apks)
+}
+
+// Signers returns every signer of the multisignature, in canonical order.
+func (m *CanonicalHAEMultiSig) Signers() []Point {
+	signers := make([]Point, 0)
+	for _, group := range m.Groups {
+		signers = append(signers, group...)
+	}
+	return SortPubKeys(signers)
+}
+
+// Verify verifies the multisignature on msg. Every group must be non-empty,
+// and no key may be in more than one group.
+func (m *CanonicalHAEMultiSig) Verify(curve CurveSystem, msg []byte) bool {
+	if len(m.Groups) == 0 {
+		return false
+	}
+	if _, _, ok := sortPubKeys(m.Signers()); !ok {
+		return false
+	}
+	apks, ok := groupAggregatePubKeys(m.Groups)
+	if !ok {
+		return false
+	}
+	exps, ok := canonicalExponents(apks)
+	if !ok {
+		return false
+	}
+	return VerifySingleSignature(curve, m.Sig, AggregatePoints(ScalePoints(apks, exps)), msg)
+}
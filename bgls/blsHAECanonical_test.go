@@ -0,0 +1,136 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalHAE(t *testing.T) {
+	N := 6
+	for _, curve := range curves {
+		sks := make([]*big.Int, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+		}
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sigs[i] = Sign(curve, sks[i], msg)
+		}
+
+		aggsig, ok := AggregateSignaturesWithCanonicalHAE(sigs, keys)
+		assert.True(t, ok)
+		// The order of the signers doesn't matter.
+		reversedSigs := make([]Point, N)
+		reversedKeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			reversedSigs[i] = sigs[N-1-i]
+			reversedKeys[i] = keys[N-1-i]
+		}
+		reversed, _ := AggregateSignaturesWithCanonicalHAE(reversedSigs, reversedKeys)
+		assert.True(t, aggsig.Equals(reversed), "Canonical HAE depends on the signers' order")
+		assert.True(t, VerifyMultiSignatureWithCanonicalHAE(curve, aggsig, reversedKeys, msg))
+		sorted := SortPubKeys(keys)
+		assert.True(t, VerifyMultiSignatureWithHAE(curve, aggsig, sorted, msg),
+			"Canonical HAE differs from HAE over the sorted keys")
+		_, ok = AggregateSignaturesWithCanonicalHAE(append(sigs, sigs[0]), append(keys, keys[0]))
+		assert.False(t, ok, "Repeated signer was aggregated")
+		assert.False(t, VerifyMultiSignatureWithCanonicalHAE(curve, aggsig, keys[1:], msg))
+
+		msgs := make([][]byte, N)
+		distinctSigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = []byte{byte(i)}
+			distinctSigs[i] = Sign(curve, sks[i], msgs[i])
+		}
+		aggsig, _ = AggregateSignaturesWithCanonicalHAE(distinctSigs, keys)
+		assert.True(t, VerifyAggregateSignatureWithCanonicalHAE(curve, aggsig, keys, msgs))
+		msgs[0], msgs[1] = msgs[1], msgs[0]
+		assert.False(t, VerifyAggregateSignatureWithCanonicalHAE(curve, aggsig, keys, msgs))
+	}
+}
+
+func TestCombineCanonicalHAEMultiSigs(t *testing.T) {
+	N := 7
+	for _, curve := range curves {
+		sks := make([]*big.Int, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+		}
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sigs[i] = Sign(curve, sks[i], msg)
+		}
+		a, ok := NewCanonicalHAEMultiSig(sigs[:3], keys[:3])
+		assert.True(t, ok)
+		b, _ := NewCanonicalHAEMultiSig(sigs[3:5], keys[3:5])
+		c, _ := NewCanonicalHAEMultiSig(sigs[5:], keys[5:])
+		assert.True(t, a.Verify(curve, msg))
+
+		ab, ok := CombineCanonicalHAEMultiSigs(a, b)
+		assert.True(t, ok)
+		assert.True(t, ab.Verify(curve, msg), "Combined multisignature failed verification")
+		abc, _ := CombineCanonicalHAEMultiSigs(ab, c)
+		cba, _ := CombineCanonicalHAEMultiSigs(c, ab)
+		assert.True(t, abc.Verify(curve, msg))
+		assert.True(t, abc.Sig.Equals(cba.Sig))
+		assert.Equal(t, len(abc.Groups), len(cba.Groups))
+		for i := range abc.Groups {
+			assert.True(t, abc.Groups[i][0].Equals(cba.Groups[i][0]), "Combining isn't commutative")
+		}
+		signers := abc.Signers()
+		assert.Equal(t, N, len(signers))
+		assert.Equal(t, SortPubKeys(keys), signers)
+
+		_, ok = CombineCanonicalHAEMultiSigs(ab, a)
+		assert.False(t, ok, "Multisignatures with a common signer were combined")
+		// Moving a signer to another group breaks verification.
+		abc.Groups[0], abc.Groups[1] = abc.Groups[0][1:], append(abc.Groups[1], abc.Groups[0][0])
+		assert.False(t, abc.Verify(curve, msg), "Multisignature verified with the wrong groups")
+		ab.Groups = append(ab.Groups, ab.Groups[0])
+		assert.False(t, ab.Verify(curve, msg), "Multisignature with a repeated group verified")
+	}
+}
+
+// TestCanonicalHAERogueGroup checks that a group of the attacker's choosing
+// can't cancel an honest group's aggregate key in a combination.
+func TestCanonicalHAERogueGroup(t *testing.T) {
+	N := 3
+	for _, curve := range curves {
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			_, keys[i], _ = KeyGen(curve)
+		}
+		honest := SortPubKeys(keys)
+		X, _ := getCanonicalAggregatePubKey(honest)
+		msg := []byte("transfer everything")
+
+		// The attacker knows u, and picks the key of a single key group so that
+		// the combination's key is u * g2 under the exponents of a guessed
+		// combination. Since the exponents are bound to every group in the
+		// combination, including the rogue one, they change with its key.
+		order := curve.GetG1Order()
+		u, guess, _ := KeyGen(curve)
+		target := LoadPublicKey(curve, u)
+		exps, _ := groupExponents([][]Point{honest, {guess}})
+		diff, _ := target.Add(X.Mul(new(big.Int).Sub(order, exps[0])))
+		rogue := diff.Mul(new(big.Int).ModInverse(exps[1], order))
+		fixed, _ := X.Mul(exps[0]).Add(rogue.Mul(exps[1]))
+		assert.True(t, fixed.Equals(target), "Rogue key doesn't cancel under fixed exponents")
+
+		forged := &CanonicalHAEMultiSig{Sig: Sign(curve, u, msg), Groups: [][]Point{honest, {rogue}}}
+		assert.False(t, forged.Verify(curve, msg), "Rogue group cancelled an honest group's key")
+	}
+}
@@ -32,8 +32,17 @@ package bgls
 // intended to use: AggregateSignatures, KeyGen, KoskSign,
 // KoskVerifySingleSignature, KoskVerifyMultiSignature
 // KoskVerifyMultiSignatureWithMultiplicity, KoskVerifyAggregateSignature
+//
+// These verifiers accept any keys, so they are only secure if every key was
+// checked with CheckAuthentication first. To have that checked on every
+// verification, register the keys' authentications with a PopRegistry, and use
+// the KoskVerifyRegistered variants instead: KoskVerifyRegisteredSingleSignature,
+// KoskVerifyRegisteredMultiSignature, KoskVerifyRegisteredMultiSignatureWithMultiplicity,
+// KoskVerifyRegisteredAggregateSignature, KoskVerifyRegisteredBatchMultiSignature,
+// and their CustHash and Context forms (see popRegistry.go).
 
 import (
+	"context"
 	"math/big"
 
 	. "github.com/Project-Arda/bgls/curves" // nolint: golint
@@ -42,13 +51,13 @@ import (
 // Authenticate generates an Aggregatable Authentication for a given secret key.
 // It signs the public key generated from sk, with a 0x01 byte prepended to it.
 func Authenticate(curve CurveSystem, sk *big.Int) Point {
-	return AuthenticateCustHash(curve, sk, curve.HashToG1)
+	return AuthenticateCustHash(curve, sk, DefaultHasher(curve))
 }
 
 // AuthenticateCustHash generates an Aggregatable Authentication for a given secret key.
 // It signs the public key generated from sk, with a null byte prepended to it.
-// This runs with the specified hash function.
-func AuthenticateCustHash(curve CurveSystem, sk *big.Int, hash func([]byte) Point) Point {
+// This runs with the specified Hasher.
+func AuthenticateCustHash(curve CurveSystem, sk *big.Int, hash Hasher) Point {
 	msg := LoadPublicKey(curve, sk).Marshal()
 	msg = append(make([]byte, 0), msg...)
 	return SignCustHash(sk, msg, hash)
@@ -57,12 +66,12 @@ func AuthenticateCustHash(curve CurveSystem, sk *big.Int, hash func([]byte) Poin
 // CheckAuthentication verifies that the provided signature is in fact authentication
 // for this public key.
 func CheckAuthentication(curve CurveSystem, pubkey Point, authentication Point) bool {
-	return CheckAuthenticationCustHash(curve, pubkey, authentication, curve.HashToG1)
+	return CheckAuthenticationCustHash(curve, pubkey, authentication, DefaultHasher(curve))
 }
 
 // CheckAuthenticationCustHash verifies that the provided signature is in fact authentication
 // for this public key.
-func CheckAuthenticationCustHash(curve CurveSystem, pubkey Point, authentication Point, hash func([]byte) Point) bool {
+func CheckAuthenticationCustHash(curve CurveSystem, pubkey Point, authentication Point, hash Hasher) bool {
 	msg := pubkey.Marshal()
 	msg = append(make([]byte, 0), msg...)
 	return VerifySingleSignatureCustHash(curve, authentication, pubkey, msg, hash)
@@ -71,38 +80,55 @@ func CheckAuthenticationCustHash(curve CurveSystem, pubkey Point, authentication
 // KoskSign creates a kosk signature on a message with a private key.
 // A kosk signature prepends a 0x01 byte to the message before signing.
 func KoskSign(curve CurveSystem, sk *big.Int, msg []byte) Point {
-	return KoskSignCustHash(curve, sk, msg, curve.HashToG1)
+	return KoskSignCustHash(curve, sk, msg, DefaultHasher(curve))
 }
 
 // KoskSignCustHash creates a kosk signature on a message with a private key, using
-// a supplied function to hash to point. A kosk signature prepends a 0x01 byte
+// a supplied Hasher to hash to point. A kosk signature prepends a 0x01 byte
 // to the message before signing.
-func KoskSignCustHash(curve CurveSystem, sk *big.Int, msg []byte, hash func([]byte) Point) Point {
+func KoskSignCustHash(curve CurveSystem, sk *big.Int, msg []byte, hash Hasher) Point {
 	m := append([]byte{1}, msg...)
 	return SignCustHash(sk, m, hash)
 }
 
 // KoskVerifySingleSignature checks that a single kosk signature is valid.
 func KoskVerifySingleSignature(curve CurveSystem, sig Point, pubKey Point, msg []byte) bool {
-	return KoskVerifySingleSignatureCustHash(curve, pubKey, msg, sig, curve.HashToG1)
+	return KoskVerifySingleSignatureCustHash(curve, pubKey, msg, sig, DefaultHasher(curve))
 }
 
 // KoskVerifySingleSignatureCustHash checks that a single kosk signature is valid,
-// with the supplied hash function.
+// with the supplied Hasher.
 func KoskVerifySingleSignatureCustHash(curve CurveSystem, pubKey Point, msg []byte,
-	sig Point, hash func([]byte) Point) bool {
+	sig Point, hash Hasher) bool {
 	m := append([]byte{1}, msg...)
-	return VerifySingleSignature(curve, sig, pubKey, m)
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, m, hash)
 }
 
 // KoskVerifyAggregateSignature verifies that the aggregated signature proves
 // that all messages were signed by the associated keys.
 func KoskVerifyAggregateSignature(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	return KoskVerifyAggregateSignatureCustHash(curve, aggsig, keys, msgs, DefaultHasher(curve))
+}
+
+// KoskVerifyAggregateSignatureCustHash verifies that the aggregated signature
+// proves that all messages were signed by the associated keys, with the supplied Hasher.
+func KoskVerifyAggregateSignatureCustHash(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, hash Hasher) bool {
+	newMsgs := make([][]byte, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		newMsgs[i] = append([]byte{1}, msgs[i]...)
+	}
+	return verifyAggSig(curve, aggsig, keys, newMsgs, true, hash)
+}
+
+// KoskVerifyAggregateSignatureContext is KoskVerifyAggregateSignature, but
+// stops early and returns ctx.Err() if ctx is cancelled.
+func KoskVerifyAggregateSignatureContext(ctx context.Context, curve CurveSystem, aggsig Point,
+	keys []Point, msgs [][]byte) (bool, error) {
 	newMsgs := make([][]byte, len(msgs))
 	for i := 0; i < len(msgs); i++ {
 		newMsgs[i] = append([]byte{1}, msgs[i]...)
 	}
-	return verifyAggSig(curve, aggsig, keys, newMsgs, true)
+	return verifyAggSigContext(ctx, curve, aggsig, keys, newMsgs, true, DefaultHasher(curve))
 }
 
 // Verify checks that a single message has been signed by a set of keys
@@ -115,8 +141,22 @@ func (m MultiSig) Verify(curve CurveSystem) bool {
 // that a single message has been signed by a set of keys,
 // vulnerable against chosen key attack, if keys have not been authenticated
 func KoskVerifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
+	return KoskVerifyMultiSignatureCustHash(curve, aggsig, keys, msg, DefaultHasher(curve))
+}
+
+// KoskVerifyMultiSignatureCustHash checks that the aggregate signature correctly
+// proves that a single message has been signed by a set of keys, with the supplied Hasher.
+func KoskVerifyMultiSignatureCustHash(curve CurveSystem, aggsig Point, keys []Point, msg []byte, hash Hasher) bool {
+	msg2 := append([]byte{1}, msg...)
+	return verifyMultiSignature(curve, aggsig, keys, msg2, hash)
+}
+
+// KoskVerifyMultiSignatureContext is KoskVerifyMultiSignature, but stops early
+// and returns ctx.Err() if ctx is cancelled.
+func KoskVerifyMultiSignatureContext(ctx context.Context, curve CurveSystem, aggsig Point,
+	keys []Point, msg []byte) (bool, error) {
 	msg2 := append([]byte{1}, msg...)
-	return verifyMultiSignature(curve, aggsig, keys, msg2)
+	return verifyMultiSignatureContext(ctx, curve, aggsig, keys, msg2, DefaultHasher(curve))
 }
 
 // KoskVerifyBatchMultiSignature checks that the set of aggregate signatures correctly proves
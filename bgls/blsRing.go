@@ -0,0 +1,171 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// Ring signatures are from section 6 of the BGLS paper. A signer picks a ring
+// of public keys which includes their own, and signs on behalf of the ring.
+// The signature shows that some member of the ring signed, but not which one.
+//
+// A ring signature on m is one point sigma_i on G1 for each key pk_i in the
+// ring, such that e(H(m), g2) = prod_i e(sigma_i, pk_i). This is an aggregate
+// signature equation, so it is checked with a single pairing product. The
+// signer s picks sigma_i = a_i * g1 for every other member with random a_i,
+// and solves for their own sigma_s = sk_s^-1 * (H(m) - sum_i a_i * x_i * g1).
+//
+// Solving for sigma_s needs x_i * g1 for every other member, which the paper
+// gets from pk_i with a computable isomorphism from G2 to G1. Neither curve
+// has one, so each member also publishes their key on G1, from LoadRingKey.
+// Only signers need these keys. If one is wrong, the signature fails to
+// verify, so verifiers only need the usual keys on G2.
+//
+// The ring is kept in canonical order, as for canonical HAE, so a signature
+// doesn't depend on the order which the signer listed the ring in.
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// RingSignature is a signature from an unknown member of the ring.
+type RingSignature struct {
+	// Ring is the public keys of the ring, in canonical order.
+	Ring []Point
+	// Sigs has one point for each key in the ring.
+	Sigs []Point
+}
+
+// LoadRingKey returns sk * g1, the key on G1 which other signers need to
+// include this key in a ring.
+func LoadRingKey(curve CurveSystem, sk *big.Int) Point {
+	return curve.GetG1().Mul(sk)
+}
+
+// VerifyRingKey checks that the key on G1 from LoadRingKey matches the public key.
+func VerifyRingKey(curve CurveSystem, pubKey Point, ringKey Point) bool {
+	negG1 := curve.GetG1().Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{ringKey, negG1}, []Point{curve.GetG2(), pubKey})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// RingSign signs msg on behalf of the ring of public keys, which must include
+// the key for sk. ringKeys are the members' keys on G1, at the same positions
+// as their public keys. The ring may be in any order.
+func RingSign(curve CurveSystem, sk *big.Int, pubKeys []Point, ringKeys []Point, msg []byte) (
+	*RingSignature, error) {
+	return RingSignCustHash(curve, sk, pubKeys, ringKeys, msg, DefaultHasher(curve))
+}
+
+// RingSignCustHash is RingSign, using the supplied Hasher to hash onto G1.
+func RingSignCustHash(curve CurveSystem, sk *big.Int, pubKeys []Point, ringKeys []Point, msg []byte,
+	hash Hasher) (*RingSignature, error) {
+	nonces := make([]*big.Int, len(pubKeys))
+	for i := range nonces {
+		nonce, err := encoding.RandomNonzeroScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		nonces[i] = nonce
+	}
+	return ringSign(curve, sk, pubKeys, ringKeys, hash.Hash(msg), nonces)
+}
+
+// ringSign creates a ring signature with the given nonces, which are indexed
+// by the ring's canonical order. The signer's own nonce is unused.
+func ringSign(curve CurveSystem, sk *big.Int, pubKeys []Point, ringKeys []Point, h Point,
+	nonces []*big.Int) (*RingSignature, error) {
+	if len(pubKeys) != len(ringKeys) || len(pubKeys) == 0 {
+		return nil, errors.New("ring needs one key on G1 for each public key")
+	}
+	ring, order, ok := sortPubKeys(pubKeys)
+	if !ok {
+		return nil, errors.New("ring keys must be distinct")
+	}
+	pubKey := LoadPublicKey(curve, sk)
+	signer := -1
+	for i, key := range ring {
+		if key.Equals(pubKey) {
+			signer = i
+		}
+	}
+	if signer < 0 {
+		return nil, errors.New("signer isn't a member of the ring")
+	}
+	sigs := make([]Point, len(ring))
+	masks := make([]Point, 1, len(ring))
+	masks[0] = h
+	for i := range ring {
+		if i == signer {
+			continue
+		}
+		sigs[i] = curve.GetG1().Mul(nonces[i])
+		negNonce := new(big.Int).Neg(nonces[i])
+		masks = append(masks, ringKeys[order[i]].Mul(negNonce.Mod(negNonce, curve.GetG1Order())))
+	}
+	sigs[signer] = AggregatePoints(masks).Mul(new(big.Int).ModInverse(sk, curve.GetG1Order()))
+	return &RingSignature{ring, sigs}, nil
+}
+
+// Verify checks that the signature on msg is from a member of its ring. The
+// ring must be in canonical order, without repeated keys.
+func (sig *RingSignature) Verify(curve CurveSystem, msg []byte) bool {
+	return sig.VerifyCustHash(curve, msg, DefaultHasher(curve))
+}
+
+// VerifyCustHash is Verify, using the supplied Hasher to hash onto G1.
+func (sig *RingSignature) VerifyCustHash(curve CurveSystem, msg []byte, hash Hasher) bool {
+	if len(sig.Ring) != len(sig.Sigs) || len(sig.Ring) == 0 || !isCanonicalRing(sig.Ring) {
+		return false
+	}
+	negH := hash.Hash(msg).Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct(append(append([]Point{}, sig.Sigs...), negH),
+		append(append([]Point{}, sig.Ring...), curve.GetG2()))
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// isCanonicalRing returns true if the keys are distinct and in canonical order.
+func isCanonicalRing(ring []Point) bool {
+	_, order, ok := sortPubKeys(ring)
+	if !ok {
+		return false
+	}
+	for i, j := range order {
+		if i != j {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal serializes the ring signature.
+func (sig *RingSignature) Marshal() []byte {
+	buf := encoding.AppendUint32(nil, uint32(len(sig.Ring)))
+	for i := range sig.Ring {
+		buf = encoding.AppendLengthPrefixed(buf, sig.Ring[i].Marshal())
+		buf = encoding.AppendLengthPrefixed(buf, sig.Sigs[i].Marshal())
+	}
+	return buf
+}
+
+// UnmarshalRingSignature deserializes a RingSignature created with Marshal.
+// It rejects rings which aren't in canonical order.
+func UnmarshalRingSignature(curve CurveSystem, data []byte) (*RingSignature, bool) {
+	r := encoding.NewReader(data)
+	n := int(r.Uint32())
+	// Each key and signature takes at least 8 bytes, which bounds the allocation.
+	if !r.OK() || n == 0 || n > r.Len()/8 {
+		return nil, false
+	}
+	sig := &RingSignature{make([]Point, n), make([]Point, n)}
+	for i := 0; i < n; i++ {
+		sig.Ring[i] = r.G2(curve)
+		sig.Sigs[i] = r.G1(curve)
+	}
+	if !r.Done() || !isCanonicalRing(sig.Ring) {
+		return nil, false
+	}
+	return sig, true
+}
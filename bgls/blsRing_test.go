@@ -0,0 +1,136 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingSignatures(t *testing.T) {
+	n := 5
+	for _, curve := range curves {
+		sks, pubKeys, ringKeys := ringKeyGen(curve, n)
+		for i := range ringKeys {
+			assert.True(t, VerifyRingKey(curve, pubKeys[i], ringKeys[i]), "Valid ring key failed verification")
+		}
+		assert.False(t, VerifyRingKey(curve, pubKeys[0], ringKeys[1]), "Ring key verified for the wrong public key")
+		msg := make([]byte, 32)
+		rand.Read(msg)
+
+		for i, sk := range sks {
+			sig, err := RingSign(curve, sk, pubKeys, ringKeys, msg)
+			assert.Nil(t, err)
+			assert.True(t, sig.Verify(curve, msg), "Valid ring signature failed verification")
+			assert.False(t, sig.Verify(curve, append(msg, 0)), "Ring signature verified on the wrong message")
+
+			unmarshalled, ok := UnmarshalRingSignature(curve, sig.Marshal())
+			assert.True(t, ok)
+			assert.True(t, unmarshalled.Verify(curve, msg), "Unmarshalled ring signature failed verification")
+			assert.Equal(t, sig.Marshal(), unmarshalled.Marshal())
+			_, ok = UnmarshalRingSignature(curve, append(sig.Marshal(), 0))
+			assert.False(t, ok, "Unmarshalled a ring signature with trailing data")
+
+			// Swapping two members makes the ring non-canonical, even with their signatures swapped.
+			swapped := &RingSignature{append([]Point{}, sig.Ring...), append([]Point{}, sig.Sigs...)}
+			swapped.Ring[0], swapped.Ring[1] = swapped.Ring[1], swapped.Ring[0]
+			swapped.Sigs[0], swapped.Sigs[1] = swapped.Sigs[1], swapped.Sigs[0]
+			assert.False(t, swapped.Verify(curve, msg), "Ring signature verified with a non-canonical ring")
+			_, ok = UnmarshalRingSignature(curve, swapped.Marshal())
+			assert.False(t, ok, "Unmarshalled a ring signature with a non-canonical ring")
+
+			smaller := &RingSignature{sig.Ring[1:], sig.Sigs[1:]}
+			assert.False(t, smaller.Verify(curve, msg), "Ring signature verified without a member")
+			if i == 0 {
+				reversed := make([]Point, n)
+				reversedRingKeys := make([]Point, n)
+				for j := range pubKeys {
+					reversed[j] = pubKeys[n-1-j]
+					reversedRingKeys[j] = ringKeys[n-1-j]
+				}
+				other, _ := RingSign(curve, sk, reversed, reversedRingKeys, msg)
+				for j := range sig.Ring {
+					assert.True(t, sig.Ring[j].Equals(other.Ring[j]), "Ring isn't in canonical order")
+				}
+			}
+		}
+
+		outsider, _, _ := KeyGen(curve)
+		_, err := RingSign(curve, outsider, pubKeys, ringKeys, msg)
+		assert.NotNil(t, err, "Signed for a ring without the signer")
+		_, err = RingSign(curve, sks[0], append(pubKeys, pubKeys[1]), append(ringKeys, ringKeys[1]), msg)
+		assert.NotNil(t, err, "Signed for a ring with a repeated key")
+		_, err = RingSign(curve, sks[0], pubKeys, ringKeys[1:], msg)
+		assert.NotNil(t, err, "Signed without every ring key")
+
+		badRingKeys := append([]Point{}, ringKeys...)
+		badRingKeys[1] = ringKeys[2]
+		sig, _ := RingSign(curve, sks[0], pubKeys, badRingKeys, msg)
+		assert.False(t, sig.Verify(curve, msg), "Ring signature verified with a wrong ring key")
+	}
+}
+
+// TestRingSignatureIndistinguishability checks that for any two members, each
+// choice of one member's nonces gives exactly the same signature as one choice
+// of the other's. Since the nonces are uniform, signatures from any member
+// have the same distribution. The message is hashed to a point with a known
+// discrete log, so that the nonces can be computed from the signature.
+func TestRingSignatureIndistinguishability(t *testing.T) {
+	n := 4
+	for _, curve := range curves {
+		order := curve.GetG1Order()
+		sks, pubKeys, ringKeys := ringKeyGen(curve, n)
+		_, canonical, _ := sortPubKeys(pubKeys)
+		// x[i] is the secret key of the i'th member in canonical order.
+		x := make([]*big.Int, n)
+		for i, j := range canonical {
+			x[i] = sks[j]
+		}
+		c, _ := rand.Int(rand.Reader, order)
+		h := curve.GetG1().Mul(c)
+
+		for signer := 0; signer < n; signer++ {
+			nonces := make([]*big.Int, n)
+			for i := range nonces {
+				nonces[i], _ = rand.Int(rand.Reader, order)
+			}
+			sig, err := ringSign(curve, x[signer], pubKeys, ringKeys, h, nonces)
+			assert.Nil(t, err)
+
+			// The signer's point is sk^-1 * (c - sum_i a_i * x_i) * g1.
+			dlog := new(big.Int).Set(c)
+			for i := range nonces {
+				if i != signer {
+					dlog.Sub(dlog, new(big.Int).Mul(nonces[i], x[i]))
+				}
+			}
+			dlog.Mul(dlog, new(big.Int).ModInverse(x[signer], order))
+			dlogs := append([]*big.Int{}, nonces...)
+			dlogs[signer] = dlog.Mod(dlog, order)
+
+			other := (signer + 1) % n
+			otherSig, err := ringSign(curve, x[other], pubKeys, ringKeys, h, dlogs)
+			assert.Nil(t, err)
+			for i := range sig.Sigs {
+				assert.True(t, sig.Sigs[i].Equals(otherSig.Sigs[i]),
+					"Ring signature can't be produced by another member")
+			}
+		}
+	}
+}
+
+func ringKeyGen(curve CurveSystem, n int) ([]*big.Int, []Point, []Point) {
+	sks := make([]*big.Int, n)
+	pubKeys := make([]Point, n)
+	ringKeys := make([]Point, n)
+	for i := 0; i < n; i++ {
+		sks[i], pubKeys[i], _ = KeyGen(curve)
+		ringKeys[i] = LoadRingKey(curve, sks[i])
+	}
+	return sks, pubKeys, ringKeys
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements t-of-n threshold BLS signatures, with a trusted dealer.
+// The dealer picks a random polynomial f of degree t-1, with f(0) being the
+// secret key, and gives share holder i the secret share f(i). The dealer
+// publishes the verification vector, which is the Feldman commitment
+// (a_0 * g2, a_1 * g2, ..., a_{t-1} * g2) to the coefficients of f. The first
+// element of the verification vector is the group public key.
+//
+// Each share holder can check that its share is consistent with the
+// verification vector, since f(i) * g2 = sum_j i^j * (a_j * g2). The right
+// hand side is also the public key of share i, which anyone can compute.
+//
+// Share holders create partial signatures by signing with their share, using
+// Sign. Any t valid partial signatures are then combined with Lagrange
+// interpolation in the exponent into the signature which the secret key would
+// have produced. This is an ordinary BLS signature, which is verified under the
+// group public key with VerifySingleSignature.
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// SecretShare is a share holder's share of a secret key. Index is the point
+// at which the secret polynomial was evaluated, and starts at 1.
+type SecretShare struct {
+	Index int
+	Value *big.Int
+}
+
+// ThresholdKeyGen generates a random secret key, and splits it into n shares,
+// such that any t of them can sign on behalf of the group. It returns the
+// shares, and the verification vector, whose first element is the group public key.
+func ThresholdKeyGen(curve CurveSystem, t int, n int) ([]SecretShare, []Point, error) {
+	sk, _, err := KeyGen(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ShareSecretKey(curve, sk, t, n)
+}
+
+// ShareSecretKey splits sk into n shares, such that any t of them can sign on
+// behalf of the group. It returns the shares, and the verification vector.
+func ShareSecretKey(curve CurveSystem, sk *big.Int, t int, n int) ([]SecretShare, []Point, error) {
+	if t < 1 || t > n {
+		return nil, nil, errors.New("threshold must be between 1 and the number of shares")
+	}
+	poly, err := randomPolynomial(curve, sk, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]SecretShare, n)
+	for i := 0; i < n; i++ {
+		shares[i] = SecretShare{i + 1, evalPolynomial(curve, poly, big.NewInt(int64(i+1)))}
+	}
+	return shares, CommitPolynomial(curve, poly), nil
+}
+
+// randomPolynomial returns the coefficients of a random polynomial of degree
+// t-1, whose constant term is secret.
+func randomPolynomial(curve CurveSystem, secret *big.Int, t int) ([]*big.Int, error) {
+	poly := make([]*big.Int, t)
+	poly[0] = new(big.Int).Mod(secret, curve.GetG1Order())
+	for i := 1; i < t; i++ {
+		coeff, err := rand.Int(rand.Reader, curve.GetG1Order())
+		if err != nil {
+			return nil, err
+		}
+		poly[i] = coeff
+	}
+	return poly, nil
+}
+
+// evalPolynomial evaluates the polynomial at x, modulo the group order.
+func evalPolynomial(curve CurveSystem, poly []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := len(poly) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, poly[i])
+		result.Mod(result, curve.GetG1Order())
+	}
+	return result
+}
+
+// CommitPolynomial returns the Feldman commitment to the polynomial's
+// coefficients on G2, which is used as a verification vector.
+func CommitPolynomial(curve CurveSystem, poly []*big.Int) []Point {
+	vvec := make([]Point, len(poly))
+	for i := 0; i < len(poly); i++ {
+		vvec[i] = LoadPublicKey(curve, poly[i])
+	}
+	return vvec
+}
+
+// GetSharePublicKey returns the public key corresponding to the share with
+// the given index, from the verification vector.
+func GetSharePublicKey(curve CurveSystem, vvec []Point, index int) Point {
+	return evalCommitment(curve, vvec, big.NewInt(int64(index)))
+}
+
+// evalCommitment evaluates the committed polynomial at x in the exponent,
+// i.e. it computes sum_j x^j * vvec[j].
+func evalCommitment(curve CurveSystem, vvec []Point, x *big.Int) Point {
+	if len(vvec) == 1 {
+		return vvec[0]
+	}
+	powers := make([]*big.Int, len(vvec))
+	powers[0] = big.NewInt(1)
+	for i := 1; i < len(vvec); i++ {
+		powers[i] = new(big.Int).Mul(powers[i-1], x)
+		powers[i].Mod(powers[i], curve.GetG1Order())
+	}
+	return AggregatePoints(ScalePoints(vvec, powers))
+}
+
+// VerifySecretShare checks that the share is consistent with the verification vector.
+func VerifySecretShare(curve CurveSystem, share SecretShare, vvec []Point) bool {
+	if share.Index < 1 || len(vvec) == 0 {
+		return false
+	}
+	return LoadPublicKey(curve, share.Value).Equals(GetSharePublicKey(curve, vvec, share.Index))
+}
+
+// ThresholdVerifyPartialSignature checks that sig is a valid partial signature
+// on msg, from the share with the given index.
+func ThresholdVerifyPartialSignature(curve CurveSystem, sig Point, vvec []Point, index int, msg []byte) bool {
+	if index < 1 || len(vvec) == 0 {
+		return false
+	}
+	return VerifySingleSignature(curve, sig, GetSharePublicKey(curve, vvec, index), msg)
+}
+
+// LagrangeCoefficients returns the Lagrange coefficients for interpolating the
+// value at 0 of a polynomial from its values at the given indices, modulo the
+// group order. It returns false if an index isn't positive, or is repeated.
+func LagrangeCoefficients(curve CurveSystem, indices []int) ([]*big.Int, bool) {
+	order := curve.GetG1Order()
+	seen := make(map[int]bool)
+	for _, index := range indices {
+		if index < 1 || seen[index] {
+			return nil, false
+		}
+		seen[index] = true
+	}
+	coeffs := make([]*big.Int, len(indices))
+	for i, xi := range indices {
+		num := big.NewInt(1)
+		den := big.NewInt(1)
+		for j, xj := range indices {
+			if i == j {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(xj)))
+			num.Mod(num, order)
+			den.Mul(den, big.NewInt(int64(xj-xi)))
+			den.Mod(den, order)
+		}
+		den.ModInverse(den, order)
+		coeffs[i] = num.Mul(num, den)
+		coeffs[i].Mod(coeffs[i], order)
+	}
+	return coeffs, true
+}
+
+// ThresholdCombineSignatures combines partial signatures from the shares with
+// the given indices into the group's signature. If at least t valid partial
+// signatures are provided, the result verifies under the group public key
+// with VerifySingleSignature. It returns false if the indices are invalid.
+func ThresholdCombineSignatures(curve CurveSystem, sigs []Point, indices []int) (Point, bool) {
+	return interpolateInExponent(curve, sigs, indices)
+}
+
+// interpolateInExponent returns sum_i lambda_i * pts[i], where lambda_i are
+// the Lagrange coefficients for the indices.
+func interpolateInExponent(curve CurveSystem, pts []Point, indices []int) (Point, bool) {
+	if len(pts) != len(indices) || len(pts) == 0 {
+		return nil, false
+	}
+	coeffs, ok := LagrangeCoefficients(curve, indices)
+	if !ok {
+		return nil, false
+	}
+	return AggregatePoints(ScalePoints(pts, coeffs)), true
+}
+
+// RecoverSecret interpolates the secret from t or more shares.
+func RecoverSecret(curve CurveSystem, shares []SecretShare) (*big.Int, bool) {
+	indices := make([]int, len(shares))
+	for i, share := range shares {
+		indices[i] = share.Index
+	}
+	coeffs, ok := LagrangeCoefficients(curve, indices)
+	if !ok || len(shares) == 0 {
+		return nil, false
+	}
+	secret := new(big.Int)
+	for i, share := range shares {
+		secret.Add(secret, new(big.Int).Mul(coeffs[i], share.Value))
+	}
+	return secret.Mod(secret, curve.GetG1Order()), true
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdSignatures(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		sk, groupKey, _ := KeyGen(curve)
+		shares, vvec, err := ShareSecretKey(curve, sk, threshold, n)
+		assert.Nil(t, err)
+		assert.Equal(t, threshold, len(vvec))
+		assert.True(t, groupKey.Equals(vvec[0]), "Verification vector doesn't start with the group key")
+		for _, share := range shares {
+			assert.True(t, VerifySecretShare(curve, share, vvec), "Valid share failed verification")
+		}
+		badShare := SecretShare{shares[0].Index, new(big.Int).Add(shares[0].Value, big.NewInt(1))}
+		assert.False(t, VerifySecretShare(curve, badShare, vvec), "Invalid share passed verification")
+
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		partials := make([]Point, n)
+		for i, share := range shares {
+			partials[i] = Sign(curve, share.Value, msg)
+			assert.True(t, ThresholdVerifyPartialSignature(curve, partials[i], vvec, share.Index, msg),
+				"Partial signature failed verification")
+		}
+		assert.False(t, ThresholdVerifyPartialSignature(curve, partials[0], vvec, shares[1].Index, msg),
+			"Partial signature verified under the wrong share")
+
+		expected := Sign(curve, sk, msg)
+		for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 2, 3, 4}} {
+			sigs := make([]Point, len(subset))
+			indices := make([]int, len(subset))
+			for i, j := range subset {
+				sigs[i] = partials[j]
+				indices[i] = shares[j].Index
+			}
+			sig, ok := ThresholdCombineSignatures(curve, sigs, indices)
+			assert.True(t, ok)
+			assert.True(t, expected.Equals(sig), "Combined signature differs from the group's signature")
+			assert.True(t, VerifySingleSignature(curve, sig, vvec[0], msg), "Combined signature failed verification")
+		}
+		sig, _ := ThresholdCombineSignatures(curve, partials[:2], []int{1, 2})
+		assert.False(t, VerifySingleSignature(curve, sig, vvec[0], msg), "Signature with too few partials verified")
+		_, ok := ThresholdCombineSignatures(curve, partials[:2], []int{1, 1})
+		assert.False(t, ok, "Combining with repeated indices succeeded")
+
+		recovered, ok := RecoverSecret(curve, shares[2:])
+		assert.True(t, ok)
+		assert.Equal(t, 0, sk.Cmp(recovered), "Recovered secret differs")
+	}
+}
+
+func TestThresholdKeyGen(t *testing.T) {
+	for _, curve := range curves {
+		_, _, err := ThresholdKeyGen(curve, 4, 3)
+		assert.NotNil(t, err, "Threshold above the number of shares was accepted")
+		shares, vvec, err := ThresholdKeyGen(curve, 1, 3)
+		assert.Nil(t, err)
+		for _, share := range shares {
+			assert.True(t, LoadPublicKey(curve, share.Value).Equals(vvec[0]),
+				"1-of-n shares should all equal the secret key")
+		}
+	}
+}
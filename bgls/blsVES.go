@@ -0,0 +1,107 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// Verifiably encrypted signatures (VES) are from section 5 of the BGLS paper.
+// A signer encrypts their signature under an adjudicator's key, and anyone can
+// check that the ciphertext contains a valid signature on the message. This
+// is used for fair exchange: a party hands over the encrypted signature
+// first, and if they never reveal the plain signature, the adjudicator can
+// extract it.
+//
+// A VES is an aggregate of the signer's signature sigma = sk * H(m) and a
+// "signature" r * A under the adjudicator's key A = a * g1 on the random nonce
+// r. The VES is (omega, mu) = (sigma + r * A, r * g1), and verifies with
+// e(omega, g2) = e(H(m), pk) * e(mu, a * g2). The adjudicator removes their
+// part with sigma = omega - a * mu.
+//
+// The paper uses a pairing with a computable isomorphism from G2 to G1, which
+// Altbn128 and Bls12 don't have. So the adjudicator's public key has a
+// component in each group, which are checked to have the same discrete log.
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// AdjudicatorPublicKey is the public key a * g1, a * g2 of an adjudicator.
+type AdjudicatorPublicKey struct {
+	G1 Point
+	G2 Point
+}
+
+// VerifiablyEncryptedSignature is a signature encrypted under an adjudicator's key.
+type VerifiablyEncryptedSignature struct {
+	// Sig is the signature plus the encryption mask, omega.
+	Sig Point
+	// Nonce is r * g1, mu.
+	Nonce Point
+}
+
+// AdjudicatorKeyGen generates an adjudicator's secret key and public key.
+func AdjudicatorKeyGen(curve CurveSystem) (*big.Int, *AdjudicatorPublicKey, error) {
+	a, err := encoding.RandomNonzeroScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, LoadAdjudicatorPublicKey(curve, a), nil
+}
+
+// LoadAdjudicatorPublicKey turns an adjudicator's secret key into its public key.
+func LoadAdjudicatorPublicKey(curve CurveSystem, a *big.Int) *AdjudicatorPublicKey {
+	return &AdjudicatorPublicKey{curve.GetG1().Mul(a), curve.GetG2().Mul(a)}
+}
+
+// Verify checks that both components of the key have the same discrete log,
+// and that it isn't zero. A signer should verify the adjudicator's key once,
+// before encrypting signatures under it.
+func (k *AdjudicatorPublicKey) Verify(curve CurveSystem) bool {
+	if k.G1.Equals(curve.GetG1Infinity()) {
+		return false
+	}
+	negG1 := curve.GetG1().Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{k.G1, negG1}, []Point{curve.GetG2(), k.G2})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// VESCreate signs msg with sk, and encrypts the signature under the
+// adjudicator's key.
+func VESCreate(curve CurveSystem, sk *big.Int, adjKey *AdjudicatorPublicKey, msg []byte) (
+	*VerifiablyEncryptedSignature, error) {
+	r, err := encoding.RandomNonzeroScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	if adjKey.G1.Equals(curve.GetG1Infinity()) {
+		return nil, errors.New("adjudicator key is the identity")
+	}
+	sig, _ := Sign(curve, sk, msg).Add(adjKey.G1.Mul(r))
+	return &VerifiablyEncryptedSignature{sig, curve.GetG1().Mul(r)}, nil
+}
+
+// VESVerify checks that ves encrypts a valid signature on msg under pubKey,
+// which the adjudicator can extract.
+func VESVerify(curve CurveSystem, ves *VerifiablyEncryptedSignature, pubKey Point,
+	adjKey *AdjudicatorPublicKey, msg []byte) bool {
+	negSig := ves.Sig.Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{curve.HashToG1(msg), ves.Nonce, negSig},
+		[]Point{pubKey, adjKey.G2, curve.GetG2()})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// Adjudicate extracts the signature from ves with the adjudicator's secret
+// key. It returns false if ves isn't a valid encryption of a signature on msg
+// under pubKey. The result verifies with VerifySingleSignature.
+func Adjudicate(curve CurveSystem, a *big.Int, ves *VerifiablyEncryptedSignature, pubKey Point,
+	msg []byte) (Point, bool) {
+	if !VESVerify(curve, ves, pubKey, LoadAdjudicatorPublicKey(curve, a), msg) {
+		return nil, false
+	}
+	negA := new(big.Int).Neg(a)
+	sig, _ := ves.Sig.Add(ves.Nonce.Mul(negA.Mod(negA, curve.GetG1Order())))
+	return sig, true
+}
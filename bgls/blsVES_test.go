@@ -0,0 +1,52 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifiablyEncryptedSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk, pk, _ := KeyGen(curve)
+		a, adjKey, err := AdjudicatorKeyGen(curve)
+		assert.Nil(t, err)
+		assert.True(t, adjKey.Verify(curve), "Valid adjudicator key failed verification")
+		msg := make([]byte, 32)
+		rand.Read(msg)
+
+		ves, err := VESCreate(curve, sk, adjKey, msg)
+		assert.Nil(t, err)
+		assert.True(t, VESVerify(curve, ves, pk, adjKey, msg), "Valid VES failed verification")
+		assert.False(t, VerifySingleSignature(curve, ves.Sig, pk, msg), "VES verified as a plain signature")
+		assert.False(t, VESVerify(curve, ves, pk, adjKey, append(msg, 0)), "VES verified on the wrong message")
+		_, otherPk, _ := KeyGen(curve)
+		assert.False(t, VESVerify(curve, ves, otherPk, adjKey, msg), "VES verified under the wrong key")
+		_, otherAdjKey, _ := AdjudicatorKeyGen(curve)
+		assert.False(t, VESVerify(curve, ves, pk, otherAdjKey, msg), "VES verified for the wrong adjudicator")
+
+		sig, ok := Adjudicate(curve, a, ves, pk, msg)
+		assert.True(t, ok)
+		assert.True(t, VerifySingleSignature(curve, sig, pk, msg), "Adjudicated signature failed verification")
+		assert.True(t, sig.Equals(Sign(curve, sk, msg)))
+
+		forged := &VerifiablyEncryptedSignature{Sign(curve, sk, append(msg, 0)), ves.Nonce}
+		_, ok = Adjudicate(curve, a, forged, pk, msg)
+		assert.False(t, ok, "Adjudicated an invalid VES")
+		otherA, _, _ := AdjudicatorKeyGen(curve)
+		_, ok = Adjudicate(curve, otherA, ves, pk, msg)
+		assert.False(t, ok, "Adjudicated with the wrong adjudicator key")
+
+		mixedKey := &AdjudicatorPublicKey{adjKey.G1, curve.GetG2().Mul(new(big.Int).Add(a, big.NewInt(1)))}
+		assert.False(t, mixedKey.Verify(curve), "Adjudicator key with different discrete logs verified")
+		infKey := &AdjudicatorPublicKey{curve.GetG1Infinity(), curve.GetG2Infinity()}
+		assert.False(t, infKey.Verify(curve), "Identity adjudicator key verified")
+		_, err = VESCreate(curve, sk, infKey, msg)
+		assert.NotNil(t, err, "Encrypted a signature under the identity")
+	}
+}
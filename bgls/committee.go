@@ -0,0 +1,284 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// A Committee is an ordered list of public keys which repeatedly signs
+// multisignatures, such as the validators of an epoch. Rather than rehashing
+// and reaggregating the keys for every signature, as getAggregatePubKey and
+// KoskVerifyMultiSignature do, the committee computes its HAE exponents,
+// scaled keys and total aggregate key once.
+//
+// The signers of a multisignature are given as a Bitfield over the
+// committee's order. Their aggregate key takes min(k, n-k) point additions for
+// k signers out of n, since when most members sign, the non-signers are
+// subtracted from the total instead.
+//
+// Membership changes update the cached values incrementally. In Kosk mode,
+// adding or removing a member is a single point addition. In HAE mode, every
+// exponent is a hash of the whole committee, so a membership change rehashes
+// the committee's keys and rescales every one of them.
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// Committee is an ordered list of public keys, which caches what is needed to
+// verify multisignatures from any subset of them. It is safe for concurrent use.
+type Committee struct {
+	curve CurveSystem
+	mode  AggregationMode
+
+	mu   sync.RWMutex
+	keys []Point
+	// exps are the hashed aggregation exponents. They are nil in Kosk mode.
+	exps []*big.Int
+	// scaled are the keys scaled by their exponent, and negScaled are their
+	// negations, for subtracting non-signers.
+	scaled    []Point
+	negScaled []Point
+	aggKey    Point
+	index     map[string]int
+}
+
+// NewCommittee creates a committee from the ordered keys. The mode must be
+// KoskMode or HAEMode. In Kosk mode, the keys' authentications must already
+// have been checked.
+func NewCommittee(curve CurveSystem, mode AggregationMode, keys []Point) (*Committee, error) {
+	if mode != KoskMode && mode != HAEMode {
+		return nil, errors.New("committees support Kosk and HAE mode")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("committee needs at least one key")
+	}
+	c := &Committee{curve: curve, mode: mode, keys: make([]Point, len(keys)), index: make(map[string]int)}
+	for i, key := range keys {
+		if _, ok := c.index[string(key.Marshal())]; ok {
+			return nil, errors.New("committee keys must be distinct")
+		}
+		c.keys[i] = key.Copy()
+		c.index[string(key.Marshal())] = i
+	}
+	if mode == HAEMode {
+		c.rescale()
+	} else {
+		c.scaled = append([]Point{}, c.keys...)
+		c.negScaled = make([]Point, len(keys))
+		for i, key := range c.keys {
+			c.negScaled[i] = negate(key)
+		}
+		c.aggKey = AggregatePoints(c.scaled)
+	}
+	return c, nil
+}
+
+// rescale recomputes the HAE exponents, and everything derived from them.
+func (c *Committee) rescale() {
+	c.exps = hashPubKeysToExponents(c.keys)
+	c.scaled = ScalePoints(c.keys, c.exps)
+	c.negScaled = make([]Point, len(c.keys))
+	for i, key := range c.scaled {
+		c.negScaled[i] = negate(key)
+	}
+	c.aggKey = AggregatePoints(c.scaled)
+}
+
+func negate(pt Point) Point {
+	return pt.Mul(new(big.Int).SetInt64(-1))
+}
+
+// Mode returns the committee's defense against the rogue public key attack.
+func (c *Committee) Mode() AggregationMode {
+	return c.mode
+}
+
+// Size returns the number of members.
+func (c *Committee) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.keys)
+}
+
+// Key returns the public key of the member with the given index.
+func (c *Committee) Key(index int) Point {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys[index].Copy()
+}
+
+// Index returns the index of the member with the given key, or -1 if it isn't a member.
+func (c *Committee) Index(key Point) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if i, ok := c.index[string(key.Marshal())]; ok {
+		return i
+	}
+	return -1
+}
+
+// Exponent returns the HAE exponent of the member with the given index, or
+// nil in Kosk mode.
+func (c *Committee) Exponent(index int) *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.exps == nil {
+		return nil
+	}
+	return new(big.Int).Set(c.exps[index])
+}
+
+// AggregateKey returns the aggregate key of the signers, which in HAE mode is
+// the sum of their scaled keys. It returns nil if there are no signers, or if
+// the Bitfield's size doesn't match the committee.
+func (c *Committee) AggregateKey(signers *Bitfield) Point {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aggregateKey(signers)
+}
+
+func (c *Committee) aggregateKey(signers *Bitfield) Point {
+	n := len(c.keys)
+	if signers == nil || signers.Size() != n {
+		return nil
+	}
+	count := signers.Count()
+	if count == 0 {
+		return nil
+	} else if count == n {
+		return c.aggKey.Copy()
+	}
+	if count <= n-count {
+		keys := make([]Point, 0, count)
+		for i := 0; i < n; i++ {
+			if signers.IsSet(i) {
+				keys = append(keys, c.scaled[i])
+			}
+		}
+		return AggregatePoints(keys)
+	}
+	keys := make([]Point, 1, n-count+1)
+	keys[0] = c.aggKey
+	for i := 0; i < n; i++ {
+		if !signers.IsSet(i) {
+			keys = append(keys, c.negScaled[i])
+		}
+	}
+	return AggregatePoints(keys)
+}
+
+// AggregateSignatures aggregates the signatures of the members with the given
+// indices, scaling them by their exponents in HAE mode. It returns the
+// aggregate signature and the signers, or false if an index is out of range
+// or repeated.
+func (c *Committee) AggregateSignatures(indices []int, sigs []Point) (Point, *Bitfield, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(indices) != len(sigs) || len(indices) == 0 {
+		return nil, nil, false
+	}
+	signers := NewBitfield(len(c.keys))
+	for _, i := range indices {
+		if signers.IsSet(i) || !signers.Set(i) {
+			return nil, nil, false
+		}
+	}
+	if c.mode == HAEMode {
+		exps := make([]*big.Int, len(indices))
+		for j, i := range indices {
+			exps[j] = c.exps[i]
+		}
+		sigs = ScalePoints(sigs, exps)
+	}
+	return AggregatePoints(sigs), signers, true
+}
+
+// VerifyMultiSignature verifies a multisignature on msg from the signers.
+// Signatures are created with KoskSign in Kosk mode, and Sign in HAE mode.
+func (c *Committee) VerifyMultiSignature(sig Point, signers *Bitfield, msg []byte) bool {
+	aggKey := c.AggregateKey(signers)
+	if aggKey == nil {
+		return false
+	}
+	if c.mode == KoskMode {
+		return KoskVerifySingleSignature(c.curve, sig, aggKey, msg)
+	}
+	return VerifySingleSignature(c.curve, sig, aggKey, msg)
+}
+
+// Add appends a member to the committee, and returns its index. It returns
+// false if the key is already a member.
+func (c *Committee) Add(key Point) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[string(key.Marshal())]; ok {
+		return -1, false
+	}
+	c.index[string(key.Marshal())] = len(c.keys)
+	c.keys = append(c.keys, key.Copy())
+	if c.mode == HAEMode {
+		c.rescale()
+	} else {
+		c.scaled = append(c.scaled, c.keys[len(c.keys)-1])
+		c.negScaled = append(c.negScaled, negate(key))
+		c.aggKey, _ = c.aggKey.Add(key)
+	}
+	return len(c.keys) - 1, true
+}
+
+// Remove removes a member from the committee. The members after it move down
+// one index, so Bitfields for the old committee are no longer valid. It
+// returns false if the key isn't a member, or is the last member.
+func (c *Committee) Remove(key Point) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[string(key.Marshal())]
+	if !ok || len(c.keys) == 1 {
+		return false
+	}
+	if c.mode == KoskMode {
+		c.aggKey, _ = c.aggKey.Add(c.negScaled[i])
+	}
+	delete(c.index, string(key.Marshal()))
+	c.keys = append(c.keys[:i], c.keys[i+1:]...)
+	c.scaled = append(c.scaled[:i], c.scaled[i+1:]...)
+	c.negScaled = append(c.negScaled[:i], c.negScaled[i+1:]...)
+	for j := i; j < len(c.keys); j++ {
+		c.index[string(c.keys[j].Marshal())] = j
+	}
+	if c.mode == HAEMode {
+		c.rescale()
+	}
+	return true
+}
+
+// Replace replaces the member at the given index with a new key, keeping
+// every other member's index. It returns false if the index is out of range,
+// or the key is already a member.
+func (c *Committee) Replace(index int, key Point) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index < 0 || index >= len(c.keys) {
+		return false
+	}
+	if _, ok := c.index[string(key.Marshal())]; ok {
+		return false
+	}
+	delete(c.index, string(c.keys[index].Marshal()))
+	c.index[string(key.Marshal())] = index
+	if c.mode == KoskMode {
+		c.aggKey, _ = c.aggKey.Add(c.negScaled[index])
+		c.aggKey, _ = c.aggKey.Add(key)
+		c.negScaled[index] = negate(key)
+	}
+	c.keys[index] = key.Copy()
+	if c.mode == HAEMode {
+		c.rescale()
+	} else {
+		c.scaled[index] = c.keys[index]
+	}
+	return true
+}
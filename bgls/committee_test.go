@@ -0,0 +1,107 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommittee(t *testing.T) {
+	N := 9
+	for _, curve := range curves {
+		for _, mode := range []AggregationMode{KoskMode, HAEMode} {
+			sign := Sign
+			if mode == KoskMode {
+				sign = KoskSign
+			}
+			sks := make([]*big.Int, N)
+			keys := make([]Point, N)
+			for i := 0; i < N; i++ {
+				sks[i], keys[i], _ = KeyGen(curve)
+			}
+			committee, err := NewCommittee(curve, mode, keys)
+			assert.Nil(t, err)
+			msg := make([]byte, 32)
+			rand.Read(msg)
+			sigs := make([]Point, N)
+			for i := 0; i < N; i++ {
+				sigs[i] = sign(curve, sks[i], msg)
+			}
+
+			all := make([]int, N)
+			for i := range all {
+				all[i] = i
+			}
+			for _, indices := range [][]int{all, {8, 0, 4}, {0, 1, 2, 3, 5, 6, 7}, {6}} {
+				subset := make([]Point, len(indices))
+				for j, i := range indices {
+					subset[j] = sigs[i]
+				}
+				aggSig, signers, ok := committee.AggregateSignatures(indices, subset)
+				assert.True(t, ok)
+				assert.True(t, committee.VerifyMultiSignature(aggSig, signers, msg),
+					"Multisignature from %v failed verification", indices)
+				signers.Unset(indices[0])
+				assert.False(t, committee.VerifyMultiSignature(aggSig, signers, msg),
+					"Multisignature verified without one of its signers")
+			}
+			if mode == HAEMode {
+				aggSig, _, _ := committee.AggregateSignatures(all, sigs)
+				assert.True(t, aggSig.Equals(AggregateSignaturesWithHAE(sigs, keys)))
+				assert.True(t, VerifyMultiSignatureWithHAE(curve, aggSig, keys, msg))
+			} else {
+				assert.True(t, KoskVerifyMultiSignature(curve, AggregateSignatures(sigs), keys, msg))
+			}
+			assert.Nil(t, committee.AggregateKey(NewBitfield(N)))
+			assert.Nil(t, committee.AggregateKey(NewBitfield(N+1)))
+
+			// Membership changes match a committee built from scratch.
+			sk, key, _ := KeyGen(curve)
+			index, ok := committee.Add(key)
+			assert.True(t, ok)
+			assert.Equal(t, N, index)
+			_, ok = committee.Add(key)
+			assert.False(t, ok, "Duplicate key was added")
+			assert.True(t, committee.Remove(keys[2]))
+			assert.False(t, committee.Remove(keys[2]))
+			_, replacement, _ := KeyGen(curve)
+			assert.True(t, committee.Replace(0, replacement))
+			assert.False(t, committee.Replace(1, replacement), "Replacement key was added twice")
+			expectedKeys := append(append([]Point{replacement, keys[1]}, keys[3:]...), key)
+			fresh, _ := NewCommittee(curve, mode, expectedKeys)
+			full, _ := BitfieldFromIndices(N, []int{0, 1, 2, 3, 4, 5, 6, 7, 8})
+			partial, _ := BitfieldFromIndices(N, []int{1, 4, 8})
+			for _, signers := range []*Bitfield{full, partial} {
+				assert.True(t, fresh.AggregateKey(signers).Equals(committee.AggregateKey(signers)),
+					"Incrementally updated committee differs from a fresh one")
+			}
+			assert.Equal(t, N-1, committee.Index(key))
+			sig := sign(curve, sk, msg)
+			aggSig, signers, _ := committee.AggregateSignatures([]int{N - 1}, []Point{sig})
+			assert.True(t, committee.VerifyMultiSignature(aggSig, signers, msg))
+
+			// Concurrent verification while the membership changes.
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					committee.AggregateKey(partial)
+					committee.Key(1).Equals(keys[1])
+				}()
+			}
+			_, extra, _ := KeyGen(curve)
+			committee.Add(extra)
+			wg.Wait()
+		}
+		_, err := NewCommittee(curve, HAEMode, []Point{curve.GetG2(), curve.GetG2()})
+		assert.NotNil(t, err, "Committee with a repeated key was created")
+	}
+}
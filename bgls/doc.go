@@ -8,6 +8,8 @@
 // into the same signature. The difference is that a multi signature can be
 // verified quite quickly, using 2 pairing operations regardless of the number
 // of signers, whereas an aggregate signature requires n+1 pairing operations.
+// (Fewer if keys or messages repeat, since equal keys and equal messages are
+// grouped before pairing.)
 //
 // There are three different
 // methods to protect against the rogue public key attack. The three methods are
@@ -0,0 +1,139 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"container/list"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Hasher hashes messages onto the curve where signatures lie. It is what the
+// CustHash methods take, so that hashing can be customized or memoized.
+type Hasher interface {
+	Hash(msg []byte) Point
+}
+
+// HashFunc is an adapter to allow the use of an ordinary hash function as a Hasher.
+type HashFunc func([]byte) Point
+
+// Hash returns f(msg)
+func (f HashFunc) Hash(msg []byte) Point {
+	return f(msg)
+}
+
+// DefaultHasher returns the Hasher which the methods without a custom hash use,
+// which is the curve's HashToG1.
+func DefaultHasher(curve CurveSystem) Hasher {
+	return HashFunc(curve.HashToG1)
+}
+
+// HashCache is a bounded cache of messages which have been hashed to the curve.
+// It is safe for concurrent use, and evicts the least recently used entry once
+// it is full. Entries are keyed by the curve, the domain separation tag (DST),
+// and the message. One HashCache can be shared between many Hashers.
+type HashCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	hits     uint64
+	misses   uint64
+}
+
+type hashCacheEntry struct {
+	key string
+	pt  Point
+}
+
+// NewHashCache creates a cache which holds at most capacity hashed points.
+func NewHashCache(capacity int) *HashCache {
+	return &HashCache{capacity: capacity, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (c *HashCache) Stats() (hits uint64, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Len returns the number of hashed points currently in the cache.
+func (c *HashCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Hasher returns a Hasher that hashes DST || msg with the curve's HashToG1,
+// and memoizes the result in the cache. A nil dst makes it a cached version of
+// DefaultHasher.
+func (c *HashCache) Hasher(curve CurveSystem, dst []byte) Hasher {
+	return c.CustHasher(curve.Name(), dst, DefaultHasher(curve))
+}
+
+// CustHasher returns a Hasher that hashes DST || msg with the supplied Hasher,
+// and memoizes the result in the cache. name identifies the curve, and must
+// differ between hashers that hash onto different curves.
+func (c *HashCache) CustHasher(name string, dst []byte, hash Hasher) Hasher {
+	return &cachedHasher{c, name, append([]byte{}, dst...), hash}
+}
+
+type cachedHasher struct {
+	cache *HashCache
+	name  string
+	dst   []byte
+	hash  Hasher
+}
+
+func (h *cachedHasher) Hash(msg []byte) Point {
+	key := h.cacheKey(msg)
+	if pt, ok := h.cache.get(key); ok {
+		return pt
+	}
+	pt := h.hash.Hash(append(append([]byte{}, h.dst...), msg...))
+	h.cache.put(key, pt)
+	return pt.Copy()
+}
+
+// cacheKey is the length prefixed curve name and DST, followed by the message.
+func (h *cachedHasher) cacheKey(msg []byte) string {
+	key := make([]byte, 0, 8+len(h.name)+len(h.dst)+len(msg))
+	key = encoding.AppendLengthPrefixed(key, []byte(h.name))
+	key = encoding.AppendLengthPrefixed(key, h.dst)
+	return string(append(key, msg...))
+}
+
+// get returns a copy of the cached point, so the cache can't be mutated by callers.
+func (c *HashCache) get(key string) (Point, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.hits++
+		c.order.MoveToFront(elem)
+		return elem.Value.(*hashCacheEntry).pt.Copy(), true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *HashCache) put(key string, pt Point) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity <= 0 {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&hashCacheEntry{key, pt})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*hashCacheEntry).key)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashCache(t *testing.T) {
+	cache := NewHashCache(2)
+	for _, curve := range curves {
+		hasher := cache.Hasher(curve, nil)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		assert.True(t, curve.HashToG1(msg).Equals(hasher.Hash(msg)), "Cached hash differs from HashToG1")
+		hitsBefore, _ := cache.Stats()
+		assert.True(t, curve.HashToG1(msg).Equals(hasher.Hash(msg)), "Cached hash differs from HashToG1")
+		hits, _ := cache.Stats()
+		assert.Equal(t, hitsBefore+1, hits, "Repeated hash was not a cache hit")
+
+		// The DST is prepended, and is part of the cache key
+		dstHasher := cache.Hasher(curve, []byte{1})
+		assert.True(t, curve.HashToG1(append([]byte{1}, msg...)).Equals(dstHasher.Hash(msg)),
+			"Cached hash with DST differs from HashToG1")
+		assert.Equal(t, 2, cache.Len())
+
+		// Signing and verifying with the cache
+		sk, vk, _ := KeyGen(curve)
+		sig := KoskSignCustHash(curve, sk, msg, hasher)
+		assert.True(t, KoskVerifySingleSignature(curve, sig, vk, msg))
+		assert.True(t, KoskVerifySingleSignatureCustHash(curve, vk, msg, sig, hasher))
+		assert.True(t, KoskVerifyMultiSignatureCustHash(curve, sig, []Point{vk}, msg, hasher))
+		_, misses := cache.Stats()
+		assert.True(t, KoskVerifyAggregateSignatureCustHash(curve, sig, []Point{vk}, [][]byte{msg}, hasher))
+		_, misses2 := cache.Stats()
+		assert.Equal(t, misses, misses2, "Verification after signing missed the cache")
+		assert.Equal(t, 2, cache.Len(), "Cache grew beyond its capacity")
+	}
+	_, misses := cache.Stats()
+	assert.Equal(t, uint64(3*len(curves)), misses)
+}
+
+func TestHashCacheConcurrency(t *testing.T) {
+	cache := NewHashCache(8)
+	for _, curve := range curves {
+		hasher := cache.Hasher(curve, nil)
+		msgs := make([][]byte, 16)
+		for i := 0; i < len(msgs); i++ {
+			msgs[i] = []byte{byte(i % 4)}
+		}
+		var wg sync.WaitGroup
+		wg.Add(len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			go func(i int) {
+				assert.True(t, curve.HashToG1(msgs[i]).Equals(hasher.Hash(msgs[i])))
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+		assert.True(t, cache.Len() <= 8)
+	}
+}
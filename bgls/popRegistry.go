@@ -0,0 +1,253 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// A PopRegistry remembers which public keys have proven knowledge of their
+// secret key, with the authentications from Authenticate. Kosk multisignatures
+// are only secure against the rogue public key attack if every key has been
+// authenticated, so each Kosk verifier has a KoskVerifyRegistered variant,
+// which rejects signatures from any unregistered key.
+//
+// Authentications are BLS signatures on distinct messages (the keys
+// themselves), so a batch of them is checked with a single randomized
+// aggregate check. If that fails, fault localization finds the invalid
+// authentications, and the rest of the batch is still registered.
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// PopStore persists the registered keys and their authentications, both in
+// their marshalled form. Implementations must be safe for concurrent use.
+type PopStore interface {
+	// Store saves the authentication of a key.
+	Store(key []byte, auth []byte) error
+	// Load returns the authentication of a key, or false if the key isn't stored.
+	Load(key []byte) ([]byte, bool, error)
+	// Delete removes a key.
+	Delete(key []byte) error
+}
+
+// KeyRegistry reports whether keys have proven knowledge of their secret key.
+type KeyRegistry interface {
+	IsRegistered(key Point) bool
+}
+
+// MemoryPopStore is a PopStore which keeps the keys in memory.
+type MemoryPopStore struct {
+	mu    sync.RWMutex
+	auths map[string][]byte
+}
+
+// NewMemoryPopStore creates an empty MemoryPopStore.
+func NewMemoryPopStore() *MemoryPopStore {
+	return &MemoryPopStore{auths: make(map[string][]byte)}
+}
+
+// Store saves the authentication of a key.
+func (s *MemoryPopStore) Store(key []byte, auth []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[string(key)] = append([]byte{}, auth...)
+	return nil
+}
+
+// Load returns the authentication of a key, or false if the key isn't stored.
+func (s *MemoryPopStore) Load(key []byte) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	auth, ok := s.auths[string(key)]
+	return append([]byte{}, auth...), ok, nil
+}
+
+// Delete removes a key.
+func (s *MemoryPopStore) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.auths, string(key))
+	return nil
+}
+
+// PopRegistry verifies and records the authentications of public keys. It is
+// safe for concurrent use.
+type PopRegistry struct {
+	curve CurveSystem
+	store PopStore
+}
+
+// NewPopRegistry creates a registry which persists keys in the store.
+func NewPopRegistry(curve CurveSystem, store PopStore) *PopRegistry {
+	return &PopRegistry{curve, store}
+}
+
+// Register verifies the authentications of the keys at the same positions,
+// and registers every key with a valid authentication. It returns the sorted
+// indices of the invalid authentications. Keys which are already registered
+// aren't verified again. An error is returned if the store fails, or the
+// number of keys and authentications differ.
+func (r *PopRegistry) Register(keys []Point, auths []Point) ([]int, error) {
+	if len(keys) != len(auths) {
+		return nil, errors.New("each key needs one authentication")
+	}
+	var pending []int
+	for i, key := range keys {
+		_, ok, err := r.store.Load(key.Marshal())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	pendingKeys := make([]Point, len(pending))
+	pendingAuths := make([]Point, len(pending))
+	msgs := make([][]byte, len(pending))
+	for j, i := range pending {
+		pendingKeys[j] = keys[i]
+		pendingAuths[j] = auths[i]
+		msgs[j] = keys[i].Marshal()
+	}
+	invalidPending, _ := FindInvalidSignatures(r.curve, pendingAuths, pendingKeys, msgs)
+	invalid := make([]int, len(invalidPending))
+	isInvalid := make(map[int]bool)
+	for k, j := range invalidPending {
+		invalid[k] = pending[j]
+		isInvalid[j] = true
+	}
+	for j, i := range pending {
+		if isInvalid[j] {
+			continue
+		}
+		if err := r.store.Store(msgs[j], auths[i].Marshal()); err != nil {
+			return invalid, err
+		}
+	}
+	return invalid, nil
+}
+
+// IsRegistered returns true if the key has a valid authentication in the
+// registry. It returns false if the store fails.
+func (r *PopRegistry) IsRegistered(key Point) bool {
+	_, ok, err := r.store.Load(key.Marshal())
+	return ok && err == nil
+}
+
+// Authentication returns the registered authentication of the key.
+func (r *PopRegistry) Authentication(key Point) (Point, bool) {
+	auth, ok, err := r.store.Load(key.Marshal())
+	if !ok || err != nil {
+		return nil, false
+	}
+	return r.curve.UnmarshalG1(append([]byte{}, auth...))
+}
+
+// Unregister removes a key from the registry.
+func (r *PopRegistry) Unregister(key Point) error {
+	return r.store.Delete(key.Marshal())
+}
+
+// allRegistered returns true if every key is registered.
+func allRegistered(registry KeyRegistry, keys []Point) bool {
+	for _, key := range keys {
+		if !registry.IsRegistered(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// The KoskVerifyRegistered functions are the Kosk verifiers, but first check
+// that every key is registered, and reject the signature if not.
+
+// KoskVerifyRegisteredSingleSignature is KoskVerifySingleSignature for a registered key.
+func KoskVerifyRegisteredSingleSignature(curve CurveSystem, registry KeyRegistry, sig Point,
+	pubKey Point, msg []byte) bool {
+	return registry.IsRegistered(pubKey) && KoskVerifySingleSignature(curve, sig, pubKey, msg)
+}
+
+// KoskVerifyRegisteredSingleSignatureCustHash is KoskVerifySingleSignatureCustHash
+// for a registered key.
+func KoskVerifyRegisteredSingleSignatureCustHash(curve CurveSystem, registry KeyRegistry, pubKey Point,
+	msg []byte, sig Point, hash Hasher) bool {
+	return registry.IsRegistered(pubKey) && KoskVerifySingleSignatureCustHash(curve, pubKey, msg, sig, hash)
+}
+
+// KoskVerifyRegisteredAggregateSignature is KoskVerifyAggregateSignature for registered keys.
+func KoskVerifyRegisteredAggregateSignature(curve CurveSystem, registry KeyRegistry, aggsig Point,
+	keys []Point, msgs [][]byte) bool {
+	return allRegistered(registry, keys) && KoskVerifyAggregateSignature(curve, aggsig, keys, msgs)
+}
+
+// KoskVerifyRegisteredAggregateSignatureCustHash is
+// KoskVerifyAggregateSignatureCustHash for registered keys.
+func KoskVerifyRegisteredAggregateSignatureCustHash(curve CurveSystem, registry KeyRegistry, aggsig Point,
+	keys []Point, msgs [][]byte, hash Hasher) bool {
+	return allRegistered(registry, keys) && KoskVerifyAggregateSignatureCustHash(curve, aggsig, keys, msgs, hash)
+}
+
+// KoskVerifyRegisteredAggregateSignatureContext is
+// KoskVerifyAggregateSignatureContext for registered keys.
+func KoskVerifyRegisteredAggregateSignatureContext(ctx context.Context, curve CurveSystem,
+	registry KeyRegistry, aggsig Point, keys []Point, msgs [][]byte) (bool, error) {
+	if !allRegistered(registry, keys) {
+		return false, nil
+	}
+	return KoskVerifyAggregateSignatureContext(ctx, curve, aggsig, keys, msgs)
+}
+
+// KoskVerifyRegisteredMultiSignature is KoskVerifyMultiSignature for registered keys.
+func KoskVerifyRegisteredMultiSignature(curve CurveSystem, registry KeyRegistry, aggsig Point,
+	keys []Point, msg []byte) bool {
+	return allRegistered(registry, keys) && KoskVerifyMultiSignature(curve, aggsig, keys, msg)
+}
+
+// KoskVerifyRegisteredMultiSignatureCustHash is KoskVerifyMultiSignatureCustHash
+// for registered keys.
+func KoskVerifyRegisteredMultiSignatureCustHash(curve CurveSystem, registry KeyRegistry, aggsig Point,
+	keys []Point, msg []byte, hash Hasher) bool {
+	return allRegistered(registry, keys) && KoskVerifyMultiSignatureCustHash(curve, aggsig, keys, msg, hash)
+}
+
+// KoskVerifyRegisteredMultiSignatureContext is KoskVerifyMultiSignatureContext
+// for registered keys.
+func KoskVerifyRegisteredMultiSignatureContext(ctx context.Context, curve CurveSystem,
+	registry KeyRegistry, aggsig Point, keys []Point, msg []byte) (bool, error) {
+	if !allRegistered(registry, keys) {
+		return false, nil
+	}
+	return KoskVerifyMultiSignatureContext(ctx, curve, aggsig, keys, msg)
+}
+
+// KoskVerifyRegisteredBatchMultiSignature is KoskVerifyBatchMultiSignature for
+// registered keys.
+func KoskVerifyRegisteredBatchMultiSignature(curve CurveSystem, registry KeyRegistry, aggsigs []Point,
+	pubkeys [][]Point, msgs [][]byte) bool {
+	for _, keys := range pubkeys {
+		if !allRegistered(registry, keys) {
+			return false
+		}
+	}
+	return KoskVerifyBatchMultiSignature(curve, aggsigs, pubkeys, msgs)
+}
+
+// KoskVerifyRegisteredMultiSignatureWithMultiplicity is
+// KoskVerifyMultiSignatureWithMultiplicity for registered keys.
+func KoskVerifyRegisteredMultiSignatureWithMultiplicity(curve CurveSystem, registry KeyRegistry,
+	aggsig Point, keys []Point, multiplicity []int64, msg []byte) bool {
+	return allRegistered(registry, keys) &&
+		KoskVerifyMultiSignatureWithMultiplicity(curve, aggsig, keys, multiplicity, msg)
+}
+
+// VerifyRegistered is Verify, but rejects the multisignature if any of its
+// keys isn't registered.
+func (m MultiSig) VerifyRegistered(curve CurveSystem, registry KeyRegistry) bool {
+	return KoskVerifyRegisteredMultiSignature(curve, registry, m.sig, m.keys, m.msg)
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a PopStore whose writes fail.
+type failingStore struct {
+	*MemoryPopStore
+}
+
+func (s failingStore) Store(key []byte, auth []byte) error {
+	return errors.New("store is read only")
+}
+
+func TestPopRegistry(t *testing.T) {
+	N := 8
+	for _, curve := range curves {
+		sks := make([]*big.Int, N)
+		keys := make([]Point, N)
+		auths := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+			auths[i] = Authenticate(curve, sks[i])
+		}
+		// Two invalid authentications, including a swapped pair.
+		auths[2], auths[5] = auths[5], auths[2]
+		registry := NewPopRegistry(curve, NewMemoryPopStore())
+		invalid, err := registry.Register(keys, auths)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{2, 5}, invalid)
+		for i := 0; i < N; i++ {
+			assert.Equal(t, i != 2 && i != 5, registry.IsRegistered(keys[i]))
+		}
+		auth, ok := registry.Authentication(keys[0])
+		assert.True(t, ok)
+		assert.True(t, CheckAuthentication(curve, keys[0], auth))
+
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sigs[i] = KoskSign(curve, sks[i], msg)
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, KoskVerifyMultiSignature(curve, aggsig, keys, msg))
+		assert.False(t, KoskVerifyRegisteredMultiSignature(curve, registry, aggsig, keys, msg),
+			"Multisignature with unregistered keys was accepted")
+
+		// Registering the corrected authentications only verifies the new keys.
+		auths[2], auths[5] = auths[5], auths[2]
+		invalid, err = registry.Register(keys, auths)
+		assert.Nil(t, err)
+		assert.Empty(t, invalid)
+		assert.True(t, KoskVerifyRegisteredMultiSignature(curve, registry, aggsig, keys, msg))
+		assert.True(t, KoskVerifyRegisteredSingleSignature(curve, registry, sigs[3], keys[3], msg))
+		assert.True(t, KoskVerifyRegisteredBatchMultiSignature(curve, registry,
+			[]Point{aggsig}, [][]Point{keys}, [][]byte{msg}))
+		ok, err = KoskVerifyRegisteredMultiSignatureContext(context.Background(), curve, registry, aggsig, keys, msg)
+		assert.True(t, ok)
+		assert.Nil(t, err)
+
+		assert.Nil(t, registry.Unregister(keys[3]))
+		assert.False(t, KoskVerifyRegisteredSingleSignature(curve, registry, sigs[3], keys[3], msg))
+		assert.False(t, KoskVerifyRegisteredBatchMultiSignature(curve, registry,
+			[]Point{aggsig}, [][]Point{keys}, [][]byte{msg}))
+		ok, err = KoskVerifyRegisteredMultiSignatureContext(context.Background(), curve, registry, aggsig, keys, msg)
+		assert.False(t, ok)
+		assert.Nil(t, err)
+		msgs := make([][]byte, N)
+		aggSigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = []byte{byte(i)}
+			aggSigs[i] = KoskSign(curve, sks[i], msgs[i])
+		}
+		aggsig2 := AggregateSignatures(aggSigs)
+		assert.True(t, KoskVerifyAggregateSignature(curve, aggsig2, keys, msgs))
+		assert.False(t, KoskVerifyRegisteredAggregateSignature(curve, registry, aggsig2, keys, msgs))
+		registered := append(keys[:3:3], keys[4:]...)
+		registeredMsgs := append(msgs[:3:3], msgs[4:]...)
+		aggsig3 := AggregateSignatures(append(aggSigs[:3:3], aggSigs[4:]...))
+		assert.True(t, KoskVerifyRegisteredAggregateSignature(curve, registry, aggsig3,
+			registered, registeredMsgs))
+		assert.False(t, registry.IsRegistered(keys[3]))
+		assert.False(t, KoskVerifyRegisteredMultiSignature(curve, registry, aggsig, keys, msg))
+
+		_, err = NewPopRegistry(curve, failingStore{NewMemoryPopStore()}).Register(keys[:1], auths[:1])
+		assert.NotNil(t, err, "Store failure wasn't reported")
+		_, err = registry.Register(keys, auths[1:])
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// A WeightedCommittee is a Committee where each key has an integer weight,
+// such as its stake. A multisignature from any subset of the committee is
+// accepted if it verifies under the subset's aggregate key, and the subset
+// holds at least a threshold fraction of the total weight.
+//
+// The rogue public key attack is prevented with either Kosk, where every key
+// must have been authenticated before it joins the committee, or with hashed
+// aggregation exponents. In HAE mode, the exponents are derived from the whole
+// committee rather than from the signers, so that they are fixed. Each signer
+// signs with Sign, and the aggregator scales their signature by its exponent.
+// The signers' aggregate key is computed from the Committee's cached
+// aggregate key, by subtracting the non-signers when they are fewer.
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// WeightedCommittee is a Committee with weighted keys, which verifies
+// multisignatures from subsets holding enough of the total weight.
+type WeightedCommittee struct {
+	*Committee
+	mu      sync.RWMutex
+	weights []uint64
+	total   *big.Int
+
+	thresholdNum *big.Int
+	thresholdDen *big.Int
+}
+
+// NewWeightedCommittee creates a committee from the keys and their weights. A
+// multisignature is accepted if its signers hold at least num/den of the total
+// weight. The mode must be KoskMode or HAEMode. In Kosk mode, the keys'
+// authentications must already have been checked.
+func NewWeightedCommittee(curve CurveSystem, mode AggregationMode, keys []Point, weights []uint64,
+	num uint64, den uint64) (*WeightedCommittee, error) {
+	if len(keys) != len(weights) {
+		return nil, errors.New("committee needs one weight per key")
+	}
+	if den == 0 || num == 0 || num > den {
+		return nil, errors.New("threshold must be a fraction in (0, 1]")
+	}
+	committee, err := NewCommittee(curve, mode, keys)
+	if err != nil {
+		return nil, err
+	}
+	c := &WeightedCommittee{Committee: committee, weights: append([]uint64{}, weights...), total: new(big.Int),
+		thresholdNum: new(big.Int).SetUint64(num), thresholdDen: new(big.Int).SetUint64(den)}
+	for _, weight := range weights {
+		c.total.Add(c.total, new(big.Int).SetUint64(weight))
+	}
+	if c.total.Sign() == 0 {
+		return nil, errors.New("committee has no weight")
+	}
+	return c, nil
+}
+
+// TotalWeight returns the sum of the members' weights.
+func (c *WeightedCommittee) TotalWeight() *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return new(big.Int).Set(c.total)
+}
+
+// Weight returns the weight of the member with the given index.
+func (c *WeightedCommittee) Weight(index int) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.weights[index]
+}
+
+// SignedWeight returns the total weight of the signers.
+func (c *WeightedCommittee) SignedWeight(signers *Bitfield) *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.signedWeight(signers)
+}
+
+func (c *WeightedCommittee) signedWeight(signers *Bitfield) *big.Int {
+	weight := new(big.Int)
+	for _, i := range signers.Indices() {
+		if i < len(c.weights) {
+			weight.Add(weight, new(big.Int).SetUint64(c.weights[i]))
+		}
+	}
+	return weight
+}
+
+// MeetsThreshold returns true if the signers hold at least the threshold
+// fraction of the total weight.
+func (c *WeightedCommittee) MeetsThreshold(signers *Bitfield) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.meetsThreshold(c.signedWeight(signers))
+}
+
+func (c *WeightedCommittee) meetsThreshold(weight *big.Int) bool {
+	signed := new(big.Int).Mul(weight, c.thresholdDen)
+	return signed.Cmp(new(big.Int).Mul(c.total, c.thresholdNum)) >= 0
+}
+
+// Verify verifies a multisignature on msg from the signers, and returns their
+// total weight. It only returns true if the signature is valid, and the
+// signers hold at least the threshold fraction of the total weight.
+func (c *WeightedCommittee) Verify(sig Point, signers *Bitfield, msg []byte) (*big.Int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if signers == nil || signers.Size() != len(c.weights) {
+		return new(big.Int), false
+	}
+	weight := c.signedWeight(signers)
+	if !c.meetsThreshold(weight) {
+		return weight, false
+	}
+	return weight, c.VerifyMultiSignature(sig, signers, msg)
+}
+
+// Add appends a member with the given weight, and returns its index. It
+// returns false if the key is already a member.
+func (c *WeightedCommittee) Add(key Point, weight uint64) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index, ok := c.Committee.Add(key)
+	if ok {
+		c.weights = append(c.weights, weight)
+		c.total.Add(c.total, new(big.Int).SetUint64(weight))
+	}
+	return index, ok
+}
+
+// Remove removes a member. The members after it move down one index. It
+// returns false if the key isn't a member, is the last member, or holds all
+// of the remaining weight.
+func (c *WeightedCommittee) Remove(key Point) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := c.Committee.Index(key)
+	if index < 0 || c.total.Cmp(new(big.Int).SetUint64(c.weights[index])) == 0 || !c.Committee.Remove(key) {
+		return false
+	}
+	c.total.Sub(c.total, new(big.Int).SetUint64(c.weights[index]))
+	c.weights = append(c.weights[:index], c.weights[index+1:]...)
+	return true
+}
+
+// SetWeight changes the weight of the member with the given index. It returns
+// false if there is no such member, or if the total weight would become zero,
+// in which case the weights are unchanged.
+func (c *WeightedCommittee) SetWeight(index int, weight uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index < 0 || index >= len(c.weights) {
+		return false
+	}
+	total := new(big.Int).Sub(c.total, new(big.Int).SetUint64(c.weights[index]))
+	total.Add(total, new(big.Int).SetUint64(weight))
+	if total.Sign() == 0 {
+		return false
+	}
+	c.total = total
+	c.weights[index] = weight
+	return true
+}
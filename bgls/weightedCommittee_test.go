@@ -0,0 +1,106 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedCommittee(t *testing.T) {
+	weights := []uint64{10, 40, 5, 25, 20}
+	signFuncs := map[AggregationMode]func(CurveSystem, *big.Int, []byte) Point{
+		KoskMode: KoskSign,
+		HAEMode:  Sign,
+	}
+	for _, curve := range curves {
+		for mode, sign := range signFuncs {
+			sks := make([]*big.Int, len(weights))
+			keys := make([]Point, len(weights))
+			for i := range weights {
+				sks[i], keys[i], _ = KeyGen(curve)
+			}
+			committee, err := NewWeightedCommittee(curve, mode, keys, weights, 2, 3)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(100), committee.TotalWeight().Int64())
+			msg := make([]byte, 32)
+			rand.Read(msg)
+			sigs := make([]Point, len(weights))
+			for i := range weights {
+				sigs[i] = sign(curve, sks[i], msg)
+			}
+
+			tests := []struct {
+				signers []int
+				weight  int64
+				valid   bool
+			}{
+				{[]int{0, 1, 2, 3, 4}, 100, true},
+				{[]int{1, 3, 4}, 85, true},
+				{[]int{3, 1}, 65, false},
+				{[]int{0, 1, 2, 4}, 75, true},
+				{[]int{0, 2}, 15, false},
+			}
+			for _, test := range tests {
+				testSigs := make([]Point, len(test.signers))
+				for j, i := range test.signers {
+					testSigs[j] = sigs[i]
+				}
+				aggSig, signers, ok := committee.AggregateSignatures(test.signers, testSigs)
+				assert.True(t, ok)
+				weight, ok := committee.Verify(aggSig, signers, msg)
+				assert.Equal(t, test.weight, weight.Int64())
+				assert.Equal(t, test.valid, ok, "Unexpected result for signers %v", test.signers)
+
+				// The aggregate key is the same whether it is summed or subtracted.
+				keys := make([]Point, len(test.signers))
+				for j, i := range test.signers {
+					keys[j] = committee.Committee.scaled[i]
+				}
+				assert.True(t, AggregatePoints(keys).Equals(committee.AggregateKey(signers)))
+			}
+
+			aggSig, signers, _ := committee.AggregateSignatures([]int{0, 1, 3}, []Point{sigs[0], sigs[1], sigs[3]})
+			assert.True(t, committee.MeetsThreshold(signers))
+			_, ok := committee.Verify(aggSig, signers, msg)
+			assert.True(t, ok)
+			signers.Set(4)
+			_, ok = committee.Verify(aggSig, signers, msg)
+			assert.False(t, ok, "Multisignature verified with a signer who didn't sign")
+			_, _, ok = committee.AggregateSignatures([]int{1, 1}, []Point{sigs[1], sigs[1]})
+			assert.False(t, ok, "Repeated signer was aggregated")
+
+			// Membership changes update the total weight.
+			_, newKey, _ := KeyGen(curve)
+			index, ok := committee.Add(newKey, 50)
+			assert.True(t, ok)
+			assert.Equal(t, uint64(50), committee.Weight(index))
+			assert.Equal(t, int64(150), committee.TotalWeight().Int64())
+			assert.True(t, committee.Remove(keys[1]))
+			assert.Equal(t, int64(110), committee.TotalWeight().Int64())
+			signers, _ = BitfieldFromIndices(committee.Size(), []int{0, 2, 3})
+			assert.False(t, committee.MeetsThreshold(signers))
+			assert.True(t, committee.SetWeight(3, 100))
+			assert.True(t, committee.MeetsThreshold(signers))
+			assert.False(t, committee.SetWeight(committee.Size(), 1), "Set the weight of a member which doesn't exist")
+			assert.False(t, committee.SetWeight(-1, 1), "Set the weight of a negative index")
+
+			// The total weight can't drop to zero.
+			for i := 1; i < committee.Size(); i++ {
+				assert.True(t, committee.SetWeight(i, 0))
+			}
+			assert.False(t, committee.SetWeight(0, 0), "Total weight was set to zero")
+			assert.False(t, committee.Remove(committee.Committee.Key(0)), "Removed the member holding all the weight")
+			assert.Equal(t, committee.Weight(0), committee.TotalWeight().Uint64())
+		}
+		_, err := NewWeightedCommittee(curve, HAEMode, []Point{curve.GetG2()}, []uint64{1}, 3, 2)
+		assert.NotNil(t, err, "Threshold above 1 was accepted")
+		_, err = NewWeightedCommittee(curve, DistinctMsgMode, []Point{curve.GetG2()}, []uint64{1}, 1, 2)
+		assert.NotNil(t, err, "Distinct message mode was accepted")
+	}
+}
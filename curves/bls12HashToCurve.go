@@ -0,0 +1,424 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// This file implements hash_to_curve from RFC 9380 for BLS12-381, the
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ and BLS12381G2_XMD:SHA-256_SSWU_RO_ suites.
+// These are the hashes used by the IETF BLS signature draft, drand, and the
+// IRTF BBS draft. Everything is computed on affine coordinates with big.Int,
+// including cofactor clearing, since the effective cofactor of G2 doesn't fit
+// in the underlying library's scalars. Only the final point is handed to it.
+
+// fq2 is the element c0 + c1 * u of F_q^2 = F_q[u] / (u^2 + 1). Elements of F_q
+// are those with c1 = 0, and stay so under every operation here, so the same
+// curve arithmetic serves G1 and G2.
+type fq2 struct {
+	c0, c1 *big.Int
+}
+
+func newFq2(c0, c1 *big.Int) fq2 {
+	return fq2{new(big.Int).Mod(c0, bls12Q), new(big.Int).Mod(c1, bls12Q)}
+}
+
+func fq2FromHex(c0, c1 string) fq2 {
+	x0, _ := new(big.Int).SetString(c0, 16)
+	x1, _ := new(big.Int).SetString(c1, 16)
+	return newFq2(x0, x1)
+}
+
+func fqElement(x int64) fq2 {
+	return newFq2(big.NewInt(x), zero)
+}
+
+func (a fq2) add(b fq2) fq2 {
+	return newFq2(new(big.Int).Add(a.c0, b.c0), new(big.Int).Add(a.c1, b.c1))
+}
+
+func (a fq2) sub(b fq2) fq2 {
+	return newFq2(new(big.Int).Sub(a.c0, b.c0), new(big.Int).Sub(a.c1, b.c1))
+}
+
+func (a fq2) neg() fq2 {
+	return newFq2(new(big.Int).Neg(a.c0), new(big.Int).Neg(a.c1))
+}
+
+func (a fq2) mul(b fq2) fq2 {
+	c0 := new(big.Int).Mul(a.c0, b.c0)
+	c0.Sub(c0, new(big.Int).Mul(a.c1, b.c1))
+	c1 := new(big.Int).Mul(a.c0, b.c1)
+	c1.Add(c1, new(big.Int).Mul(a.c1, b.c0))
+	return newFq2(c0, c1)
+}
+
+func (a fq2) square() fq2 {
+	return a.mul(a)
+}
+
+// inverse returns 1/a, or 0 if a is 0, which is inv0 from RFC 9380.
+func (a fq2) inverse() fq2 {
+	norm := new(big.Int).Mul(a.c0, a.c0)
+	norm.Add(norm, new(big.Int).Mul(a.c1, a.c1))
+	norm.Mod(norm, bls12Q)
+	if norm.Sign() == 0 {
+		return fqElement(0)
+	}
+	norm.ModInverse(norm, bls12Q)
+	return newFq2(new(big.Int).Mul(a.c0, norm), new(big.Int).Neg(new(big.Int).Mul(a.c1, norm)))
+}
+
+func (a fq2) isZero() bool {
+	return a.c0.Sign() == 0 && a.c1.Sign() == 0
+}
+
+func (a fq2) equals(b fq2) bool {
+	return a.c0.Cmp(b.c0) == 0 && a.c1.Cmp(b.c1) == 0
+}
+
+// sgn0 is the sign of a from RFC 9380 section 4.1.
+func (a fq2) sgn0() uint {
+	return a.c0.Bit(0) | (boolToBit(a.c0.Sign() == 0) & a.c1.Bit(0))
+}
+
+// lexicographicallyLargest reports whether a > -a, comparing c1 first, which
+// is the sign bit of zcash's point compression.
+func (a fq2) lexicographicallyLargest() bool {
+	if a.c1.Sign() != 0 {
+		return parity(a.c1, bls12Q)
+	}
+	return parity(a.c0, bls12Q)
+}
+
+func boolToBit(b bool) uint {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// fqSqrt returns a square root of a in F_q, if it has one.
+func fqSqrt(a fq2) (fq2, bool) {
+	root := newFq2(calcQuadRes(a.c0, bls12Q), zero)
+	return root, root.square().equals(a)
+}
+
+// fq2Sqrt returns a square root of a in F_q^2, if it has one. It's the method
+// of calcComplexQuadRes, which also handles the a with non-square c0 and c1 = 0.
+func fq2Sqrt(a fq2) (fq2, bool) {
+	var root fq2
+	if a.c1.Sign() == 0 {
+		if isQuadRes(a.c0, bls12Q) {
+			root = newFq2(calcQuadRes(a.c0, bls12Q), zero)
+		} else {
+			root = newFq2(zero, calcQuadRes(new(big.Int).Sub(bls12Q, a.c0), bls12Q))
+		}
+	} else {
+		norm := new(big.Int).Mul(a.c0, a.c0)
+		norm.Add(norm, new(big.Int).Mul(a.c1, a.c1))
+		gamma := calcQuadRes(norm.Mod(norm, bls12Q), bls12Q)
+		halve := new(big.Int).ModInverse(two, bls12Q)
+		delta := new(big.Int).Add(a.c0, gamma)
+		delta.Mul(delta, halve).Mod(delta, bls12Q)
+		if !isQuadRes(delta, bls12Q) {
+			delta.Sub(a.c0, gamma)
+			delta.Mul(delta, halve).Mod(delta, bls12Q)
+		}
+		x0 := calcQuadRes(delta, bls12Q)
+		if x0.Sign() == 0 {
+			return fq2{}, false
+		}
+		x1 := new(big.Int).ModInverse(new(big.Int).Lsh(x0, 1), bls12Q)
+		root = newFq2(x0, x1.Mul(x1, a.c1))
+	}
+	return root, root.square().equals(a)
+}
+
+// bls12Affine is an affine point on y^2 = x^3 + b, over F_q or F_q^2.
+type bls12Affine struct {
+	x, y     fq2
+	infinity bool
+}
+
+func (p bls12Affine) double() bls12Affine {
+	if p.infinity || p.y.isZero() {
+		return bls12Affine{infinity: true}
+	}
+	// lambda = 3x^2 / 2y
+	lambda := p.x.square().mul(fqElement(3)).mul(p.y.add(p.y).inverse())
+	x := lambda.square().sub(p.x).sub(p.x)
+	return bls12Affine{x: x, y: lambda.mul(p.x.sub(x)).sub(p.y)}
+}
+
+func (p bls12Affine) add(o bls12Affine) bls12Affine {
+	if p.infinity {
+		return o
+	} else if o.infinity {
+		return p
+	} else if p.x.equals(o.x) {
+		if p.y.equals(o.y) {
+			return p.double()
+		}
+		return bls12Affine{infinity: true}
+	}
+	lambda := o.y.sub(p.y).mul(o.x.sub(p.x).inverse())
+	x := lambda.square().sub(p.x).sub(o.x)
+	return bls12Affine{x: x, y: lambda.mul(p.x.sub(x)).sub(p.y)}
+}
+
+func (p bls12Affine) mul(k *big.Int) bls12Affine {
+	result := bls12Affine{infinity: true}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.double()
+		if k.Bit(i) == 1 {
+			result = result.add(p)
+		}
+	}
+	return result
+}
+
+// isOnCurve checks y^2 = x^3 + b.
+func (p bls12Affine) isOnCurve(b fq2) bool {
+	return p.infinity || p.y.square().equals(p.x.square().mul(p.x).add(b))
+}
+
+// inSubgroup checks that the point has order r. It should be on the curve.
+func (p bls12Affine) inSubgroup() bool {
+	return p.mul(bls12Order).infinity
+}
+
+// bls12H2CSuite holds the parameters of a hash_to_curve suite for one group.
+type bls12H2CSuite struct {
+	// m is the extension degree of the field.
+	m int
+	// z, a and b are the SSWU parameters of the isogenous curve y^2 = x^3 + ax + b.
+	z, a, b fq2
+	// isoXNum, isoXDen, isoYNum and isoYDen are the isogeny map's coefficients,
+	// from the constant term up.
+	isoXNum, isoXDen, isoYNum, isoYDen []fq2
+	hEff                               *big.Int
+	sqrt                               func(fq2) (fq2, bool)
+}
+
+// mapToCurve is the simplified SWU map of RFC 9380 section 6.6.2 onto the
+// isogenous curve, followed by the isogeny. It returns a point which still
+// needs its cofactor cleared.
+func (s *bls12H2CSuite) mapToCurve(u fq2) bls12Affine {
+	g := func(x fq2) fq2 {
+		return x.square().mul(x).add(s.a.mul(x)).add(s.b)
+	}
+	zu2 := s.z.mul(u.square())
+	tv1 := zu2.square().add(zu2).inverse()
+	var x1 fq2
+	if tv1.isZero() {
+		x1 = s.b.mul(s.z.mul(s.a).inverse())
+	} else {
+		x1 = s.b.neg().mul(s.a.inverse()).mul(fqElement(1).add(tv1))
+	}
+	x := x1
+	y, ok := s.sqrt(g(x1))
+	if !ok {
+		x = zu2.mul(x1)
+		y, _ = s.sqrt(g(x))
+	}
+	if u.sgn0() != y.sgn0() {
+		y = y.neg()
+	}
+	return s.isogeny(x, y)
+}
+
+func evalPoly(coeffs []fq2, x fq2) fq2 {
+	result := coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		result = result.mul(x).add(coeffs[i])
+	}
+	return result
+}
+
+func (s *bls12H2CSuite) isogeny(x, y fq2) bls12Affine {
+	xDen := evalPoly(s.isoXDen, x)
+	yDen := evalPoly(s.isoYDen, x)
+	if xDen.isZero() || yDen.isZero() {
+		return bls12Affine{infinity: true}
+	}
+	return bls12Affine{
+		x: evalPoly(s.isoXNum, x).mul(xDen.inverse()),
+		y: y.mul(evalPoly(s.isoYNum, x)).mul(yDen.inverse()),
+	}
+}
+
+// hashToCurve is hash_to_curve from RFC 9380 section 3, for the suite.
+func (s *bls12H2CSuite) hashToCurve(msg []byte, dst []byte) bls12Affine {
+	u := HashToField(msg, dst, bls12Q, 2*s.m)
+	var u0, u1 fq2
+	if s.m == 1 {
+		u0, u1 = newFq2(u[0], zero), newFq2(u[1], zero)
+	} else {
+		u0, u1 = newFq2(u[0], u[1]), newFq2(u[2], u[3])
+	}
+	return s.mapToCurve(u0).add(s.mapToCurve(u1)).mul(s.hEff)
+}
+
+func fqsFromHex(hexes ...string) []fq2 {
+	elems := make([]fq2, len(hexes))
+	for i, h := range hexes {
+		elems[i] = fq2FromHex(h, "0")
+	}
+	return elems
+}
+
+var bls12H2CG1 = &bls12H2CSuite{
+	m: 1,
+	z: fqElement(11),
+	a: fq2FromHex("144698a3b8e9433d693a02c96d4982b0ea985383ee66a8d8e8981aefd881ac98936f8da0e0f97f5cf428082d584c1d", "0"),
+	b: fq2FromHex("12e2908d11688030018b12e8753eee3b2016c1f0f24f4070a0b9c14fcef35ef55a23215a316ceaa5d1cc48e98e172be0", "0"),
+	isoXNum: fqsFromHex(
+		"11a05f2b1e833340b809101dd99815856b303e88a2d7005ff2627b56cdb4e2c85610c2d5f2e62d6eaeac1662734649b7",
+		"17294ed3e943ab2f0588bab22147a81c7c17e75b2f6a8417f565e33c70d1e86b4838f2a6f318c356e834eef1b3cb83bb",
+		"0d54005db97678ec1d1048c5d10a9a1bce032473295983e56878e501ec68e25c958c3e3d2a09729fe0179f9dac9edcb0",
+		"1778e7166fcc6db74e0609d307e55412d7f5e4656a8dbf25f1b33289f1b330835336e25ce3107193c5b388641d9b6861",
+		"0e99726a3199f4436642b4b3e4118e5499db995a1257fb3f086eeb65982fac18985a286f301e77c451154ce9ac8895d9",
+		"1630c3250d7313ff01d1201bf7a74ab5db3cb17dd952799b9ed3ab9097e68f90a0870d2dcae73d19cd13c1c66f652983",
+		"0d6ed6553fe44d296a3726c38ae652bfb11586264f0f8ce19008e218f9c86b2a8da25128c1052ecaddd7f225a139ed84",
+		"17b81e7701abdbe2e8743884d1117e53356de5ab275b4db1a682c62ef0f2753339b7c8f8c8f475af9ccb5618e3f0c88e",
+		"080d3cf1f9a78fc47b90b33563be990dc43b756ce79f5574a2c596c928c5d1de4fa295f296b74e956d71986a8497e317",
+		"169b1f8e1bcfa7c42e0c37515d138f22dd2ecb803a0c5c99676314baf4bb1b7fa3190b2edc0327797f241067be390c9e",
+		"10321da079ce07e272d8ec09d2565b0dfa7dccdde6787f96d50af36003b14866f69b771f8c285decca67df3f1605fb7b",
+		"06e08c248e260e70bd1e962381edee3d31d79d7e22c837bc23c0bf1bc24c6b68c24b1b80b64d391fa9c8ba2e8ba2d229"),
+	isoXDen: fqsFromHex(
+		"08ca8d548cff19ae18b2e62f4bd3fa6f01d5ef4ba35b48ba9c9588617fc8ac62b558d681be343df8993cf9fa40d21b1c",
+		"12561a5deb559c4348b4711298e536367041e8ca0cf0800c0126c2588c48bf5713daa8846cb026e9e5c8276ec82b3bff",
+		"0b2962fe57a3225e8137e629bff2991f6f89416f5a718cd1fca64e00b11aceacd6a3d0967c94fedcfcc239ba5cb83e19",
+		"03425581a58ae2fec83aafef7c40eb545b08243f16b1655154cca8abc28d6fd04976d5243eecf5c4130de8938dc62cd8",
+		"13a8e162022914a80a6f1d5f43e7a07dffdfc759a12062bb8d6b44e833b306da9bd29ba81f35781d539d395b3532a21e",
+		"0e7355f8e4e667b955390f7f0506c6e9395735e9ce9cad4d0a43bcef24b8982f7400d24bc4228f11c02df9a29f6304a5",
+		"0772caacf16936190f3e0c63e0596721570f5799af53a1894e2e073062aede9cea73b3538f0de06cec2574496ee84a3a",
+		"14a7ac2a9d64a8b230b3f5b074cf01996e7f63c21bca68a81996e1cdf9822c580fa5b9489d11e2d311f7d99bbdcc5a5e",
+		"0a10ecf6ada54f825e920b3dafc7a3cce07f8d1d7161366b74100da67f39883503826692abba43704776ec3a79a1d641",
+		"095fc13ab9e92ad4476d6e3eb3a56680f682b4ee96f7d03776df533978f31c1593174e4b4b7865002d6384d168ecdd0a",
+		"1"),
+	isoYNum: fqsFromHex(
+		"090d97c81ba24ee0259d1f094980dcfa11ad138e48a869522b52af6c956543d3cd0c7aee9b3ba3c2be9845719707bb33",
+		"134996a104ee5811d51036d776fb46831223e96c254f383d0f906343eb67ad34d6c56711962fa8bfe097e75a2e41c696",
+		"00cc786baa966e66f4a384c86a3b49942552e2d658a31ce2c344be4b91400da7d26d521628b00523b8dfe240c72de1f6",
+		"01f86376e8981c217898751ad8746757d42aa7b90eeb791c09e4a3ec03251cf9de405aba9ec61deca6355c77b0e5f4cb",
+		"08cc03fdefe0ff135caf4fe2a21529c4195536fbe3ce50b879833fd221351adc2ee7f8dc099040a841b6daecf2e8fedb",
+		"16603fca40634b6a2211e11db8f0a6a074a7d0d4afadb7bd76505c3d3ad5544e203f6326c95a807299b23ab13633a5f0",
+		"04ab0b9bcfac1bbcb2c977d027796b3ce75bb8ca2be184cb5231413c4d634f3747a87ac2460f415ec961f8855fe9d6f2",
+		"0987c8d5333ab86fde9926bd2ca6c674170a05bfe3bdd81ffd038da6c26c842642f64550fedfe935a15e4ca31870fb29",
+		"09fc4018bd96684be88c9e221e4da1bb8f3abd16679dc26c1e8b6e6a1f20cabe69d65201c78607a360370e577bdba587",
+		"0e1bba7a1186bdb5223abde7ada14a23c42a0ca7915af6fe06985e7ed1e4d43b9b3f7055dd4eba6f2bafaaebca731c30",
+		"19713e47937cd1be0dfd0b8f1d43fb93cd2fcbcb6caf493fd1183e416389e61031bf3a5cce3fbafce813711ad011c132",
+		"18b46a908f36f6deb918c143fed2edcc523559b8aaf0c2462e6bfe7f911f643249d9cdf41b44d606ce07c8a4d0074d8e",
+		"0b182cac101b9399d155096004f53f447aa7b12a3426b08ec02710e807b4633f06c851c1919211f20d4c04f00b971ef8",
+		"0245a394ad1eca9b72fc00ae7be315dc757b3b080d4c158013e6632d3c40659cc6cf90ad1c232a6442d9d3f5db980133",
+		"05c129645e44cf1102a159f748c4a3fc5e673d81d7e86568d9ab0f5d396a7ce46ba1049b6579afb7866b1e715475224b",
+		"15e6be4e990f03ce4ea50b3b42df2eb5cb181d8f84965a3957add4fa95af01b2b665027efec01c7704b456be69c8b604"),
+	isoYDen: fqsFromHex(
+		"16112c4c3a9c98b252181140fad0eae9601a6de578980be6eec3232b5be72e7a07f3688ef60c206d01479253b03663c1",
+		"1962d75c2381201e1a0cbd6c43c348b885c84ff731c4d59ca4a10356f453e01f78a4260763529e3532f6102c2e49a03d",
+		"058df3306640da276faaae7d6e8eb15778c4855551ae7f310c35a5dd279cd2eca6757cd636f96f891e2538b53dbf67f2",
+		"16b7d288798e5395f20d23bf89edb4d1d115c5dbddbcd30e123da489e726af41727364f2c28297ada8d26d98445f5416",
+		"0be0e079545f43e4b00cc912f8228ddcc6d19c9f0f69bbb0542eda0fc9dec916a20b15dc0fd2ededda39142311a5001d",
+		"08d9e5297186db2d9fb266eaac783182b70152c65550d881c5ecd87b6f0f5a6449f38db9dfa9cce202c6477faaf9b7ac",
+		"166007c08a99db2fc3ba8734ace9824b5eecfdfa8d0cf8ef5dd365bc400a0051d5fa9c01a58b1fb93d1a1399126a775c",
+		"16a3ef08be3ea7ea03bcddfabba6ff6ee5a4375efa1f4fd7feb34fd206357132b920f5b00801dee460ee415a15812ed9",
+		"1866c8ed336c61231a1be54fd1d74cc4f9fb0ce4c6af5920abc5750c4bf39b4852cfe2f7bb9248836b233d9d55535d4a",
+		"167a55cda70a6e1cea820597d94a84903216f763e13d87bb5308592e7ea7d4fbc7385ea3d529b35e346ef48bb8913f55",
+		"04d2f259eea405bd48f010a01ad2911d9c6dd039bb61a6290e591b36e636a5c871a5c29f4f83060400f8b49cba8f6aa8",
+		"0accbb67481d033ff5852c1e48c50c477f94ff8aefce42d28c0f9a88cea7913516f968986f7ebbea9684b529e2561092",
+		"0ad6b9514c767fe3c3613144b45f1496543346d98adf02267d5ceef9a00d9b8693000763e3b90ac11e99b138573345cc",
+		"02660400eb2e4f3b628bdd0d53cd76f2bf565b94e72927c1cb748df27942480e420517bd8714cc80d1fadc1326ed06f7",
+		"0e0fa1d816ddc03e6b24255e0d7819c171c40f65e273b853324efcd6356caa205ca2f570f13497804415473a1d634b8f",
+		"1"),
+	hEff: new(big.Int).SetUint64(0xd201000000010001),
+	sqrt: fqSqrt,
+}
+
+var bls12H2CG2 = &bls12H2CSuite{
+	m: 2,
+	z: fq2FromHex("2", "1").neg(),
+	a: fq2FromHex("0", "f0"),
+	b: fq2FromHex("3f4", "3f4"),
+	isoXNum: []fq2{
+		fq2FromHex("5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6",
+			"5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6"),
+		fq2FromHex("0",
+			"11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71a"),
+		fq2FromHex("11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71e",
+			"8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38d"),
+		fq2FromHex("171d6541fa38ccfaed6dea691f5fb614cb14b4e7f4e810aa22d6108f142b85757098e38d0f671c7188e2aaaaaaaa5ed1",
+			"0"),
+	},
+	isoXDen: []fq2{
+		fq2FromHex("0",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa63"),
+		fq2FromHex("c",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa9f"),
+		fq2FromHex("1", "0"),
+	},
+	isoYNum: []fq2{
+		fq2FromHex("1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706",
+			"1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706"),
+		fq2FromHex("0",
+			"5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97be"),
+		fq2FromHex("11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71c",
+			"8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38f"),
+		fq2FromHex("124c9ad43b6cf79bfbf7043de3811ad0761b0f37a1e26286b0e977c69aa274524e79097a56dc4bd9e1b371c71c718b10",
+			"0"),
+	},
+	isoYDen: []fq2{
+		fq2FromHex("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb"),
+		fq2FromHex("0",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa9d3"),
+		fq2FromHex("12",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa99"),
+		fq2FromHex("1", "0"),
+	},
+	hEff: bls12G2HEff,
+	sqrt: fq2Sqrt,
+}
+
+var bls12G2HEff, _ = new(big.Int).SetString("0bc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551", 16)
+
+// bls12G1B and bls12G2B are the b coefficients of the curves of G1 and G2.
+var bls12G1B = fqElement(4)
+var bls12G2B = fq2FromHex("4", "4")
+
+// g1Coords and g2Coords convert affine points to the coordinates taken by
+// MakeG1Point and MakeG2Point.
+func (p bls12Affine) g1Coords() []*big.Int {
+	return []*big.Int{p.x.c0, p.y.c0}
+}
+
+func (p bls12Affine) g2Coords() []*big.Int {
+	return []*big.Int{p.x.c1, p.x.c0, p.y.c1, p.y.c0}
+}
+
+// Bls12HashToG1 hashes a message onto G1 of Bls12 with hash_to_curve from
+// RFC 9380, the BLS12381G1_XMD:SHA-256_SSWU_RO_ suite, under the domain
+// separation tag dst.
+func Bls12HashToG1(msg []byte, dst []byte) Point {
+	p := bls12H2CG1.hashToCurve(msg, dst)
+	if p.infinity {
+		return Bls12.GetG1Infinity()
+	}
+	pt, _ := Bls12.MakeG1Point(p.g1Coords(), false)
+	return pt
+}
+
+// Bls12HashToG2 hashes a message onto G2 of Bls12 with hash_to_curve from
+// RFC 9380, the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite, under the domain
+// separation tag dst.
+func Bls12HashToG2(msg []byte, dst []byte) Point {
+	p := bls12H2CG2.hashToCurve(msg, dst)
+	if p.infinity {
+		return Bls12.GetG2Infinity()
+	}
+	pt, _ := Bls12.MakeG2Point(p.g2Coords(), false)
+	return pt
+}
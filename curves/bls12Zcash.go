@@ -0,0 +1,132 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// Zcash's compressed encoding of Bls12 points, which the IETF BLS signature
+// draft, drand, and the IRTF BBS draft use. The x coordinate is written big
+// endian, with c1 before c0 on G2, and its top three bits are flags: the
+// encoding is compressed, the point is the identity, and y is the
+// lexicographically larger of its two choices.
+
+const (
+	zcashCompressedFlag = 0x80
+	zcashInfinityFlag   = 0x40
+	zcashSortFlag       = 0x20
+	zcashFlags          = zcashCompressedFlag | zcashInfinityFlag | zcashSortFlag
+)
+
+// Bls12ZcashG1Size and Bls12ZcashG2Size are the lengths of the compressed
+// encodings of points on G1 and G2.
+const (
+	Bls12ZcashG1Size = 48
+	Bls12ZcashG2Size = 96
+)
+
+// Bls12MarshalZcashG1 returns the compressed zcash encoding of a point on G1
+// of Bls12.
+func Bls12MarshalZcashG1(pt Point) []byte {
+	if pt.Equals(Bls12.GetG1Infinity()) {
+		return zcashInfinity(Bls12ZcashG1Size)
+	}
+	coords := pt.ToAffineCoords()
+	return zcashCompress(Bls12ZcashG1Size, []*big.Int{coords[0]}, newFq2(coords[1], zero))
+}
+
+// Bls12MarshalZcashG2 returns the compressed zcash encoding of a point on G2
+// of Bls12.
+func Bls12MarshalZcashG2(pt Point) []byte {
+	if pt.Equals(Bls12.GetG2Infinity()) {
+		return zcashInfinity(Bls12ZcashG2Size)
+	}
+	coords := pt.ToAffineCoords()
+	return zcashCompress(Bls12ZcashG2Size, coords[:2], newFq2(coords[3], coords[2]))
+}
+
+// Bls12UnmarshalZcashG1 decodes a compressed zcash encoding of a point on G1
+// of Bls12. It returns false unless the point is on the curve and in G1.
+func Bls12UnmarshalZcashG1(data []byte) (Point, bool) {
+	p, ok := zcashDecompress(data, Bls12ZcashG1Size, bls12G1B, fqSqrt)
+	if !ok {
+		return nil, false
+	} else if p.infinity {
+		return Bls12.GetG1Infinity(), true
+	}
+	return Bls12.MakeG1Point(p.g1Coords(), false)
+}
+
+// Bls12UnmarshalZcashG2 decodes a compressed zcash encoding of a point on G2
+// of Bls12. It returns false unless the point is on the curve and in G2.
+func Bls12UnmarshalZcashG2(data []byte) (Point, bool) {
+	p, ok := zcashDecompress(data, Bls12ZcashG2Size, bls12G2B, fq2Sqrt)
+	if !ok {
+		return nil, false
+	} else if p.infinity {
+		return Bls12.GetG2Infinity(), true
+	}
+	return Bls12.MakeG2Point(p.g2Coords(), false)
+}
+
+func zcashInfinity(size int) []byte {
+	out := make([]byte, size)
+	out[0] = zcashCompressedFlag | zcashInfinityFlag
+	return out
+}
+
+// zcashCompress writes the coefficients of x, most significant first, and
+// sets the flags from y.
+func zcashCompress(size int, x []*big.Int, y fq2) []byte {
+	out := make([]byte, size)
+	n := size / len(x)
+	for i, c := range x {
+		b := c.Bytes()
+		copy(out[(i+1)*n-len(b):(i+1)*n], b)
+	}
+	out[0] |= zcashCompressedFlag
+	if y.lexicographicallyLargest() {
+		out[0] |= zcashSortFlag
+	}
+	return out
+}
+
+// zcashDecompress decodes a compressed point on y^2 = x^3 + b, and checks
+// that it's in the subgroup of order r.
+func zcashDecompress(data []byte, size int, b fq2, sqrt func(fq2) (fq2, bool)) (bls12Affine, bool) {
+	if len(data) != size || data[0]&zcashCompressedFlag == 0 {
+		return bls12Affine{}, false
+	}
+	flags := data[0] & zcashFlags
+	x := append([]byte{data[0] &^ zcashFlags}, data[1:]...)
+	if flags&zcashInfinityFlag != 0 {
+		if flags&zcashSortFlag != 0 || new(big.Int).SetBytes(x).Sign() != 0 {
+			return bls12Affine{}, false
+		}
+		return bls12Affine{infinity: true}, true
+	}
+
+	// The coefficients are c1 then c0 on G2, and just c0 on G1.
+	n := len(x) / Bls12ZcashG1Size
+	coeffs := make([]*big.Int, 2)
+	coeffs[1] = zero
+	for i := 0; i < n; i++ {
+		c := new(big.Int).SetBytes(x[i*Bls12ZcashG1Size : (i+1)*Bls12ZcashG1Size])
+		if c.Cmp(bls12Q) >= 0 {
+			return bls12Affine{}, false
+		}
+		coeffs[n-1-i] = c
+	}
+	p := bls12Affine{x: newFq2(coeffs[0], coeffs[1])}
+	y, ok := sqrt(p.x.square().mul(p.x).add(b))
+	if !ok {
+		return bls12Affine{}, false
+	}
+	if y.lexicographicallyLargest() != (flags&zcashSortFlag != 0) {
+		y = y.neg()
+	}
+	p.y = y
+	return p, p.inSubgroup()
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The test vectors are the encodings of i * g for i from 0 to 99, the first
+// of zkcrypto's bls12_381 serialization vectors.
+func TestBls12ZcashVectors(t *testing.T) {
+	for _, v := range []struct {
+		file      string
+		size      int
+		g         Point
+		marshal   func(Point) []byte
+		unmarshal func([]byte) (Point, bool)
+	}{
+		{"testcases/bls12G1Compressed.dat", Bls12ZcashG1Size, Bls12.GetG1(), Bls12MarshalZcashG1, Bls12UnmarshalZcashG1},
+		{"testcases/bls12G2Compressed.dat", Bls12ZcashG2Size, Bls12.GetG2(), Bls12MarshalZcashG2, Bls12UnmarshalZcashG2},
+	} {
+		data, err := ioutil.ReadFile(v.file)
+		assert.Nil(t, err)
+		pt := v.g.Mul(zero)
+		for i := 0; i < 100; i++ {
+			expected := data[i*v.size : (i+1)*v.size]
+			assert.Equal(t, expected, v.marshal(pt), "Wrong encoding of %d * g", i)
+			decoded, ok := v.unmarshal(expected)
+			assert.True(t, ok, "Failed to decode %d * g", i)
+			assert.True(t, ok && decoded.Equals(pt), "Wrong decoding of %d * g", i)
+			pt, _ = pt.Add(v.g)
+		}
+	}
+}
+
+func TestBls12ZcashRejects(t *testing.T) {
+	g1 := Bls12MarshalZcashG1(Bls12.GetG1())
+	assert.Equal(t, "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+		hex.EncodeToString(g1))
+	for _, data := range [][]byte{
+		nil,
+		g1[:47],
+		append([]byte{g1[0] &^ zcashCompressedFlag}, g1[1:]...),
+		append([]byte{g1[0] | zcashInfinityFlag}, g1[1:]...),
+		append([]byte{0xe0}, make([]byte, 47)...),
+		// x = q
+		func() []byte {
+			q := make([]byte, 48)
+			bls12Q.FillBytes(q)
+			q[0] |= zcashCompressedFlag
+			return q
+		}(),
+	} {
+		_, ok := Bls12UnmarshalZcashG1(data)
+		assert.False(t, ok, "Decoded invalid encoding %x", data)
+	}
+
+	// A point on the curve outside G1, x = 0 is on y^2 = x^3 + 4 but has order 3.
+	notInG1 := append([]byte{zcashCompressedFlag}, make([]byte, 47)...)
+	_, ok := Bls12UnmarshalZcashG1(notInG1)
+	assert.False(t, ok, "Decoded a point outside G1")
+
+	g2 := Bls12MarshalZcashG2(Bls12.GetG2())
+	negG2 := append([]byte{g2[0] ^ zcashSortFlag}, g2[1:]...)
+	pt, ok := Bls12UnmarshalZcashG2(negG2)
+	assert.True(t, ok)
+	sum, _ := pt.Add(Bls12.GetG2())
+	assert.True(t, sum.Equals(Bls12.GetG2Infinity()), "Sort flag doesn't negate the point")
+	assert.False(t, bytes.Equal(g2, negG2))
+}
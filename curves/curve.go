@@ -4,6 +4,7 @@
 package curves
 
 import (
+	"context"
 	"math/big"
 )
 
@@ -69,155 +70,112 @@ type PointT interface {
 	// ToAffineCoords() (*big.Int, *big.Int)
 }
 
-// AggregatePoints takes the sum of points.
+// AggregatePoints takes the sum of points. It returns nil if there are no points.
 func AggregatePoints(points []Point) Point {
-	if len(points) == 2 { // No parallelization needed
-		aggPoint, _ := points[0].Add(points[1])
-		return aggPoint
-	}
-	// Aggregate all the points together using concurrency
-	c := make(chan Point)
-
-	// Initialize aggPoint to an array with elements being the sum of two
-	// adjacent Points.
-	counter := 0
-	for i := 0; i < len(points); i += 2 {
-		go concurrentAggregatePoints(i, points, c)
-		counter++
-	}
-	aggPoint := make([]Point, counter)
-	for i := 0; i < counter; i++ {
-		aggPoint[i] = <-c
-	}
+	aggPoint, _ := AggregatePointsContext(context.Background(), points)
+	return aggPoint
+}
 
-	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPoint) == 1 {
-			break
+// AggregatePointsContext takes the sum of points, using the Executor. The points
+// are split into one contiguous chunk per worker, each chunk is summed, and then
+// the chunk sums are added together. It returns early with ctx.Err() if ctx is cancelled.
+func AggregatePointsContext(ctx context.Context, points []Point) (Point, error) {
+	if len(points) == 0 {
+		return nil, ctx.Err()
+	}
+	if len(points) <= 2 { // No parallelization needed
+		return sumPointRange(points, 0, len(points)), ctx.Err()
+	}
+	exec := GetExecutor()
+	chunks := exec.Workers()
+	if chunks > len(points)/2 {
+		chunks = len(points) / 2
+	}
+	chunkSize := (len(points) + chunks - 1) / chunks
+	chunks = (len(points) + chunkSize - 1) / chunkSize
+	sums := make([]Point, chunks)
+	err := exec.Run(ctx, chunks, func(i int) {
+		end := (i + 1) * chunkSize
+		if end > len(points) {
+			end = len(points)
 		}
-		for i := 0; i < len(aggPoint); i += 2 {
-			go concurrentAggregatePoints(i, aggPoint, c)
-			counter++
-		}
-		nxtAggPoint := make([]Point, counter)
-		for i := 0; i < counter; i++ {
-			nxtAggPoint[i] = <-c
-		}
-		aggPoint = nxtAggPoint
+		sums[i] = sumPointRange(points, i*chunkSize, end)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return aggPoint[0]
+	return sumPointRange(sums, 0, len(sums)), nil
 }
 
-// concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
-func concurrentAggregatePoints(start int, points []Point, c chan Point) {
-	if start+1 >= len(points) {
-		c <- points[start]
-		return
+// sumPointRange adds up points[start:end] on the calling goroutine.
+func sumPointRange(points []Point, start int, end int) Point {
+	sum := points[start]
+	for i := start + 1; i < end; i++ {
+		sum, _ = sum.Add(points[i])
 	}
-	summed, _ := points[start].Add(points[start+1])
-	c <- summed
+	return sum
 }
 
 // concurrentPairingProduct computes a set of pairings in parallel,
-// and then takes their product again using concurrency.
+// and then takes their product.
 func concurrentPairingProduct(curve CurveSystem, points1 []Point, points2 []Point) (PointT, bool) {
-	if len(points1) != len(points2) {
-		return nil, false
+	product, ok, _ := PairingProductContext(context.Background(), curve, points1, points2)
+	return product, ok
+}
+
+// PairingProductContext computes the product of the pairings of points1[i] and
+// points2[i]. The pairings are computed in parallel using the Executor.
+// It returns early with ctx.Err() if ctx is cancelled.
+func PairingProductContext(ctx context.Context, curve CurveSystem, points1 []Point, points2 []Point) (PointT, bool, error) {
+	if len(points1) != len(points2) || len(points1) == 0 {
+		return nil, false, ctx.Err()
 	}
-	// Compute all the pairings in parallel
-	c := make(chan PointT)
 	pairedPoints := make([]PointT, len(points1))
-	for i := 0; i < len(pairedPoints); i++ {
-		go concurrentPair(curve, points1[i], points2[i], c)
+	err := GetExecutor().Run(ctx, len(points1), func(i int) {
+		pairedPoints[i], _ = curve.Pair(points1[i], points2[i])
+	})
+	if err != nil {
+		return nil, false, err
 	}
-	for i := 0; i < len(pairedPoints); i++ {
-		pairedPoints[i] = <-c
-		if pairedPoints[i] == nil {
-			return nil, false
-		}
-	}
-	counter := 0
-	// Set aggPairedPoints to an array with elements being the sum of two
-	// adjacent Points.
-	for i := 0; i < len(pairedPoints); i += 2 {
-		go concurrentAggregatePointTs(i, pairedPoints, c)
-		counter++
-	}
-	aggPairedPoints := make([]PointT, counter)
-	for i := 0; i < counter; i++ {
-		aggPairedPoints[i] = <-c
+	product := pairedPoints[0]
+	if product == nil {
+		return nil, false, nil
 	}
-
-	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPairedPoints) == 1 {
-			break
-		}
-		for i := 0; i < len(aggPairedPoints); i += 2 {
-			go concurrentAggregatePointTs(i, aggPairedPoints, c)
-			counter++
-		}
-		nxtPairedPoints := make([]PointT, counter)
-		for i := 0; i < counter; i++ {
-			nxtPairedPoints[i] = <-c
+	for i := 1; i < len(pairedPoints); i++ {
+		if pairedPoints[i] == nil {
+			return nil, false, nil
 		}
-		aggPairedPoints = nxtPairedPoints
+		product, _ = product.Add(pairedPoints[i])
 	}
-	return aggPairedPoints[0], true
-}
-
-// concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
-func concurrentAggregatePointTs(start int, points []PointT, c chan PointT) {
-	if start+1 >= len(points) {
-		c <- points[start]
-		return
-	}
-	summed, _ := points[start].Add(points[start+1])
-	c <- summed
-}
-
-type indexedPoint struct {
-	index int
-	pt    Point
+	return product, true, nil
 }
 
 // ScalePoints takes a set of points, and a set of multiples, and returns a
 // new set of points multiplied by the corresponding factor.
 func ScalePoints(pts []Point, factors []*big.Int) (newKeys []Point) {
-	if factors == nil {
-		return pts
-	} else if len(pts) != len(factors) {
-		return nil
-	}
-	newKeys = make([]Point, len(pts))
-	c := make(chan *indexedPoint)
-	for i := 0; i < len(pts); i++ {
-		go concurrentScale(pts[i], factors[i], i, c)
-	}
-	for i := 0; i < len(pts); i++ {
-		pt := <-c
-		newKeys[pt.index] = pt.pt
-	}
+	newKeys, _ = ScalePointsContext(context.Background(), pts, factors)
 	return newKeys
 }
 
-func concurrentScale(key Point, factor *big.Int, index int, c chan *indexedPoint) {
-	if factor == nil {
-		c <- &indexedPoint{index, key.Copy()}
-	} else {
-		c <- &indexedPoint{index, key.Mul(factor)}
-	}
-}
-
-// concurrentPair pairs pt with key, and sends the result down the channel.
-func concurrentPair(curve CurveSystem, pt1 Point, pt2 Point, c chan PointT) {
-	if targetPoint, ok := curve.Pair(pt1, pt2); ok {
-		c <- targetPoint
-		return
+// ScalePointsContext scales each point by the corresponding factor in parallel,
+// using the Executor. A nil factor leaves its point unscaled. It returns early
+// with ctx.Err() if ctx is cancelled.
+func ScalePointsContext(ctx context.Context, pts []Point, factors []*big.Int) ([]Point, error) {
+	if factors == nil {
+		return pts, ctx.Err()
+	} else if len(pts) != len(factors) {
+		return nil, ctx.Err()
+	}
+	newKeys := make([]Point, len(pts))
+	err := GetExecutor().Run(ctx, len(pts), func(i int) {
+		if factors[i] == nil {
+			newKeys[i] = pts[i].Copy()
+		} else {
+			newKeys[i] = pts[i].Mul(factors[i])
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
-	c <- nil
+	return newKeys, nil
 }
@@ -0,0 +1,123 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Executor runs the independent tasks which the concurrent curve operations
+// (aggregation, scaling, pairing products, hashing) are split into.
+type Executor interface {
+	// Run calls task(i) for every i in [0, n), and returns once they have all
+	// completed. If ctx is cancelled, the tasks which haven't started yet are
+	// skipped, and ctx.Err() is returned. If every task ran, Run returns nil,
+	// even if ctx was cancelled meanwhile.
+	Run(ctx context.Context, n int, task func(i int)) error
+	// Workers is the number of tasks which are run at the same time.
+	Workers() int
+}
+
+// SerialExecutor runs every task on the calling goroutine, in order.
+// This is intended for deterministic tests and single threaded environments.
+var SerialExecutor Executor = serialExecutor{}
+
+type serialExecutor struct{}
+
+func (serialExecutor) Run(ctx context.Context, n int, task func(i int)) error {
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		task(i)
+	}
+	return nil
+}
+
+func (serialExecutor) Workers() int {
+	return 1
+}
+
+type poolExecutor struct {
+	workers int
+	// helpers holds a token for each helper goroutine running, across all
+	// calls to Run.
+	helpers chan struct{}
+}
+
+// NewPoolExecutor creates an Executor which runs tasks on a pool of workers.
+// If workers isn't positive, GOMAXPROCS workers are used. The calling
+// goroutine of Run is one worker, and the other workers - 1 are helper
+// goroutines shared by every call, so nested calls, such as an aggregation
+// inside a task, don't grow the pool. A call only starts helpers while the
+// pool has some free, and otherwise runs its tasks itself, so nested calls
+// can't deadlock waiting for the pool.
+func NewPoolExecutor(workers int) Executor {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &poolExecutor{workers, make(chan struct{}, workers-1)}
+}
+
+func (e *poolExecutor) Workers() int {
+	return e.workers
+}
+
+func (e *poolExecutor) Run(ctx context.Context, n int, task func(i int)) error {
+	// Each worker takes the next unstarted task, until none remain.
+	next, completed := int64(-1), int64(0)
+	work := func() {
+		for ctx.Err() == nil {
+			i := int(atomic.AddInt64(&next, 1))
+			if i >= n {
+				return
+			}
+			task(i)
+			atomic.AddInt64(&completed, 1)
+		}
+	}
+	var wg sync.WaitGroup
+start:
+	for h := 0; h < n-1; h++ {
+		select {
+		case e.helpers <- struct{}{}:
+		default:
+			break start
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-e.helpers
+				wg.Done()
+			}()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+	if atomic.LoadInt64(&completed) == int64(n) {
+		return nil
+	}
+	return ctx.Err()
+}
+
+var executor atomic.Value
+var defaultExecutor = NewPoolExecutor(0)
+
+// SetExecutor sets the Executor used by all of the concurrent curve operations.
+// By default, a pool of GOMAXPROCS workers, as of program start, is used.
+func SetExecutor(e Executor) {
+	executor.Store(&e)
+}
+
+// GetExecutor returns the Executor used by the concurrent curve operations.
+func GetExecutor() Executor {
+	if e, ok := executor.Load().(*Executor); ok {
+		return *e
+	}
+	return defaultExecutor
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorsAgree(t *testing.T) {
+	defer SetExecutor(GetExecutor())
+	executors := []Executor{SerialExecutor, NewPoolExecutor(0), NewPoolExecutor(3)}
+	for _, curve := range curves {
+		N := 37
+		pts := make([]Point, N)
+		g2s := make([]Point, N)
+		scalars := make([]*big.Int, N)
+		for i := 0; i < N; i++ {
+			scalars[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+			pts[i] = curve.GetG1().Mul(scalars[i])
+			g2s[i] = curve.GetG2()
+		}
+		var expectedSum Point
+		var expectedProd PointT
+		for _, e := range executors {
+			SetExecutor(e)
+			sum := AggregatePoints(pts)
+			scaled := ScalePoints(pts, scalars)
+			prod, ok := curve.PairingProduct(pts[:5], g2s[:5])
+			assert.True(t, ok)
+			if expectedSum == nil {
+				expectedSum, expectedProd = sum, prod
+			}
+			assert.True(t, expectedSum.Equals(sum), "Executors disagree on aggregation")
+			assert.True(t, expectedProd.Equals(prod), "Executors disagree on pairing products")
+			assert.True(t, pts[3].Mul(scalars[3]).Equals(scaled[3]), "Scaling was incorrect")
+		}
+	}
+}
+
+func TestExecutorCancellation(t *testing.T) {
+	for _, e := range []Executor{SerialExecutor, NewPoolExecutor(4)} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var started int64
+		err := e.Run(ctx, 1000, func(i int) {
+			if atomic.AddInt64(&started, 1) == 10 {
+				cancel()
+			}
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.True(t, atomic.LoadInt64(&started) < 1000, "Tasks kept on running after cancellation")
+	}
+	// A cancellation during the last task doesn't discard the complete results.
+	for _, e := range []Executor{SerialExecutor, NewPoolExecutor(4)} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var completed int64
+		err := e.Run(ctx, 100, func(i int) {
+			if i == 99 {
+				cancel()
+			}
+			atomic.AddInt64(&completed, 1)
+		})
+		assert.Nil(t, err, "Cancellation after every task started was reported")
+		assert.Equal(t, int64(100), completed)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, curve := range curves {
+		_, err := AggregatePointsContext(ctx, []Point{curve.GetG1(), curve.GetG1(), curve.GetG1()})
+		assert.Equal(t, context.Canceled, err)
+		_, ok, err := PairingProductContext(ctx, curve, []Point{curve.GetG1()}, []Point{curve.GetG2()})
+		assert.False(t, ok)
+		assert.Equal(t, context.Canceled, err)
+	}
+}
+
+func TestNestedExecutorBound(t *testing.T) {
+	e := NewPoolExecutor(3)
+	var running, maxRunning int64
+	err := e.Run(context.Background(), 8, func(i int) {
+		e.Run(context.Background(), 8, func(j int) {
+			n := atomic.AddInt64(&running, 1)
+			for {
+				prev := atomic.LoadInt64(&maxRunning)
+				if n <= prev || atomic.CompareAndSwapInt64(&maxRunning, prev, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+		})
+	})
+	assert.Nil(t, err)
+	assert.True(t, maxRunning <= 3, "Nested calls ran more tasks at once than the pool has workers")
+}
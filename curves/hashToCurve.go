@@ -0,0 +1,87 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// ExpandMessageXMD is expand_message_xmd from RFC 9380 section 5.3.1, with
+// SHA-256. It returns n uniform bytes, where n is at most 255 * 32.
+func ExpandMessageXMD(msg []byte, dst []byte, n int) []byte {
+	if len(dst) > 255 {
+		h := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), dst...))
+		dst = h[:]
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, h.BlockSize()))
+	h.Write(msg)
+	h.Write([]byte{byte(n >> 8), byte(n), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	var out, b []byte
+	for i := 1; len(out) < n; i++ {
+		h.Reset()
+		if i == 1 {
+			h.Write(b0)
+		} else {
+			xored := make([]byte, len(b0))
+			for j := range b0 {
+				xored[j] = b0[j] ^ b[j]
+			}
+			h.Write(xored)
+		}
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		b = h.Sum(nil)
+		out = append(out, b...)
+	}
+	return out[:n]
+}
+
+// HashToField is hash_to_field from RFC 9380 section 5.2, for the prime field
+// modulo q. It returns count elements, each reduced from
+// ceil((ceil(log2(q)) + 128) / 8) bytes of ExpandMessageXMD output.
+func HashToField(msg []byte, dst []byte, q *big.Int, count int) []*big.Int {
+	l := (q.BitLen() + 128 + 7) / 8
+	uniform := ExpandMessageXMD(msg, dst, count*l)
+	elems := make([]*big.Int, count)
+	for i := range elems {
+		elems[i] = new(big.Int).SetBytes(uniform[i*l : (i+1)*l])
+		elems[i].Mod(elems[i], q)
+	}
+	return elems
+}
+
+// HashToG1WithDST hashes a message onto G1 under the domain separation tag
+// dst, in the shape of the random oracle hash_to_curve of RFC 9380: two field
+// elements from HashToField are each mapped with the Shallue - van de
+// Woestijne encoding, and the sum of the points has its cofactor cleared.
+// Its outputs are independent of HashToG1's, so a protocol can hash onto G1
+// with it under its own tag, without any message which plain signing hashes to
+// the same point.
+func HashToG1WithDST(curve CurveSystem, msg []byte, dst []byte) Point {
+	u := HashToField(msg, dst, curve.GetG1Q(), 2)
+	pt, _ := swEncode(curve, u[0]).Add(swEncode(curve, u[1]))
+	return pt
+}
+
+// swEncode maps a field element to G1 with the Shallue - van de Woestijne
+// encoding. The encoding is undefined on t = 0 and on the roots of
+// 1 + b + t^2, which are sent to the identity.
+func swEncode(curve CurveSystem, t *big.Int) Point {
+	q := curve.GetG1Q()
+	denominator := new(big.Int).Mul(t, t)
+	denominator.Add(denominator, one)
+	denominator.Add(denominator, curve.getG1B())
+	if t.Sign() == 0 || denominator.Mod(denominator, q).Sign() == 0 {
+		return curve.GetG1Infinity()
+	}
+	pt, _ := fouqueTibouchiG1(curve, t, false)
+	return pt
+}
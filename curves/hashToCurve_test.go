@@ -0,0 +1,50 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors from RFC 9380 appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg      string
+		n        int
+		expected string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"abcdef0123456789", 0x20, "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+		{"", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbe" +
+			"e0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18" +
+			"eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dc" +
+			"c541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+	}
+	for _, v := range vectors {
+		assert.Equal(t, v.expected, hex.EncodeToString(ExpandMessageXMD([]byte(v.msg), dst, v.n)), v.msg)
+	}
+}
+
+func TestHashToG1WithDST(t *testing.T) {
+	dst := []byte("BGLS-TEST-DST")
+	for _, curve := range curves {
+		for _, msg := range []string{"", "abc", "a longer message, to hash onto the curve"} {
+			pt := HashToG1WithDST(curve, []byte(msg), dst)
+			_, ok := curve.MakeG1Point(pt.ToAffineCoords(), true)
+			assert.True(t, ok, "Hash isn't on the curve")
+			assert.False(t, pt.Equals(curve.GetG1Infinity()))
+			assert.True(t, pt.Mul(curve.GetG1Order()).Equals(curve.GetG1Infinity()), "Hash isn't in G1")
+			assert.True(t, HashToG1WithDST(curve, []byte(msg), dst).Equals(pt), "Hashing isn't deterministic")
+			assert.False(t, HashToG1WithDST(curve, []byte(msg), []byte("BGLS-OTHER-DST")).Equals(pt),
+				"Hash doesn't depend on the tag")
+			assert.False(t, curve.HashToG1(append(append([]byte{}, dst...), msg...)).Equals(pt),
+				"Hash matches HashToG1 of the tagged message")
+		}
+	}
+}
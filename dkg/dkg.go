@@ -0,0 +1,370 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package dkg implements distributed key generation for threshold BLS
+// signatures, so that no single party ever knows the group's secret key.
+//
+// This is the Joint-Feldman protocol of Pedersen, with the complaint and
+// justification rounds of Gennaro, Jarecki, Krawczyk and Rabin. Every
+// participant acts as a dealer of a random secret, using Feldman verifiable
+// secret sharing. Participants complain about dealers which sent them invalid
+// shares, and the accused dealers must publicly reveal the complaining
+// participant's share. Dealers which fail to deal, or to answer a complaint
+// with a valid share, are disqualified. The group secret key is the sum of the
+// qualified dealers' secrets, and each participant's share of it is the sum of
+// the shares it received from them.
+//
+// The resulting shares and verification vector are used exactly like those
+// created by bgls.ShareSecretKey. Partial signatures are combined with
+// bgls.ThresholdCombineSignatures, and verified with bgls.VerifySingleSignature
+// under the group public key.
+//
+// Note that a malicious participant can bias the distribution of the group
+// public key in this protocol, by choosing whether or not to be disqualified
+// after seeing the other dealers' commitments. This doesn't affect the security
+// of BLS threshold signatures.
+//
+// The same rounds are used to reshare an existing group key to a new committee,
+// or to refresh the shares of the current committee, as described in reshare.go.
+package dkg
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+type ceremonyMode byte
+
+const (
+	keygenMode ceremonyMode = iota
+	reshareMode
+	refreshMode
+)
+
+// Participant is one party's state in a key generation, resharing or refresh
+// ceremony. A party can be a dealer, a receiver of shares, or both.
+type Participant struct {
+	curve CurveSystem
+	// ID identifies the participant on the transport. In key generation and
+	// refresh ceremonies, it is also the index of the participant's share.
+	ID        int
+	mode      ceremonyMode
+	dealers   []int
+	receivers []int
+	t         int
+	// oldVvec is the verification vector of the shares being reshared or refreshed.
+	oldVvec  []Point
+	oldShare *big.Int
+
+	// shares is the participant's own dealing, indexed by receiver.
+	shares      []bgls.SecretShare
+	commitments map[int][]Point
+	received    map[int]*big.Int
+	complaints  map[int][]int
+	// disqualified dealers are excluded from the result.
+	disqualified map[int]bool
+}
+
+// Result is a participant's output from a successful ceremony.
+type Result struct {
+	// Share is the participant's share of the group secret key. It is left
+	// empty for participants which only dealt in a resharing.
+	Share bgls.SecretShare
+	// VerificationVector is the Feldman commitment to the group's polynomial.
+	// Its first element is the group public key.
+	VerificationVector []Point
+	// GroupKey is the group public key.
+	GroupKey Point
+	// Qualified are the sorted IDs of the dealers whose dealings make up the result.
+	Qualified []int
+}
+
+// NewParticipant creates a participant with the given ID, in a key generation
+// ceremony with n participants, where any t of them can sign on behalf of the
+// group. It picks the participant's random secret, and shares it.
+func NewParticipant(curve CurveSystem, id int, t int, n int) (*Participant, error) {
+	if id < 1 || id > n {
+		return nil, errors.New("participant ID must be between 1 and n")
+	}
+	sk, _, err := bgls.KeyGen(curve)
+	if err != nil {
+		return nil, err
+	}
+	committee := makeCommittee(n)
+	return newParticipant(curve, id, keygenMode, committee, committee, t, sk)
+}
+
+// newParticipant creates a participant, and deals secret to the receivers if
+// secret isn't nil.
+func newParticipant(curve CurveSystem, id int, mode ceremonyMode, dealers []int, receivers []int,
+	t int, secret *big.Int) (*Participant, error) {
+	if t < 1 || t > len(receivers) {
+		return nil, errors.New("threshold must be between 1 and the number of receivers")
+	}
+	p := &Participant{curve: curve, ID: id, mode: mode, dealers: dealers, receivers: receivers, t: t,
+		commitments: make(map[int][]Point), received: make(map[int]*big.Int),
+		complaints: make(map[int][]int), disqualified: make(map[int]bool)}
+	if secret == nil {
+		return p, nil
+	}
+	shares, vvec, err := bgls.ShareSecretKey(curve, secret, t, len(receivers))
+	if err != nil {
+		return nil, err
+	}
+	p.shares = shares
+	p.commitments[id] = vvec
+	if index := p.receiverIndex(id); index > 0 {
+		p.received[id] = shares[index-1].Value
+	}
+	return p, nil
+}
+
+// Deal returns the participant's messages for the deal phase. These are the
+// broadcast commitment to its polynomial, and a private share for each other
+// receiver. It returns nil if the participant isn't a dealer.
+func (p *Participant) Deal() []*Message {
+	if p.shares == nil {
+		return nil
+	}
+	msgs := []*Message{{Type: CommitmentMsg, From: p.ID, To: Broadcast, Commitments: p.commitments[p.ID]}}
+	for i, receiver := range p.receivers {
+		if receiver != p.ID {
+			msgs = append(msgs, &Message{Type: ShareMsg, From: p.ID, To: receiver, Share: p.shares[i].Value})
+		}
+	}
+	return msgs
+}
+
+// ProcessDeals verifies the messages received in the deal phase, and returns
+// the participant's complaints. Dealers which didn't broadcast a valid
+// commitment are disqualified immediately, since every participant sees this.
+func (p *Participant) ProcessDeals(msgs []*Message) []*Message {
+	commitments := make(map[int][][]Point)
+	shares := make(map[int][]*big.Int)
+	for _, msg := range msgs {
+		if msg.From == p.ID || !containsInt(p.dealers, msg.From) {
+			continue
+		}
+		switch {
+		case msg.Type == CommitmentMsg && msg.To == Broadcast:
+			commitments[msg.From] = append(commitments[msg.From], msg.Commitments)
+		case msg.Type == ShareMsg && msg.To == p.ID && msg.Share != nil:
+			shares[msg.From] = append(shares[msg.From], msg.Share)
+		}
+	}
+	index := p.receiverIndex(p.ID)
+	var complaints []*Message
+	for _, dealer := range p.dealers {
+		if dealer == p.ID {
+			continue
+		}
+		if len(commitments[dealer]) != 1 || !p.validCommitment(dealer, commitments[dealer][0]) {
+			p.disqualified[dealer] = true
+			continue
+		}
+		p.commitments[dealer] = commitments[dealer][0]
+		if index == 0 {
+			continue
+		}
+		if len(shares[dealer]) == 1 && p.verifyShare(dealer, index, shares[dealer][0]) {
+			p.received[dealer] = shares[dealer][0]
+			continue
+		}
+		complaints = append(complaints, &Message{Type: ComplaintMsg, From: p.ID, To: Broadcast, Dealer: dealer})
+	}
+	return complaints
+}
+
+// validCommitment checks the length of a dealer's commitment, and that it
+// commits to the right secret when resharing or refreshing.
+func (p *Participant) validCommitment(dealer int, commitment []Point) bool {
+	if len(commitment) != p.t {
+		return false
+	}
+	switch p.mode {
+	case reshareMode:
+		oldIndex := indexOf(p.dealers, dealer)
+		return commitment[0].Equals(bgls.GetSharePublicKey(p.curve, p.oldVvec, oldIndex))
+	case refreshMode:
+		return commitment[0].Equals(p.curve.GetG2Infinity())
+	}
+	return true
+}
+
+// ProcessComplaints records the complaints received in the complaint phase,
+// and returns the participant's justifications for the complaints against it.
+func (p *Participant) ProcessComplaints(msgs []*Message) []*Message {
+	var justifications []*Message
+	for _, msg := range msgs {
+		if msg.Type != ComplaintMsg || msg.To != Broadcast || !containsInt(p.receivers, msg.From) ||
+			msg.From == msg.Dealer || !containsInt(p.dealers, msg.Dealer) || p.disqualified[msg.Dealer] {
+			continue
+		}
+		if containsInt(p.complaints[msg.Dealer], msg.From) {
+			continue
+		}
+		p.complaints[msg.Dealer] = append(p.complaints[msg.Dealer], msg.From)
+		if msg.Dealer == p.ID {
+			share := p.shares[p.receiverIndex(msg.From)-1].Value
+			justifications = append(justifications, &Message{Type: JustificationMsg, From: p.ID,
+				To: Broadcast, Dealer: p.ID, Accuser: msg.From, Share: share})
+		}
+	}
+	return justifications
+}
+
+// ProcessJustifications checks the justifications received in the
+// justification phase, disqualifies every dealer which didn't justify all of
+// the complaints against it, and computes the participant's result.
+func (p *Participant) ProcessJustifications(msgs []*Message) (*Result, error) {
+	justified := make(map[int]map[int]*big.Int)
+	for _, msg := range msgs {
+		if msg.Type != JustificationMsg || msg.To != Broadcast || msg.From != msg.Dealer ||
+			msg.Share == nil || !containsInt(p.complaints[msg.Dealer], msg.Accuser) {
+			continue
+		}
+		if justified[msg.Dealer] == nil {
+			justified[msg.Dealer] = make(map[int]*big.Int)
+		}
+		if _, ok := justified[msg.Dealer][msg.Accuser]; ok {
+			// Conflicting justifications are treated as no justification.
+			justified[msg.Dealer][msg.Accuser] = nil
+			continue
+		}
+		justified[msg.Dealer][msg.Accuser] = msg.Share
+	}
+	for dealer, accusers := range p.complaints {
+		for _, accuser := range accusers {
+			share := justified[dealer][accuser]
+			if share == nil || !p.verifyShare(dealer, p.receiverIndex(accuser), share) {
+				p.disqualified[dealer] = true
+				break
+			}
+			if accuser == p.ID {
+				p.received[dealer] = share
+			}
+		}
+	}
+	return p.result()
+}
+
+// result combines the dealings of the qualified dealers. Key generation sums
+// them, refreshing adds them to the old shares, and resharing interpolates the
+// old shares from them.
+func (p *Participant) result() (*Result, error) {
+	res := &Result{}
+	for _, dealer := range p.dealers {
+		if !p.disqualified[dealer] {
+			res.Qualified = append(res.Qualified, dealer)
+		}
+	}
+	sort.Ints(res.Qualified)
+	if len(res.Qualified) == 0 {
+		return nil, errors.New("every dealer was disqualified")
+	}
+	coeffs := make([]*big.Int, len(res.Qualified))
+	for i := range coeffs {
+		coeffs[i] = big.NewInt(1)
+	}
+	if p.mode == reshareMode {
+		if len(res.Qualified) < len(p.oldVvec) {
+			return nil, errors.New("too few qualified dealers to reshare the group key")
+		}
+		oldIndices := make([]int, len(res.Qualified))
+		for i, dealer := range res.Qualified {
+			oldIndices[i] = indexOf(p.dealers, dealer)
+		}
+		coeffs, _ = bgls.LagrangeCoefficients(p.curve, oldIndices)
+	}
+
+	res.VerificationVector = make([]Point, p.t)
+	for k := 0; k < p.t; k++ {
+		column := make([]Point, len(res.Qualified))
+		for i, dealer := range res.Qualified {
+			column[i] = p.commitments[dealer][k]
+		}
+		res.VerificationVector[k] = AggregatePoints(ScalePoints(column, coeffs))
+	}
+	if p.mode == refreshMode {
+		for k := range res.VerificationVector {
+			res.VerificationVector[k], _ = res.VerificationVector[k].Add(p.oldVvec[k])
+		}
+	}
+	res.GroupKey = res.VerificationVector[0]
+	if p.mode != keygenMode && !res.GroupKey.Equals(p.oldVvec[0]) {
+		return nil, errors.New("group key changed")
+	}
+
+	index := p.receiverIndex(p.ID)
+	if index == 0 {
+		return res, nil
+	}
+	value := new(big.Int)
+	if p.mode == refreshMode {
+		value.Set(p.oldShare)
+	}
+	for i, dealer := range res.Qualified {
+		share, ok := p.received[dealer]
+		if !ok {
+			return nil, errors.New("missing a share from a qualified dealer")
+		}
+		value.Add(value, new(big.Int).Mul(coeffs[i], share))
+	}
+	res.Share = bgls.SecretShare{Index: index, Value: value.Mod(value, p.curve.GetG1Order())}
+	if !bgls.VerifySecretShare(p.curve, res.Share, res.VerificationVector) {
+		return nil, errors.New("share is inconsistent with the verification vector")
+	}
+	return res, nil
+}
+
+// verifyShare checks the share which the dealer gave to the receiver with the
+// given share index, against the dealer's commitment.
+func (p *Participant) verifyShare(dealer int, index int, share *big.Int) bool {
+	return bgls.VerifySecretShare(p.curve, bgls.SecretShare{Index: index, Value: share}, p.commitments[dealer])
+}
+
+// receiverIndex returns the index of the share which the receiver gets, or 0
+// if it isn't a receiver.
+func (p *Participant) receiverIndex(id int) int {
+	return indexOf(p.receivers, id)
+}
+
+// makeCommittee returns the IDs 1 to n.
+func makeCommittee(n int) []int {
+	committee := make([]int, n)
+	for i := range committee {
+		committee[i] = i + 1
+	}
+	return committee
+}
+
+// indexOf returns the position of x in the list, starting at 1, or 0 if it isn't present.
+func indexOf(list []int, x int) int {
+	for i, y := range list {
+		if x == y {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// copyPoints copies the points, since points which are shared between
+// goroutines can't safely be used concurrently.
+func copyPoints(pts []Point) []Point {
+	if pts == nil {
+		return nil
+	}
+	copied := make([]Point, len(pts))
+	for i, pt := range pts {
+		copied[i] = pt.Copy()
+	}
+	return copied
+}
+
+func containsInt(list []int, x int) bool {
+	return indexOf(list, x) > 0
+}
@@ -0,0 +1,213 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+// runCeremony runs a key generation ceremony with every participant in its own goroutine.
+func runCeremony(t *testing.T, curve CurveSystem, threshold int, n int, transport Transport) ([]*Result, []error) {
+	participants := make([]*Participant, n)
+	for i := 0; i < n; i++ {
+		p, err := NewParticipant(curve, i+1, threshold, n)
+		assert.Nil(t, err)
+		participants[i] = p
+	}
+	return runParticipants(participants, transport)
+}
+
+func runParticipants(participants []*Participant, transport Transport) ([]*Result, []error) {
+	results := make([]*Result, len(participants))
+	errs := make([]error, len(participants))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i, p := range participants {
+		wg.Add(1)
+		go func(i int, p *Participant) {
+			defer wg.Done()
+			results[i], errs[i] = Run(ctx, p, transport)
+		}(i, p)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// checkResults checks that the honest participants agree on the group key,
+// and that their shares produce threshold signatures under it.
+func checkResults(t *testing.T, curve CurveSystem, threshold int, results []*Result, honest []int, qualified []int) {
+	first := results[honest[0]-1]
+	assert.Equal(t, qualified, first.Qualified)
+	assert.True(t, first.GroupKey.Equals(first.VerificationVector[0]))
+	for _, id := range honest {
+		res := results[id-1]
+		assert.Equal(t, qualified, res.Qualified, "Participants disagree on the qualified dealers")
+		assert.True(t, first.GroupKey.Equals(res.GroupKey), "Participants disagree on the group key")
+		for i := range first.VerificationVector {
+			assert.True(t, first.VerificationVector[i].Equals(res.VerificationVector[i]))
+		}
+	}
+
+	msg := make([]byte, 32)
+	rand.Read(msg)
+	sigs := make([]Point, threshold)
+	indices := make([]int, threshold)
+	for i := 0; i < threshold; i++ {
+		share := results[honest[i]-1].Share
+		sigs[i] = bgls.Sign(curve, share.Value, msg)
+		indices[i] = share.Index
+		assert.True(t, bgls.ThresholdVerifyPartialSignature(curve, sigs[i], first.VerificationVector, share.Index, msg))
+	}
+	sig, ok := bgls.ThresholdCombineSignatures(curve, sigs, indices)
+	assert.True(t, ok)
+	assert.True(t, bgls.VerifySingleSignature(curve, sig, first.GroupKey, msg),
+		"Threshold signature failed verification under the group key")
+}
+
+func TestHonestCeremony(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		results, errs := runCeremony(t, curve, threshold, n, NewMemoryTransport(n))
+		for _, err := range errs {
+			assert.Nil(t, err)
+		}
+		checkResults(t, curve, threshold, results, []int{1, 2, 3, 4, 5}, []int{1, 2, 3, 4, 5})
+
+		shares := make([]bgls.SecretShare, threshold)
+		for i := 0; i < threshold; i++ {
+			shares[i] = results[i+1].Share
+		}
+		sk, ok := bgls.RecoverSecret(curve, shares)
+		assert.True(t, ok)
+		assert.True(t, bgls.LoadPublicKey(curve, sk).Equals(results[0].GroupKey),
+			"Shares don't interpolate to the group secret key")
+	}
+}
+
+func TestMaliciousDealers(t *testing.T) {
+	threshold, n := 3, 6
+	badShare := func(phase Phase, msg *Message) *Message {
+		msg.Share = new(big.Int).Add(msg.Share, big.NewInt(1))
+		return msg
+	}
+	tests := []struct {
+		name      string
+		intercept func(phase Phase, msg *Message) *Message
+		qualified []int
+	}{
+		{"bad share, justified", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == ShareMsg && msg.To == 4 {
+				return badShare(phase, msg)
+			}
+			return msg
+		}, []int{1, 2, 3, 4, 5, 6}},
+		{"withheld share, justified", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == ShareMsg && msg.To == 4 {
+				return nil
+			}
+			return msg
+		}, []int{1, 2, 3, 4, 5, 6}},
+		{"bad share, unjustified", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == ShareMsg && msg.To == 4 {
+				return badShare(phase, msg)
+			}
+			if msg.From == 2 && msg.Type == JustificationMsg {
+				return nil
+			}
+			return msg
+		}, []int{1, 3, 4, 5, 6}},
+		{"bad share, bad justification", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && (msg.Type == JustificationMsg || msg.Type == ShareMsg && msg.To == 4) {
+				return badShare(phase, msg)
+			}
+			return msg
+		}, []int{1, 3, 4, 5, 6}},
+		{"no commitment", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == CommitmentMsg {
+				return nil
+			}
+			return msg
+		}, []int{1, 3, 4, 5, 6}},
+		{"short commitment", func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == CommitmentMsg {
+				msg.Commitments = msg.Commitments[:threshold-1]
+			}
+			return msg
+		}, []int{1, 3, 4, 5, 6}},
+		{"two bad dealers and a false accuser", func(phase Phase, msg *Message) *Message {
+			if (msg.From == 2 || msg.From == 5) && msg.Type == ShareMsg && msg.To != 1 {
+				return badShare(phase, msg)
+			}
+			if msg.From == 5 && msg.Type == JustificationMsg {
+				return nil
+			}
+			if msg.From == 3 && phase == ComplaintPhase {
+				msg.Dealer = 1
+			}
+			return msg
+		}, []int{1, 2, 3, 4, 6}},
+	}
+	for _, curve := range curves {
+		for _, test := range tests {
+			transport := NewMemoryTransport(n)
+			transport.Intercept = test.intercept
+			results, errs := runCeremony(t, curve, threshold, n, transport)
+			honest := []int{}
+			for id := 1; id <= n; id++ {
+				if id != 2 && id != 5 && id != 3 {
+					assert.Nil(t, errs[id-1], test.name)
+					honest = append(honest, id)
+				}
+			}
+			checkResults(t, curve, threshold, results, honest, test.qualified)
+		}
+	}
+}
+
+func TestMemoryTransportCancellation(t *testing.T) {
+	transport := NewMemoryTransport(3)
+	assert.Nil(t, transport.Send(context.Background(), 1, DealPhase, nil))
+	assert.NotNil(t, transport.Send(context.Background(), 1, DealPhase, nil), "Participant sent twice in a phase")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := transport.Receive(ctx, 1, DealPhase)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestMessageMarshal(t *testing.T) {
+	for _, curve := range curves {
+		p, err := NewParticipant(curve, 1, 2, 3)
+		assert.Nil(t, err)
+		for _, msg := range append(p.Deal(), &Message{Type: ComplaintMsg, From: 3, Dealer: 1}) {
+			decoded, ok := UnmarshalMessage(curve, msg.Marshal())
+			assert.True(t, ok)
+			assert.Equal(t, msg.Type, decoded.Type)
+			assert.Equal(t, msg.To, decoded.To)
+			assert.Equal(t, msg.Dealer, decoded.Dealer)
+			assert.Equal(t, len(msg.Commitments), len(decoded.Commitments))
+			for i := range msg.Commitments {
+				assert.True(t, msg.Commitments[i].Equals(decoded.Commitments[i]))
+			}
+			if msg.Share != nil {
+				assert.Equal(t, 0, msg.Share.Cmp(decoded.Share))
+			}
+			_, ok = UnmarshalMessage(curve, msg.Marshal()[1:])
+			assert.False(t, ok, "Truncated message was unmarshalled")
+		}
+		_, err = NewParticipant(curve, 4, 2, 3)
+		assert.NotNil(t, err, "Participant ID above n was accepted")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package dkg
+
+import (
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Phase is a round of the protocol. Every participant sends its messages for
+// a phase, and then receives everything that was sent to it during that phase.
+type Phase byte
+
+const (
+	// DealPhase is when every dealer broadcasts its commitments, and privately
+	// sends each participant their share.
+	DealPhase Phase = iota
+	// ComplaintPhase is when participants complain about dealers which sent
+	// them invalid shares, or no share at all.
+	ComplaintPhase
+	// JustificationPhase is when dealers answer complaints by publicly
+	// revealing the complaining participant's share.
+	JustificationPhase
+)
+
+// MessageType identifies the contents of a Message.
+type MessageType byte
+
+const (
+	// CommitmentMsg is a dealer's broadcast Feldman commitment to its polynomial.
+	CommitmentMsg MessageType = iota
+	// ShareMsg is a dealer's private share for a single participant.
+	ShareMsg
+	// ComplaintMsg is a broadcast complaint against a dealer.
+	ComplaintMsg
+	// JustificationMsg is a dealer's broadcast of the share of a participant
+	// which complained about it.
+	JustificationMsg
+)
+
+// Broadcast is the recipient of messages which are sent to every participant.
+const Broadcast = 0
+
+// Message is a protocol message. Participants are numbered from 1 to n.
+// Share messages must be sent over a private channel, and the transport must
+// authenticate the sender of every message.
+type Message struct {
+	Type MessageType
+	From int
+	// To is the recipient, or Broadcast.
+	To int
+	// Dealer is the dealer which a complaint or justification is about.
+	Dealer int
+	// Accuser is the participant whose share a justification reveals.
+	Accuser     int
+	Commitments []Point
+	Share       *big.Int
+}
+
+// Marshal serializes the message.
+func (m *Message) Marshal() []byte {
+	buf := []byte{byte(m.Type)}
+	for _, n := range []int{m.From, m.To, m.Dealer, m.Accuser, len(m.Commitments)} {
+		buf = encoding.AppendUint32(buf, uint32(n))
+	}
+	for _, c := range m.Commitments {
+		buf = encoding.AppendLengthPrefixed(buf, c.Marshal())
+	}
+	if m.Share == nil {
+		return append(buf, 0)
+	}
+	return encoding.AppendLengthPrefixed(append(buf, 1), m.Share.Bytes())
+}
+
+// UnmarshalMessage deserializes a message created with Marshal.
+func UnmarshalMessage(curve CurveSystem, data []byte) (*Message, bool) {
+	r := encoding.NewReader(data)
+	m := &Message{Type: MessageType(r.Byte())}
+	for _, field := range []*int{&m.From, &m.To, &m.Dealer, &m.Accuser} {
+		*field = int(r.Uint32())
+	}
+	for i, n := uint32(0), r.Uint32(); i < n && r.OK(); i++ {
+		m.Commitments = append(m.Commitments, r.G2(curve))
+	}
+	switch r.Byte() {
+	case 0:
+	case 1:
+		m.Share = new(big.Int).SetBytes(r.LengthPrefixed())
+	default:
+		return nil, false
+	}
+	if !r.Done() || m.Type > JustificationMsg {
+		return nil, false
+	}
+	return m, true
+}
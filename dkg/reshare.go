@@ -0,0 +1,104 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package dkg
+
+// Resharing hands the group secret key over to a new committee, with a
+// different size and threshold, without changing the group public key. Each
+// old share holder i deals its share s_i to the new committee, with a random
+// polynomial g_i of the new degree where g_i(0) = s_i. Its dealing is checked
+// against the old verification vector, since the first element of its
+// commitment must be the public key of share i. After the complaint and
+// justification rounds, the new committee member j computes its share as
+// sum_i lambda_i * g_i(j), over the qualified old share holders, where lambda_i
+// are their Lagrange coefficients. Since sum_i lambda_i * s_i is the old
+// secret key, this is a sharing of the same key, and the new verification
+// vector is interpolated from the dealers' commitments in the same way. At
+// least t of the old share holders must deal honestly.
+//
+// Refreshing re-randomizes the shares of the same committee, so that shares
+// which leaked in earlier epochs become useless. Each share holder deals a
+// random polynomial with a constant term of zero, and everyone adds the
+// qualified dealings to their old share and verification vector.
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// ReshareConfig describes a resharing from one committee to another. A
+// committee lists its members' IDs on the transport, and the member at
+// position i of the list holds the share with index i+1. A party can be a
+// member of both committees.
+type ReshareConfig struct {
+	OldCommittee []int
+	// OldVerificationVector is the old committee's verification vector. Its
+	// length is the old threshold.
+	OldVerificationVector []Point
+	NewCommittee          []int
+	NewThreshold          int
+}
+
+// NewResharer creates the participant with the given ID in a resharing. Old
+// committee members must provide their share, which they deal to the new
+// committee. Members of only the new committee pass a nil share.
+func NewResharer(curve CurveSystem, id int, config ReshareConfig, share *bgls.SecretShare) (*Participant, error) {
+	if len(config.OldVerificationVector) == 0 || len(config.OldCommittee) < len(config.OldVerificationVector) {
+		return nil, errors.New("old committee is smaller than its threshold")
+	}
+	if !distinctIDs(config.OldCommittee) || !distinctIDs(config.NewCommittee) {
+		return nil, errors.New("committee members must be distinct and positive")
+	}
+	oldIndex := indexOf(config.OldCommittee, id)
+	if oldIndex == 0 && indexOf(config.NewCommittee, id) == 0 {
+		return nil, errors.New("participant isn't in either committee")
+	}
+	var secret *big.Int
+	if oldIndex > 0 {
+		if share == nil || share.Index != oldIndex ||
+			!bgls.VerifySecretShare(curve, *share, config.OldVerificationVector) {
+			return nil, errors.New("old committee member needs its valid share")
+		}
+		secret = share.Value
+	} else if share != nil {
+		return nil, errors.New("only old committee members have a share")
+	}
+	p, err := newParticipant(curve, id, reshareMode, config.OldCommittee, config.NewCommittee,
+		config.NewThreshold, secret)
+	if err != nil {
+		return nil, err
+	}
+	p.oldVvec = copyPoints(config.OldVerificationVector)
+	return p, nil
+}
+
+// NewRefresher creates a participant in refreshing the shares of a committee
+// of n members, which were created by key generation or a previous refresh.
+// The participant's ID is its share index.
+func NewRefresher(curve CurveSystem, share bgls.SecretShare, vvec []Point, n int) (*Participant, error) {
+	if share.Index < 1 || share.Index > n || len(vvec) > n || !bgls.VerifySecretShare(curve, share, vvec) {
+		return nil, errors.New("refreshing needs a valid share from a committee of n members")
+	}
+	committee := makeCommittee(n)
+	p, err := newParticipant(curve, share.Index, refreshMode, committee, committee, len(vvec), new(big.Int))
+	if err != nil {
+		return nil, err
+	}
+	p.oldVvec = copyPoints(vvec)
+	p.oldShare = share.Value
+	return p, nil
+}
+
+func distinctIDs(ids []int) bool {
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if id < 1 || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// epoch is a committee's state between ceremonies. The member at position i of
+// the committee holds shares[i].
+type epoch struct {
+	committee []int
+	shares    []bgls.SecretShare
+	vvec      []Point
+}
+
+// reshare runs a resharing from the old epoch's committee to the new
+// committee, and returns the new epoch. Every party in either committee runs
+// in its own goroutine.
+func reshare(t *testing.T, curve CurveSystem, old *epoch, newCommittee []int, newThreshold int,
+	intercept func(Phase, *Message) *Message) (*epoch, []error) {
+	config := ReshareConfig{old.committee, old.vvec, newCommittee, newThreshold}
+	ids := append([]int{}, old.committee...)
+	maxID := 0
+	for _, id := range newCommittee {
+		if !containsInt(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	participants := make([]*Participant, len(ids))
+	for i, id := range ids {
+		var share *bgls.SecretShare
+		if index := indexOf(old.committee, id); index > 0 {
+			share = &old.shares[index-1]
+		}
+		p, err := NewResharer(curve, id, config, share)
+		assert.Nil(t, err)
+		participants[i] = p
+		if id > maxID {
+			maxID = id
+		}
+	}
+	transport := NewMemoryTransport(maxID)
+	transport.Intercept = intercept
+	results, errs := runParticipants(participants, transport)
+	next := &epoch{committee: newCommittee, shares: make([]bgls.SecretShare, len(newCommittee))}
+	for i, id := range ids {
+		if index := indexOf(newCommittee, id); index > 0 && results[i] != nil {
+			next.shares[index-1] = results[i].Share
+			next.vvec = results[i].VerificationVector
+		}
+	}
+	return next, errs
+}
+
+// refresh runs a refresh of the epoch's shares.
+func refresh(t *testing.T, curve CurveSystem, old *epoch) *epoch {
+	n := len(old.committee)
+	participants := make([]*Participant, n)
+	for i := 0; i < n; i++ {
+		p, err := NewRefresher(curve, old.shares[i], old.vvec, n)
+		assert.Nil(t, err)
+		participants[i] = p
+	}
+	results, errs := runParticipants(participants, NewMemoryTransport(n))
+	next := &epoch{committee: old.committee, shares: make([]bgls.SecretShare, n)}
+	for i := 0; i < n; i++ {
+		assert.Nil(t, errs[i])
+		next.shares[i] = results[i].Share
+		next.vvec = results[i].VerificationVector
+	}
+	return next
+}
+
+// thresholdSign combines partial signatures from the shares at the given positions.
+func thresholdSign(curve CurveSystem, shares []bgls.SecretShare, positions []int, msg []byte) Point {
+	sigs := make([]Point, len(positions))
+	indices := make([]int, len(positions))
+	for i, pos := range positions {
+		sigs[i] = bgls.Sign(curve, shares[pos].Value, msg)
+		indices[i] = shares[pos].Index
+	}
+	sig, _ := bgls.ThresholdCombineSignatures(curve, sigs, indices)
+	return sig
+}
+
+func TestResharingEpochs(t *testing.T) {
+	msg := make([]byte, 32)
+	rand.Read(msg)
+	for _, curve := range curves {
+		results, _ := runCeremony(t, curve, 3, 5, NewMemoryTransport(5))
+		current := &epoch{committee: makeCommittee(5), vvec: results[0].VerificationVector}
+		for _, res := range results {
+			current.shares = append(current.shares, res.Share)
+		}
+		groupKey := current.vvec[0]
+
+		// Old member 2 sends a bad share, and doesn't justify it.
+		cheat := func(phase Phase, msg *Message) *Message {
+			if msg.From == 2 && msg.Type == ShareMsg && msg.To == 6 {
+				msg.Share = new(big.Int).Add(msg.Share, big.NewInt(1))
+			}
+			if msg.From == 2 && msg.Type == JustificationMsg {
+				return nil
+			}
+			return msg
+		}
+		steps := []struct {
+			committee []int
+			threshold int
+			intercept func(Phase, *Message) *Message
+		}{
+			{[]int{4, 5, 6, 7, 8, 9, 10}, 4, cheat},
+			{nil, 0, nil},
+			{[]int{3, 1, 2}, 2, nil},
+			{[]int{1, 2, 3, 4, 5, 6}, 6, nil},
+		}
+		for _, step := range steps {
+			previous := current
+			if step.committee == nil {
+				current = refresh(t, curve, previous)
+			} else {
+				var errs []error
+				current, errs = reshare(t, curve, previous, step.committee, step.threshold, step.intercept)
+				for i, err := range errs {
+					if step.intercept == nil || i != 1 {
+						assert.Nil(t, err)
+					}
+				}
+			}
+			threshold := len(current.vvec)
+			assert.True(t, groupKey.Equals(current.vvec[0]), "Group key changed between epochs")
+			for _, share := range current.shares {
+				assert.True(t, bgls.VerifySecretShare(curve, share, current.vvec))
+			}
+			positions := make([]int, threshold)
+			for i := range positions {
+				positions[i] = len(current.shares) - 1 - i
+			}
+			sig := thresholdSign(curve, current.shares, positions, msg)
+			assert.True(t, bgls.VerifySingleSignature(curve, sig, groupKey, msg),
+				"Threshold signature from the new shares failed verification")
+			sig = thresholdSign(curve, current.shares, positions[1:], msg)
+			assert.False(t, bgls.VerifySingleSignature(curve, sig, groupKey, msg),
+				"Threshold signature from too few shares verified")
+			// Shares from different epochs don't combine.
+			mixed := append([]bgls.SecretShare{}, current.shares...)
+			if len(previous.shares) >= len(mixed) && threshold > 1 {
+				mixed[positions[0]] = previous.shares[positions[0]]
+				sig = thresholdSign(curve, mixed, positions, msg)
+				assert.False(t, bgls.VerifySingleSignature(curve, sig, groupKey, msg),
+					"Shares from different epochs combined into a valid signature")
+			}
+		}
+	}
+}
+
+func TestResharingDealingVerification(t *testing.T) {
+	for _, curve := range curves {
+		shares, vvec, _ := bgls.ThresholdKeyGen(curve, 2, 3)
+		old := &epoch{committee: []int{1, 2, 3}, shares: shares, vvec: vvec}
+
+		// Dealing a different secret is detected by every receiver.
+		wrongSecret := func(phase Phase, msg *Message) *Message {
+			if msg.From == 1 && msg.Type == CommitmentMsg {
+				msg.Commitments = append([]Point{bgls.LoadPublicKey(curve, big.NewInt(5))}, msg.Commitments[1:]...)
+			}
+			return msg
+		}
+		next, errs := reshare(t, curve, old, []int{4, 5, 6}, 2, wrongSecret)
+		for _, err := range errs {
+			assert.Nil(t, err)
+		}
+		assert.True(t, vvec[0].Equals(next.vvec[0]), "Group key changed")
+
+		// Resharing fails without t honest dealers.
+		twoCheats := func(phase Phase, msg *Message) *Message {
+			if msg.From <= 2 && msg.Type == CommitmentMsg {
+				return nil
+			}
+			return msg
+		}
+		_, errs = reshare(t, curve, old, []int{4, 5, 6}, 2, twoCheats)
+		assert.NotNil(t, errs[3], "Resharing succeeded with too few qualified dealers")
+
+		_, err := NewResharer(curve, 1, ReshareConfig{old.committee, vvec, []int{4, 5}, 2}, &shares[1])
+		assert.NotNil(t, err, "Resharer accepted another member's share")
+		_, err = NewResharer(curve, 7, ReshareConfig{old.committee, vvec, []int{4, 5}, 2}, nil)
+		assert.NotNil(t, err, "Resharer outside of both committees was accepted")
+		for _, threshold := range []int{0, 3} {
+			_, err = NewResharer(curve, 4, ReshareConfig{old.committee, vvec, []int{4, 5}, threshold}, nil)
+			assert.NotNil(t, err, "Receiver accepted a new threshold of %d for 2 members", threshold)
+		}
+		_, err = NewRefresher(curve, shares[0], vvec[:1], 3)
+		assert.NotNil(t, err, "Refresher accepted a share inconsistent with the verification vector")
+	}
+}
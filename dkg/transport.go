@@ -0,0 +1,131 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package dkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Transport delivers messages between the participants of a ceremony. The
+// protocol is synchronous, so a transport must decide when each phase is
+// over, e.g. once every participant has sent its messages, or after a timeout.
+// It must authenticate senders, deliver broadcasts consistently to every
+// participant, and keep share messages private.
+type Transport interface {
+	// Send sends the participant's messages for the phase. It is called
+	// exactly once per phase by each participant, even if it has nothing to send.
+	Send(ctx context.Context, from int, phase Phase, msgs []*Message) error
+	// Receive blocks until the phase is over, and returns every message which
+	// was sent to the participant, or broadcast, during the phase.
+	Receive(ctx context.Context, id int, phase Phase) ([]*Message, error)
+}
+
+// Run runs the participant's side of the ceremony over the transport.
+func Run(ctx context.Context, p *Participant, transport Transport) (*Result, error) {
+	msgs, err := exchange(ctx, p.ID, transport, DealPhase, p.Deal())
+	if err != nil {
+		return nil, err
+	}
+	msgs, err = exchange(ctx, p.ID, transport, ComplaintPhase, p.ProcessDeals(msgs))
+	if err != nil {
+		return nil, err
+	}
+	msgs, err = exchange(ctx, p.ID, transport, JustificationPhase, p.ProcessComplaints(msgs))
+	if err != nil {
+		return nil, err
+	}
+	return p.ProcessJustifications(msgs)
+}
+
+func exchange(ctx context.Context, id int, transport Transport, phase Phase, msgs []*Message) ([]*Message, error) {
+	if err := transport.Send(ctx, id, phase, msgs); err != nil {
+		return nil, err
+	}
+	return transport.Receive(ctx, id, phase)
+}
+
+// MemoryTransport is a Transport between participants in the same process.
+// A phase ends once all n participants have sent their messages for it.
+type MemoryTransport struct {
+	n     int
+	mu    sync.Mutex
+	sent  map[Phase]map[int]bool
+	inbox map[Phase]map[int][]*Message
+	done  map[Phase]chan struct{}
+	// Intercept, if set, is called on every message before it is delivered,
+	// and may modify it, or return nil to drop it. It is used to simulate
+	// malicious participants and faulty networks.
+	Intercept func(phase Phase, msg *Message) *Message
+}
+
+// NewMemoryTransport creates a MemoryTransport for n participants.
+func NewMemoryTransport(n int) *MemoryTransport {
+	return &MemoryTransport{n: n, sent: make(map[Phase]map[int]bool),
+		inbox: make(map[Phase]map[int][]*Message), done: make(map[Phase]chan struct{})}
+}
+
+// Send delivers the messages. The sender of each message is set to from. As
+// over a network, each recipient gets its own copy of the commitments.
+func (m *MemoryTransport) Send(ctx context.Context, from int, phase Phase, msgs []*Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if from < 1 || from > m.n {
+		return errors.New("unknown participant")
+	}
+	if m.sent[phase] == nil {
+		m.sent[phase] = make(map[int]bool)
+		m.inbox[phase] = make(map[int][]*Message)
+	}
+	if m.sent[phase][from] {
+		return errors.New("participant already sent its messages for this phase")
+	}
+	m.sent[phase][from] = true
+	for _, msg := range msgs {
+		delivered := *msg
+		delivered.From = from
+		if m.Intercept != nil {
+			if msg = m.Intercept(phase, &delivered); msg == nil {
+				continue
+			}
+			delivered = *msg
+		}
+		for to := 1; to <= m.n; to++ {
+			if delivered.To == Broadcast || delivered.To == to {
+				copied := delivered
+				copied.Commitments = copyPoints(delivered.Commitments)
+				m.inbox[phase][to] = append(m.inbox[phase][to], &copied)
+			}
+		}
+	}
+	if len(m.sent[phase]) == m.n {
+		close(m.phaseDone(phase))
+	}
+	return nil
+}
+
+// Receive waits for every participant to send its messages for the phase.
+func (m *MemoryTransport) Receive(ctx context.Context, id int, phase Phase) ([]*Message, error) {
+	m.mu.Lock()
+	done := m.phaseDone(phase)
+	m.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inbox[phase][id], nil
+}
+
+// phaseDone returns the channel which is closed when the phase is over.
+// The caller must hold m.mu.
+func (m *MemoryTransport) phaseDone(phase Phase) chan struct{} {
+	if m.done[phase] == nil {
+		m.done[phase] = make(chan struct{})
+	}
+	return m.done[phase]
+}
@@ -0,0 +1,413 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package groupsig implements Boneh, Boyen and Shacham's short group
+// signatures (BBS04). A member of a group signs on behalf of the group, and
+// verifiers learn only that some member signed, while the group manager can
+// open any signature to find which member made it.
+//
+// The manager's issuing key gamma is a Boneh-Boyen signing key, with public
+// key W = gamma * G2. A member's key (A, x) is the weak BB signature
+// A = (gamma + x)^-1 * G1 on a random x, so e(A, W + x * G2) = e(G1, G2), and
+// under the strong Diffie-Hellman assumption nobody but the manager can
+// create new member keys. A signature is a linear encryption of A to the
+// manager's opening key, with a Fiat-Shamir proof of knowledge of a member key
+// (A, x) such that the ciphertext encrypts A.
+//
+// Revocation follows section 7 of the paper. The manager publishes a
+// revocation (A*, x*, G2* = (gamma + x*)^-1 * G2) which anyone can check. With
+// it, verifiers update the group key to G1 = A*, G2 = G2*, and each
+// unrevoked member updates their key, which the revoked member can't do.
+package groupsig
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+const challengeDomain = "BGLS-BBS04-challenge"
+
+// GroupPublicKey is the public key signatures are verified with.
+type GroupPublicKey struct {
+	G1 Point
+	H  Point
+	U  Point
+	V  Point
+	G2 Point
+	W  Point
+}
+
+// Manager is the group manager, who issues member keys, opens signatures and
+// revokes members. The issuing and opening keys are both held by the manager.
+type Manager struct {
+	Key     *GroupPublicKey
+	gamma   *big.Int
+	xi1     *big.Int
+	xi2     *big.Int
+	members []*MemberKey
+	revoked map[int]bool
+	// history holds the group key and member keys of each epoch before a
+	// revocation, so signatures made under old group keys can still be opened.
+	history []epoch
+}
+
+// epoch is a group key, and the A of each member who could sign under it,
+// indexed by member, with nil for members who were already revoked.
+type epoch struct {
+	key     *GroupPublicKey
+	members []Point
+}
+
+// MemberKey is a group member's signing key. Index identifies the member to
+// the manager.
+type MemberKey struct {
+	Index int
+	A     Point
+	X     *big.Int
+}
+
+// Revocation revokes the member key (A, X). G2 is the new group generator on G2.
+type Revocation struct {
+	A  Point
+	X  *big.Int
+	G2 Point
+}
+
+// Signature is a group signature. T1, T2 and T3 are the linear encryption of
+// the signer's A, and the rest is the proof of knowledge.
+type Signature struct {
+	T1      Point
+	T2      Point
+	T3      Point
+	C       *big.Int
+	SAlpha  *big.Int
+	SBeta   *big.Int
+	SX      *big.Int
+	SDelta1 *big.Int
+	SDelta2 *big.Int
+}
+
+// Setup creates a group, with no members.
+func Setup(curve CurveSystem) (*Manager, error) {
+	scalars, err := randomNonzeroScalars(curve, 4)
+	if err != nil {
+		return nil, err
+	}
+	gamma, xi1, xi2, eta := scalars[0], scalars[1], scalars[2], scalars[3]
+	order := curve.GetG1Order()
+	h := curve.GetG1().Mul(eta)
+	key := &GroupPublicKey{
+		G1: curve.GetG1(),
+		H:  h,
+		U:  h.Mul(new(big.Int).ModInverse(xi1, order)),
+		V:  h.Mul(new(big.Int).ModInverse(xi2, order)),
+		G2: curve.GetG2(),
+		W:  curve.GetG2().Mul(gamma),
+	}
+	return &Manager{Key: key, gamma: gamma, xi1: xi1, xi2: xi2, revoked: make(map[int]bool)}, nil
+}
+
+// Issue creates a key for a new member.
+func (m *Manager) Issue(curve CurveSystem) (*MemberKey, error) {
+	order := curve.GetG1Order()
+	for {
+		x, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).Add(m.gamma, x)
+		if exp.Mod(exp, order).Sign() == 0 {
+			continue
+		}
+		key := &MemberKey{len(m.members), m.Key.G1.Mul(exp.ModInverse(exp, order)), x}
+		m.members = append(m.members, key)
+		return &MemberKey{key.Index, key.A.Copy(), new(big.Int).Set(x)}, nil
+	}
+}
+
+// VerifyMemberKey checks that the member key was issued for the group key,
+// i.e. e(A, W + x * G2) = e(G1, G2).
+func VerifyMemberKey(curve CurveSystem, gpk *GroupPublicKey, key *MemberKey) bool {
+	return isMemberKey(curve, gpk, key.A, key.X)
+}
+
+func isMemberKey(curve CurveSystem, gpk *GroupPublicKey, A Point, x *big.Int) bool {
+	wx, _ := gpk.W.Add(gpk.G2.Mul(x))
+	negG1 := gpk.G1.Mul(new(big.Int).Sub(curve.GetG1Order(), big.NewInt(1)))
+	paired, ok := curve.PairingProduct([]Point{A, negG1}, []Point{wx, gpk.G2})
+	return ok && curve.GetGTIdentity().Equals(paired)
+}
+
+// Sign signs msg on behalf of the group.
+func Sign(curve CurveSystem, gpk *GroupPublicKey, key *MemberKey, msg []byte) (*Signature, error) {
+	order := curve.GetG1Order()
+	scalars, err := randomNonzeroScalars(curve, 7)
+	if err != nil {
+		return nil, err
+	}
+	alpha, beta := scalars[0], scalars[1]
+	rAlpha, rBeta, rX, rDelta1, rDelta2 := scalars[2], scalars[3], scalars[4], scalars[5], scalars[6]
+	delta1 := mulMod(key.X, alpha, order)
+	delta2 := mulMod(key.X, beta, order)
+
+	sig := &Signature{T1: gpk.U.Mul(alpha), T2: gpk.V.Mul(beta)}
+	sig.T3, _ = key.A.Add(gpk.H.Mul(addMod(alpha, beta, order)))
+
+	R1 := gpk.U.Mul(rAlpha)
+	R2 := gpk.V.Mul(rBeta)
+	// R3 = e(T3, G2)^rX * e(H, W)^-(rAlpha + rBeta) * e(H, G2)^-(rDelta1 + rDelta2)
+	R3, ok := curve.PairingProduct([]Point{sig.T3, gpk.H}, []Point{
+		gpk.G2.Mul(rX),
+		negSum(curve, gpk.W.Mul(addMod(rAlpha, rBeta, order)), gpk.G2.Mul(addMod(rDelta1, rDelta2, order))),
+	})
+	if !ok {
+		return nil, errors.New("group key isn't valid")
+	}
+	R4, _ := sig.T1.Mul(rX).Add(gpk.U.Mul(neg(rDelta1, order)))
+	R5, _ := sig.T2.Mul(rX).Add(gpk.V.Mul(neg(rDelta2, order)))
+
+	sig.C = challenge(curve, gpk, msg, sig, R1, R2, R3, R4, R5)
+	// response returns r + c * s mod order.
+	response := func(r *big.Int, s *big.Int) *big.Int {
+		return addMod(r, mulMod(sig.C, s, order), order)
+	}
+	sig.SAlpha = response(rAlpha, alpha)
+	sig.SBeta = response(rBeta, beta)
+	sig.SX = response(rX, key.X)
+	sig.SDelta1 = response(rDelta1, delta1)
+	sig.SDelta2 = response(rDelta2, delta2)
+	return sig, nil
+}
+
+// Verify checks that msg was signed by a member of the group.
+func Verify(curve CurveSystem, gpk *GroupPublicKey, msg []byte, sig *Signature) bool {
+	order := curve.GetG1Order()
+	for _, s := range []*big.Int{sig.C, sig.SAlpha, sig.SBeta, sig.SX, sig.SDelta1, sig.SDelta2} {
+		if s == nil || s.Sign() < 0 || s.Cmp(order) >= 0 {
+			return false
+		}
+	}
+	negC := neg(sig.C, order)
+	R1, _ := gpk.U.Mul(sig.SAlpha).Add(sig.T1.Mul(negC))
+	R2, _ := gpk.V.Mul(sig.SBeta).Add(sig.T2.Mul(negC))
+	// R3 = e(T3, G2)^sX * e(H, W)^-(sAlpha + sBeta) * e(H, G2)^-(sDelta1 + sDelta2)
+	//      * (e(T3, W) / e(G1, G2))^c
+	t3Key, _ := gpk.G2.Mul(sig.SX).Add(gpk.W.Mul(sig.C))
+	R3, ok := curve.PairingProduct([]Point{sig.T3, gpk.H, gpk.G1.Mul(negC)}, []Point{
+		t3Key,
+		negSum(curve, gpk.W.Mul(addMod(sig.SAlpha, sig.SBeta, order)), gpk.G2.Mul(addMod(sig.SDelta1, sig.SDelta2, order))),
+		gpk.G2,
+	})
+	if !ok {
+		return false
+	}
+	R4, _ := sig.T1.Mul(sig.SX).Add(gpk.U.Mul(neg(sig.SDelta1, order)))
+	R5, _ := sig.T2.Mul(sig.SX).Add(gpk.V.Mul(neg(sig.SDelta2, order)))
+	return sig.C.Cmp(challenge(curve, gpk, msg, sig, R1, R2, R3, R4, R5)) == 0
+}
+
+// Open returns the index of the member who created a valid signature under
+// gpk. gpk may be the manager's current group key, or any key the group had
+// before a revocation, so signatures stay traceable after the group key changes.
+func (m *Manager) Open(curve CurveSystem, gpk *GroupPublicKey, msg []byte, sig *Signature) (int, bool) {
+	e, ok := m.epoch(gpk)
+	if !ok || !Verify(curve, e.key, msg, sig) {
+		return 0, false
+	}
+	order := curve.GetG1Order()
+	A := AggregatePoints([]Point{sig.T3, sig.T1.Mul(neg(m.xi1, order)), sig.T2.Mul(neg(m.xi2, order))})
+	for index, a := range e.members {
+		if a != nil && a.Equals(A) {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// epoch returns the epoch in which the group key was gpk.
+func (m *Manager) epoch(gpk *GroupPublicKey) (epoch, bool) {
+	if m.Key.equals(gpk) {
+		return m.currentEpoch(), true
+	}
+	for _, e := range m.history {
+		if e.key.equals(gpk) {
+			return e, true
+		}
+	}
+	return epoch{}, false
+}
+
+// currentEpoch returns the current group key, and the keys of the unrevoked members.
+func (m *Manager) currentEpoch() epoch {
+	members := make([]Point, len(m.members))
+	for i, member := range m.members {
+		if !m.revoked[i] {
+			members[i] = member.A.Copy()
+		}
+	}
+	return epoch{m.Key, members}
+}
+
+// Revoke revokes the member with the index, and updates the group key and
+// the keys of the remaining members. The revocation must be published, for
+// verifiers and members to apply.
+func (m *Manager) Revoke(curve CurveSystem, index int) (*Revocation, error) {
+	if index < 0 || index >= len(m.members) || m.revoked[index] {
+		return nil, errors.New("no such member")
+	}
+	order := curve.GetG1Order()
+	member := m.members[index]
+	exp := new(big.Int).Add(m.gamma, member.X)
+	rev := &Revocation{member.A.Copy(), new(big.Int).Set(member.X), m.Key.G2.Mul(exp.ModInverse(exp, order))}
+	gpk, ok := m.Key.ApplyRevocation(curve, rev)
+	if !ok {
+		return nil, errors.New("invalid revocation")
+	}
+	m.history = append(m.history, m.currentEpoch())
+	m.revoked[index] = true
+	for _, other := range m.members {
+		if !m.revoked[other.Index] {
+			other.ApplyRevocation(curve, rev)
+		}
+	}
+	m.Key = gpk
+	return rev, nil
+}
+
+func (gpk *GroupPublicKey) equals(other *GroupPublicKey) bool {
+	return other != nil && gpk.G1.Equals(other.G1) && gpk.H.Equals(other.H) && gpk.U.Equals(other.U) &&
+		gpk.V.Equals(other.V) && gpk.G2.Equals(other.G2) && gpk.W.Equals(other.W)
+}
+
+// ApplyRevocation checks the revocation, and returns the group key which
+// excludes the revoked member: G1 = A*, G2 = G2*, and W = gamma * G2*.
+func (gpk *GroupPublicKey) ApplyRevocation(curve CurveSystem, rev *Revocation) (*GroupPublicKey, bool) {
+	// A* is a member key, and G2* has the same discrete log to G2 as A* does to G1.
+	if !isMemberKey(curve, gpk, rev.A, rev.X) {
+		return nil, false
+	}
+	negA := rev.A.Mul(new(big.Int).Sub(curve.GetG1Order(), big.NewInt(1)))
+	paired, ok := curve.PairingProduct([]Point{negA, gpk.G1}, []Point{gpk.G2, rev.G2})
+	if !ok || !curve.GetGTIdentity().Equals(paired) {
+		return nil, false
+	}
+	// gamma * G2* = G2 - x* * G2*
+	w, _ := gpk.G2.Add(rev.G2.Mul(neg(rev.X, curve.GetG1Order())))
+	return &GroupPublicKey{rev.A.Copy(), gpk.H, gpk.U, gpk.V, rev.G2.Copy(), w}, true
+}
+
+// ApplyRevocations applies each revocation in the list to the group key in
+// turn. It returns false if any revocation is invalid.
+func (gpk *GroupPublicKey) ApplyRevocations(curve CurveSystem, rl []*Revocation) (*GroupPublicKey, bool) {
+	for _, rev := range rl {
+		var ok bool
+		if gpk, ok = gpk.ApplyRevocation(curve, rev); !ok {
+			return nil, false
+		}
+	}
+	return gpk, true
+}
+
+// ApplyRevocation updates the member key for a revocation, which must already
+// have been checked against the group key. The new key is
+// A = (x - x*)^-1 * (A* - A). It returns false if the key is the revoked one,
+// in which case it can't be updated.
+func (key *MemberKey) ApplyRevocation(curve CurveSystem, rev *Revocation) bool {
+	order := curve.GetG1Order()
+	diff := new(big.Int).Sub(key.X, rev.X)
+	if diff.Mod(diff, order).Sign() == 0 {
+		return false
+	}
+	a, _ := rev.A.Add(key.A.Mul(new(big.Int).Sub(order, big.NewInt(1))))
+	key.A = a.Mul(diff.ModInverse(diff, order))
+	return true
+}
+
+// ApplyRevocations applies each revocation in the list to the member key in
+// turn. It returns false if the member is revoked.
+func (key *MemberKey) ApplyRevocations(curve CurveSystem, rl []*Revocation) bool {
+	for _, rev := range rl {
+		if !key.ApplyRevocation(curve, rev) {
+			return false
+		}
+	}
+	return true
+}
+
+func challenge(curve CurveSystem, gpk *GroupPublicKey, msg []byte, sig *Signature,
+	R1, R2 Point, R3 PointT, R4, R5 Point) *big.Int {
+	h, _ := blake2b.New512(nil)
+	h.Write([]byte(challengeDomain))
+	fields := [][]byte{msg}
+	for _, pt := range []Point{gpk.G1, gpk.H, gpk.U, gpk.V, gpk.G2, gpk.W, sig.T1, sig.T2, sig.T3, R1, R2} {
+		fields = append(fields, pt.Marshal())
+	}
+	fields = append(fields, R3.Marshal(), R4.Marshal(), R5.Marshal())
+	for _, field := range fields {
+		h.Write(encoding.AppendLengthPrefixed(nil, field))
+	}
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, curve.GetG1Order())
+}
+
+// negSum returns -(a + b).
+func negSum(curve CurveSystem, a Point, b Point) Point {
+	sum, _ := a.Add(b)
+	return sum.Mul(new(big.Int).Sub(curve.GetG1Order(), big.NewInt(1)))
+}
+
+func neg(a *big.Int, order *big.Int) *big.Int {
+	n := new(big.Int).Neg(a)
+	return n.Mod(n, order)
+}
+
+func addMod(a *big.Int, b *big.Int, order *big.Int) *big.Int {
+	sum := new(big.Int).Add(a, b)
+	return sum.Mod(sum, order)
+}
+
+func mulMod(a *big.Int, b *big.Int, order *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Mod(product, order)
+}
+
+func randomNonzeroScalars(curve CurveSystem, n int) ([]*big.Int, error) {
+	scalars := make([]*big.Int, n)
+	for i := range scalars {
+		var err error
+		if scalars[i], err = encoding.RandomNonzeroScalar(curve); err != nil {
+			return nil, err
+		}
+	}
+	return scalars, nil
+}
+
+// Marshal serializes the signature.
+func (sig *Signature) Marshal(curve CurveSystem) []byte {
+	var buf []byte
+	for _, pt := range []Point{sig.T1, sig.T2, sig.T3} {
+		buf = encoding.AppendLengthPrefixed(buf, pt.Marshal())
+	}
+	for _, s := range []*big.Int{sig.C, sig.SAlpha, sig.SBeta, sig.SX, sig.SDelta1, sig.SDelta2} {
+		buf = encoding.AppendScalar(buf, curve, s)
+	}
+	return buf
+}
+
+// UnmarshalSignature deserializes a signature created with Marshal.
+func UnmarshalSignature(curve CurveSystem, data []byte) (*Signature, bool) {
+	r := encoding.NewReader(data)
+	sig := &Signature{T1: r.G1(curve), T2: r.G1(curve), T3: r.G1(curve)}
+	sig.C, sig.SAlpha, sig.SBeta = r.Scalar(curve), r.Scalar(curve), r.Scalar(curve)
+	sig.SX, sig.SDelta1, sig.SDelta2 = r.Scalar(curve), r.Scalar(curve), r.Scalar(curve)
+	if !r.Done() {
+		return nil, false
+	}
+	return sig, true
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package groupsig
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+func setupGroup(t *testing.T, curve CurveSystem, n int) (*Manager, []*MemberKey) {
+	m, err := Setup(curve)
+	assert.Nil(t, err)
+	keys := make([]*MemberKey, n)
+	for i := range keys {
+		keys[i], err = m.Issue(curve)
+		assert.Nil(t, err)
+		assert.True(t, VerifyMemberKey(curve, m.Key, keys[i]), "Issued member key failed verification")
+	}
+	return m, keys
+}
+
+func TestSignVerifyOpen(t *testing.T) {
+	msg := []byte("audit log entry 17")
+	for _, curve := range curves {
+		m, keys := setupGroup(t, curve, 3)
+		for i, key := range keys {
+			sig, err := Sign(curve, m.Key, key, msg)
+			assert.Nil(t, err)
+			assert.True(t, Verify(curve, m.Key, msg, sig), "Valid group signature failed verification")
+			assert.False(t, Verify(curve, m.Key, []byte("audit log entry 18"), sig), "Group signature verified on another message")
+			index, ok := m.Open(curve, m.Key, msg, sig)
+			assert.True(t, ok)
+			assert.Equal(t, i, index, "Signature opened to the wrong member")
+
+			unmarshalled, ok := UnmarshalSignature(curve, sig.Marshal(curve))
+			assert.True(t, ok)
+			assert.True(t, Verify(curve, m.Key, msg, unmarshalled))
+			_, ok = UnmarshalSignature(curve, append(sig.Marshal(curve), 0))
+			assert.False(t, ok, "Unmarshalled a signature with trailing data")
+		}
+
+		// Two signatures by one member share no values.
+		a, _ := Sign(curve, m.Key, keys[0], msg)
+		b, _ := Sign(curve, m.Key, keys[0], msg)
+		assert.False(t, a.T1.Equals(b.T1) || a.T2.Equals(b.T2) || a.T3.Equals(b.T3), "Signatures are linkable")
+
+		tampered := *a
+		tampered.SX = new(big.Int).Add(a.SX, big.NewInt(1))
+		assert.False(t, Verify(curve, m.Key, msg, &tampered), "Tampered group signature verified")
+		tampered = *a
+		tampered.T3, _ = a.T3.Add(m.Key.H)
+		assert.False(t, Verify(curve, m.Key, msg, &tampered), "Group signature with modified T3 verified")
+
+		// A key which the manager didn't issue can't sign.
+		forged := &MemberKey{7, curve.GetG1().Mul(big.NewInt(5)), big.NewInt(3)}
+		assert.False(t, VerifyMemberKey(curve, m.Key, forged))
+		sig, _ := Sign(curve, m.Key, forged, msg)
+		assert.False(t, Verify(curve, m.Key, msg, sig), "Signature with an unissued key verified")
+
+		other, _ := setupGroup(t, curve, 1)
+		assert.False(t, Verify(curve, other.Key, msg, a), "Group signature verified for another group")
+		_, ok := other.Open(curve, other.Key, msg, a)
+		assert.False(t, ok, "Another group's manager opened a signature")
+		_, ok = other.Open(curve, m.Key, msg, a)
+		assert.False(t, ok, "Another group's manager opened a signature")
+	}
+}
+
+func TestRevocation(t *testing.T) {
+	msg := []byte("audit log entry 17")
+	for _, curve := range curves {
+		m, keys := setupGroup(t, curve, 4)
+		original := m.Key
+		verifierKey := m.Key
+		before, _ := Sign(curve, m.Key, keys[1], msg)
+
+		var rl []*Revocation
+		for _, index := range []int{1, 3} {
+			rev, err := m.Revoke(curve, index)
+			assert.Nil(t, err)
+			rl = append(rl, rev)
+		}
+		_, err := m.Revoke(curve, 1)
+		assert.NotNil(t, err, "Revoked a member twice")
+
+		verifierKey, ok := verifierKey.ApplyRevocations(curve, rl)
+		assert.True(t, ok, "Valid revocation list failed to apply")
+		assert.True(t, verifierKey.G1.Equals(m.Key.G1) && verifierKey.W.Equals(m.Key.W))
+		assert.False(t, Verify(curve, verifierKey, msg, before), "Signature from before a revocation verified")
+
+		// The manager can still open signatures made under the old group key,
+		// including those of members who have since been revoked.
+		index, ok := m.Open(curve, original, msg, before)
+		assert.True(t, ok, "Signature from before a revocation couldn't be opened")
+		assert.Equal(t, 1, index, "Signature from before a revocation opened to the wrong member")
+		_, ok = m.Open(curve, m.Key, msg, before)
+		assert.False(t, ok, "Opened a signature under a group key it wasn't made with")
+
+		for i, key := range keys {
+			updated := key.ApplyRevocations(curve, rl)
+			if i == 1 || i == 3 {
+				assert.False(t, updated, "Revoked member updated their key")
+				sig, _ := Sign(curve, verifierKey, key, msg)
+				assert.False(t, Verify(curve, verifierKey, msg, sig), "Revoked member's signature verified")
+				continue
+			}
+			assert.True(t, updated)
+			assert.True(t, VerifyMemberKey(curve, verifierKey, key), "Updated member key failed verification")
+			sig, _ := Sign(curve, verifierKey, key, msg)
+			assert.True(t, Verify(curve, verifierKey, msg, sig), "Unrevoked member's signature failed verification")
+			index, ok := m.Open(curve, verifierKey, msg, sig)
+			assert.True(t, ok)
+			assert.Equal(t, i, index)
+		}
+
+		joined, _ := m.Issue(curve)
+		sig, _ := Sign(curve, verifierKey, joined, msg)
+		assert.True(t, Verify(curve, verifierKey, msg, sig), "Member who joined after a revocation failed to sign")
+
+		// The revocation of a real member key with the wrong G2* is rejected.
+		forged := &Revocation{joined.A, joined.X, m.Key.G2.Mul(big.NewInt(2))}
+		_, ok = m.Key.ApplyRevocation(curve, forged)
+		assert.False(t, ok, "Applied a forged revocation")
+	}
+}
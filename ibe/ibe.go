@@ -0,0 +1,197 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package ibe implements the Boneh-Franklin identity based encryption scheme,
+// in its CCA secure FullIdent form. Anyone can encrypt to an identity, such as
+// an email address, using only the master public key. The holder of the
+// master secret key extracts the decryption key for each identity.
+//
+// The master key pair is a bgls key pair, and the decryption key for an
+// identity is the BLS signature on it, d = s * H(id) on G1, so it can be
+// checked with bgls.VerifySingleSignature. To encrypt msg, pick a random
+// sigma, derive r = H3(sigma, msg), and send
+//
+//	U = r * g2, V = sigma xor H2(e(H(id), P)^r), W = msg xor H4(sigma)
+//
+// where P is the master public key. Since e(d, U) = e(H(id), P)^r, the key
+// holder recovers sigma and then msg. The Fujisaki-Okamoto transform makes
+// decryption recompute r and reject the ciphertext unless U = r * g2, so that
+// modified ciphertexts don't decrypt.
+//
+// H2, H3 and H4 are blake2b with separate domain tags, so W can be any length.
+package ibe
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// SigmaSize is the length of the random value sigma, in bytes.
+const SigmaSize = 32
+
+const (
+	h2Domain = "BGLS-IBE-H2"
+	h3Domain = "BGLS-IBE-H3"
+	h4Domain = "BGLS-IBE-H4"
+)
+
+// Params are the master public parameters of a key generation center.
+type Params struct {
+	// PublicKey is the master public key, on G2.
+	PublicKey Point
+}
+
+// Ciphertext is a message encrypted to an identity.
+type Ciphertext struct {
+	U Point
+	V []byte
+	W []byte
+}
+
+// Setup generates the master secret key and the public parameters.
+func Setup(curve CurveSystem) (*big.Int, *Params, error) {
+	sk, pk, err := bgls.KeyGen(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, &Params{pk}, nil
+}
+
+// Extract returns the decryption key for the identity, which is the BLS
+// signature on it under the master secret key.
+func Extract(curve CurveSystem, masterKey *big.Int, id []byte) Point {
+	return bgls.Sign(curve, masterKey, id)
+}
+
+// VerifyKey checks that key is the decryption key for the identity.
+func VerifyKey(curve CurveSystem, params *Params, id []byte, key Point) bool {
+	return bgls.VerifySingleSignature(curve, key, params.PublicKey, id)
+}
+
+// Encrypt encrypts msg to the identity.
+func Encrypt(curve CurveSystem, params *Params, id []byte, msg []byte) (*Ciphertext, error) {
+	return EncryptToPoint(curve, params, curve.HashToG1(id), msg)
+}
+
+// EncryptToPoint encrypts msg to the identity which hashes to q on G1. The
+// decryption key is the master secret key times q, which for a custom Hasher
+// is bgls.SignCustHash with that Hasher.
+func EncryptToPoint(curve CurveSystem, params *Params, q Point, msg []byte) (*Ciphertext, error) {
+	sigma := make([]byte, SigmaSize)
+	if _, err := rand.Read(sigma); err != nil {
+		return nil, err
+	}
+	w, err := h4(sigma, len(msg))
+	if err != nil {
+		return nil, err
+	}
+	r := h3(curve, sigma, msg)
+	paired, ok := curve.Pair(q.Mul(r), params.PublicKey)
+	if !ok {
+		return nil, errors.New("identity and public key aren't on G1 and G2")
+	}
+	return &Ciphertext{curve.GetG2().Mul(r), encoding.Xor(sigma, h2(paired)), encoding.Xor(msg, w)}, nil
+}
+
+// Decrypt decrypts the ciphertext with the identity's decryption key. It
+// returns false if the ciphertext wasn't encrypted to that identity, or was
+// modified.
+func Decrypt(curve CurveSystem, key Point, c *Ciphertext) ([]byte, bool) {
+	if len(c.V) != SigmaSize {
+		return nil, false
+	}
+	paired, ok := curve.Pair(key, c.U)
+	if !ok {
+		return nil, false
+	}
+	sigma := encoding.Xor(c.V, h2(paired))
+	w, err := h4(sigma, len(c.W))
+	if err != nil {
+		return nil, false
+	}
+	msg := encoding.Xor(c.W, w)
+	u := curve.GetG2().Mul(h3(curve, sigma, msg))
+	if subtle.ConstantTimeCompare(u.Marshal(), c.U.Marshal()) != 1 {
+		return nil, false
+	}
+	return msg, true
+}
+
+// h2 hashes a pairing result to SigmaSize bytes.
+func h2(gt PointT) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write([]byte(h2Domain))
+	h.Write(gt.Marshal())
+	return h.Sum(nil)
+}
+
+// h3 hashes sigma and the message to a scalar, with negligible bias.
+func h3(curve CurveSystem, sigma []byte, msg []byte) *big.Int {
+	h, _ := blake2b.New512(nil)
+	h.Write([]byte(h3Domain))
+	h.Write(sigma)
+	h.Write(msg)
+	r := new(big.Int).SetBytes(h.Sum(nil))
+	return r.Mod(r, curve.GetG1Order())
+}
+
+// h4 expands sigma to n bytes.
+func h4(sigma []byte, n int) ([]byte, error) {
+	xof, err := blake2b.NewXOF(blake2b.OutputLengthUnknown, nil)
+	if err != nil {
+		return nil, err
+	}
+	xof.Write([]byte(h4Domain))
+	xof.Write(sigma)
+	out := make([]byte, n)
+	if _, err := xof.Read(out); err != nil {
+		return nil, errors.New("message is too long")
+	}
+	return out, nil
+}
+
+// Marshal serializes the parameters, along with the name of their curve.
+func (p *Params) Marshal(curve CurveSystem) []byte {
+	buf := encoding.AppendLengthPrefixed(nil, []byte(curve.Name()))
+	return encoding.AppendLengthPrefixed(buf, p.PublicKey.Marshal())
+}
+
+// UnmarshalParams deserializes parameters created with Marshal. It returns
+// false if they are for a different curve.
+func UnmarshalParams(curve CurveSystem, data []byte) (*Params, bool) {
+	r := encoding.NewReader(data)
+	name := r.LengthPrefixed()
+	if !r.OK() || string(name) != curve.Name() {
+		return nil, false
+	}
+	pk := r.G2(curve)
+	if !r.Done() {
+		return nil, false
+	}
+	return &Params{pk}, true
+}
+
+// Marshal serializes the ciphertext.
+func (c *Ciphertext) Marshal() []byte {
+	buf := encoding.AppendLengthPrefixed(nil, c.U.Marshal())
+	buf = encoding.AppendLengthPrefixed(buf, c.V)
+	return encoding.AppendLengthPrefixed(buf, c.W)
+}
+
+// UnmarshalCiphertext deserializes a ciphertext created with Marshal.
+func UnmarshalCiphertext(curve CurveSystem, data []byte) (*Ciphertext, bool) {
+	r := encoding.NewReader(data)
+	c := &Ciphertext{U: r.G2(curve), V: r.LengthPrefixed(), W: r.LengthPrefixed()}
+	if !r.Done() || len(c.V) != SigmaSize {
+		return nil, false
+	}
+	return c, true
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package ibe
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+func TestEncryption(t *testing.T) {
+	for _, curve := range curves {
+		masterKey, params, err := Setup(curve)
+		assert.Nil(t, err)
+		alice, bob := []byte("alice@example.com"), []byte("bob@example.com")
+		aliceKey := Extract(curve, masterKey, alice)
+		bobKey := Extract(curve, masterKey, bob)
+		assert.True(t, VerifyKey(curve, params, alice, aliceKey), "Extracted key failed verification")
+		assert.False(t, VerifyKey(curve, params, bob, aliceKey), "Key verified for the wrong identity")
+		assert.True(t, bgls.VerifySingleSignature(curve, aliceKey, params.PublicKey, alice),
+			"Extracted key isn't a BLS signature on the identity")
+
+		for _, msg := range [][]byte{{}, []byte("hi"), make([]byte, 1000)} {
+			c, err := Encrypt(curve, params, alice, msg)
+			assert.Nil(t, err)
+			assert.Equal(t, len(msg), len(c.W))
+			decrypted, ok := Decrypt(curve, aliceKey, c)
+			assert.True(t, ok, "Valid ciphertext failed to decrypt")
+			assert.Equal(t, msg, decrypted)
+			_, ok = Decrypt(curve, bobKey, c)
+			assert.False(t, ok, "Decrypted with the wrong identity's key")
+		}
+
+		other, err := Encrypt(curve, params, alice, []byte("hi"))
+		assert.Nil(t, err)
+		again, _ := Encrypt(curve, params, alice, []byte("hi"))
+		assert.NotEqual(t, other.Marshal(), again.Marshal(), "Encryption is deterministic")
+	}
+}
+
+func TestCiphertextIntegrity(t *testing.T) {
+	for _, curve := range curves {
+		masterKey, params, _ := Setup(curve)
+		id := []byte("carol")
+		key := Extract(curve, masterKey, id)
+		c, _ := Encrypt(curve, params, id, []byte("attack at dawn"))
+
+		for _, field := range [][]byte{c.V, c.W} {
+			field[0] ^= 1
+			_, ok := Decrypt(curve, key, c)
+			assert.False(t, ok, "Modified ciphertext decrypted")
+			field[0] ^= 1
+		}
+		u := c.U
+		c.U = u.Mul(big.NewInt(2))
+		_, ok := Decrypt(curve, key, c)
+		assert.False(t, ok, "Ciphertext with a modified U decrypted")
+		c.U = u
+		c.V = c.V[1:]
+		_, ok = Decrypt(curve, key, c)
+		assert.False(t, ok, "Ciphertext with a short V decrypted")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	for _, curve := range curves {
+		masterKey, params, _ := Setup(curve)
+		unmarshalledParams, ok := UnmarshalParams(curve, params.Marshal(curve))
+		assert.True(t, ok)
+		assert.True(t, params.PublicKey.Equals(unmarshalledParams.PublicKey))
+		_, ok = UnmarshalParams(curve, append(params.Marshal(curve), 0))
+		assert.False(t, ok, "Unmarshalled parameters with trailing data")
+		for _, other := range curves {
+			if other.Name() != curve.Name() {
+				_, ok = UnmarshalParams(other, params.Marshal(curve))
+				assert.False(t, ok, "Unmarshalled parameters for the wrong curve")
+			}
+		}
+
+		id := []byte("dave")
+		c, _ := Encrypt(curve, unmarshalledParams, id, []byte("payload"))
+		unmarshalled, ok := UnmarshalCiphertext(curve, c.Marshal())
+		assert.True(t, ok)
+		msg, ok := Decrypt(curve, Extract(curve, masterKey, id), unmarshalled)
+		assert.True(t, ok, "Unmarshalled ciphertext failed to decrypt")
+		assert.Equal(t, []byte("payload"), msg)
+		_, ok = UnmarshalCiphertext(curve, c.Marshal()[1:])
+		assert.False(t, ok, "Unmarshalled a truncated ciphertext")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package encoding holds the serialization and randomness helpers shared by
+// the packages of this library. Fields are written as big endian uint32s,
+// fixed length scalars, and length prefixed byte strings.
+package encoding
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// AppendUint32 appends n as a big endian uint32.
+func AppendUint32(buf []byte, n uint32) []byte {
+	var encoded [4]byte
+	binary.BigEndian.PutUint32(encoded[:], n)
+	return append(buf, encoded[:]...)
+}
+
+// AppendLengthPrefixed appends the big endian uint32 length of b, followed by b.
+func AppendLengthPrefixed(buf []byte, b []byte) []byte {
+	return append(AppendUint32(buf, uint32(len(b))), b...)
+}
+
+// ScalarSize is the length of a serialized scalar.
+func ScalarSize(curve CurveSystem) int {
+	return (curve.GetG1Order().BitLen() + 7) / 8
+}
+
+// AppendScalar appends the scalar as a fixed length big endian integer.
+func AppendScalar(buf []byte, curve CurveSystem, s *big.Int) []byte {
+	encoded := make([]byte, ScalarSize(curve))
+	b := s.Bytes()
+	copy(encoded[len(encoded)-len(b):], b)
+	return append(buf, encoded...)
+}
+
+// Xor returns the bytewise xor of a and b, which must be at least as long as a.
+func Xor(a []byte, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// RandomNonzeroScalar returns a uniformly random scalar in [1, order).
+func RandomNonzeroScalar(curve CurveSystem) (*big.Int, error) {
+	for {
+		r, err := rand.Int(rand.Reader, curve.GetG1Order())
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() != 0 {
+			return r, nil
+		}
+	}
+}
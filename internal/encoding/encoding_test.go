@@ -0,0 +1,66 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package encoding
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+func TestReader(t *testing.T) {
+	for _, curve := range curves {
+		s := big.NewInt(7)
+		buf := AppendUint32(nil, 3)
+		buf = AppendLengthPrefixed(buf, []byte("msg"))
+		buf = AppendScalar(buf, curve, s)
+		buf = AppendLengthPrefixed(buf, curve.GetG1().Marshal())
+		buf = AppendLengthPrefixed(buf, curve.GetG2().Marshal())
+
+		r := NewReader(buf)
+		assert.Equal(t, uint32(3), r.Uint32())
+		assert.Equal(t, []byte("msg"), r.LengthPrefixed())
+		assert.Equal(t, 0, s.Cmp(r.Scalar(curve)))
+		assert.True(t, curve.GetG1().Equals(r.G1(curve)))
+		assert.True(t, curve.GetG2().Equals(r.G2(curve)))
+		assert.True(t, r.Done())
+
+		r = NewReader(append(buf, 0))
+		r.Uint32()
+		r.LengthPrefixed()
+		r.Scalar(curve)
+		r.G1(curve)
+		r.G2(curve)
+		assert.True(t, r.OK())
+		assert.False(t, r.Done(), "Reader accepted trailing data")
+
+		r = NewReader(buf[:len(buf)-1])
+		r.Uint32()
+		r.LengthPrefixed()
+		r.Scalar(curve)
+		r.G1(curve)
+		assert.Nil(t, r.G2(curve))
+		assert.False(t, r.OK(), "Reader accepted a truncated point")
+		assert.Equal(t, uint32(0), r.Uint32(), "Read succeeded after a failure")
+
+		r = NewReader(AppendScalar(nil, curve, curve.GetG1Order()))
+		r.Scalar(curve)
+		assert.False(t, r.OK(), "Reader accepted a scalar which isn't reduced")
+
+		r = NewReader(AppendUint32(nil, 1<<31))
+		assert.Nil(t, r.LengthPrefixed())
+		assert.False(t, r.OK(), "Reader accepted a length longer than the data")
+	}
+}
+
+func TestXor(t *testing.T) {
+	a := []byte{0x0f, 0xf0, 0xaa}
+	b := []byte{0xff, 0xff, 0xaa, 0x01}
+	assert.Equal(t, []byte{0xf0, 0x0f, 0x00}, Xor(a, b))
+	assert.Equal(t, a, Xor(Xor(a, b), b))
+}
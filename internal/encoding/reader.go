@@ -0,0 +1,112 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package encoding
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+// Reader reads the fields written by the append functions. Once a read fails,
+// OK returns false and all subsequent reads return zero values.
+type Reader struct {
+	data []byte
+	ok   bool
+}
+
+// NewReader returns a Reader for data.
+func NewReader(data []byte) *Reader {
+	return &Reader{data, true}
+}
+
+// OK reports whether every read so far succeeded.
+func (r *Reader) OK() bool {
+	return r.ok
+}
+
+// Len returns the number of unread bytes.
+func (r *Reader) Len() int {
+	return len(r.data)
+}
+
+// Done reports whether every read succeeded, and all of the data was read.
+func (r *Reader) Done() bool {
+	return r.ok && len(r.data) == 0
+}
+
+// Bytes returns a copy of the next n bytes.
+func (r *Reader) Bytes(n int) []byte {
+	if !r.ok || n < 0 || len(r.data) < n {
+		r.ok = false
+		return nil
+	}
+	b := append([]byte{}, r.data[:n]...)
+	r.data = r.data[n:]
+	return b
+}
+
+// Byte reads a single byte.
+func (r *Reader) Byte() byte {
+	b := r.Bytes(1)
+	if !r.ok {
+		return 0
+	}
+	return b[0]
+}
+
+// Uint32 reads a big endian uint32.
+func (r *Reader) Uint32() uint32 {
+	b := r.Bytes(4)
+	if !r.ok {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// LengthPrefixed returns a copy of the next length prefixed field.
+func (r *Reader) LengthPrefixed() []byte {
+	n := r.Uint32()
+	if !r.ok || uint32(len(r.data)) < n {
+		r.ok = false
+		return nil
+	}
+	return r.Bytes(int(n))
+}
+
+// Scalar reads a fixed length scalar, which must be less than the group order.
+func (r *Reader) Scalar(curve CurveSystem) *big.Int {
+	b := r.Bytes(ScalarSize(curve))
+	if !r.ok {
+		return nil
+	}
+	s := new(big.Int).SetBytes(b)
+	if s.Cmp(curve.GetG1Order()) >= 0 {
+		r.ok = false
+	}
+	return s
+}
+
+// G1 reads a length prefixed marshalled point on G1.
+func (r *Reader) G1(curve CurveSystem) Point {
+	b := r.LengthPrefixed()
+	if !r.ok {
+		return nil
+	}
+	pt, ok := curve.UnmarshalG1(b)
+	r.ok = ok
+	return pt
+}
+
+// G2 reads a length prefixed marshalled point on G2.
+func (r *Reader) G2(curve CurveSystem) Point {
+	b := r.LengthPrefixed()
+	if !r.ok {
+		return nil
+	}
+	pt, ok := curve.UnmarshalG2(b)
+	r.ok = ok
+	return pt
+}
@@ -0,0 +1,457 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package sigma implements non interactive zero knowledge proofs of knowledge
+// of discrete logs in G1, G2 and GT, as Schnorr style Sigma protocols made non
+// interactive with the Fiat-Shamir heuristic.
+//
+// A Relation is a set of linear equations
+//
+//	Y_k = x_{w(k,1)} * G_{k,1} + ... + x_{w(k,n)} * G_{k,n}
+//
+// between public elements, all in the same group per equation, and secret
+// witnesses x_j, which may appear in several equations. This covers knowledge
+// of a discrete log (Y = x * G), equality of discrete logs (Y = x * G and
+// Z = x * H), Pedersen commitments (C = m * G + r * H), and conjunctions of
+// them. In GT, which is written multiplicatively elsewhere, Y = x * G means
+// Y = G^x.
+//
+// The prover sends commitments T_k = sum k_w * G_k for random k, gets the
+// challenge c from a Transcript, and responds with z_j = k_j + c * x_j. The
+// verifier checks sum z_w * G_k = T_k + c * Y_k for every equation. Since
+// proofs carry their commitments, many proofs can be checked together with a
+// random linear combination of all their equations.
+package sigma
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Group identifies which group an Element is in.
+type Group int
+
+// The groups of a pairing.
+const (
+	G1 Group = iota
+	G2
+	GT
+)
+
+// Element is an element of G1, G2 or GT.
+type Element struct {
+	group  Group
+	point  Point
+	pointT PointT
+}
+
+// Term is one summand x_Witness * Base of an equation.
+type Term struct {
+	Witness int
+	Base    Element
+}
+
+// Equation states that Public is the sum of its terms.
+type Equation struct {
+	Public Element
+	Terms  []Term
+}
+
+// Relation is a statement made of linear equations in Witnesses secret scalars.
+type Relation struct {
+	Witnesses int
+	Equations []Equation
+}
+
+// Proof is a proof of knowledge of witnesses satisfying a relation.
+type Proof struct {
+	Commitments []Element
+	Responses   []*big.Int
+}
+
+// G1Element wraps a point on G1.
+func G1Element(p Point) Element {
+	return Element{group: G1, point: p}
+}
+
+// G2Element wraps a point on G2.
+func G2Element(p Point) Element {
+	return Element{group: G2, point: p}
+}
+
+// GTElement wraps an element of GT.
+func GTElement(p PointT) Element {
+	return Element{group: GT, pointT: p}
+}
+
+// Group returns the group the element is in.
+func (e Element) Group() Group {
+	return e.group
+}
+
+// Point returns the element as a point on G1 or G2, or nil if it's in GT.
+func (e Element) Point() Point {
+	return e.point
+}
+
+// PointT returns the element as an element of GT, or nil if it isn't in GT.
+func (e Element) PointT() PointT {
+	return e.pointT
+}
+
+// Marshal serializes the element.
+func (e Element) Marshal() []byte {
+	if e.group == GT {
+		return e.pointT.Marshal()
+	}
+	return e.point.Marshal()
+}
+
+func (e Element) mul(s *big.Int) Element {
+	if e.group == GT {
+		return Element{group: GT, pointT: e.pointT.Mul(s)}
+	}
+	return Element{group: e.group, point: e.point.Mul(s)}
+}
+
+func (e Element) add(other Element) (Element, bool) {
+	if e.group != other.group {
+		return Element{}, false
+	}
+	if e.group == GT {
+		sum, ok := e.pointT.Add(other.pointT)
+		return Element{group: GT, pointT: sum}, ok
+	}
+	sum, ok := e.point.Add(other.point)
+	return Element{group: e.group, point: sum}, ok
+}
+
+func (e Element) equals(other Element) bool {
+	if e.group != other.group {
+		return false
+	}
+	if e.group == GT {
+		return e.pointT.Equals(other.pointT)
+	}
+	return e.point.Equals(other.point)
+}
+
+func (e Element) valid() bool {
+	switch e.group {
+	case G1, G2:
+		return e.point != nil
+	case GT:
+		return e.pointT != nil
+	}
+	return false
+}
+
+// inSubgroup reports whether a GT element is in the order r subgroup, i.e.
+// T^r = 1. UnmarshalGT only checks that an element is in F_p12, so commitments
+// outside the subgroup have to be rejected explicitly.
+func (e Element) inSubgroup(curve CurveSystem) bool {
+	if e.group != GT {
+		return true
+	}
+	return e.pointT.Mul(curve.GetG1Order()).Equals(curve.GetGTIdentity())
+}
+
+func identity(curve CurveSystem, group Group) Element {
+	switch group {
+	case G1:
+		return G1Element(curve.GetG1Infinity())
+	case G2:
+		return G2Element(curve.GetG2Infinity())
+	}
+	return GTElement(curve.GetGTIdentity())
+}
+
+func unmarshalElement(curve CurveSystem, group Group, data []byte) (Element, bool) {
+	data = append([]byte{}, data...)
+	switch group {
+	case G1:
+		p, ok := curve.UnmarshalG1(data)
+		return G1Element(p), ok
+	case G2:
+		p, ok := curve.UnmarshalG2(data)
+		return G2Element(p), ok
+	}
+	p, ok := curve.UnmarshalGT(data)
+	return GTElement(p), ok
+}
+
+// elementSize is the length of a marshalled element of the group.
+func elementSize(curve CurveSystem, group Group) int {
+	switch group {
+	case G1:
+		return len(curve.GetG1().Marshal())
+	case G2:
+		return len(curve.GetG2().Marshal())
+	}
+	return len(curve.GetGT().Marshal())
+}
+
+// NewRelation returns an empty relation in the given number of witnesses.
+func NewRelation(witnesses int) *Relation {
+	return &Relation{Witnesses: witnesses}
+}
+
+// AddEquation adds the equation public = sum of the terms to the relation.
+func (r *Relation) AddEquation(public Element, terms ...Term) *Relation {
+	r.Equations = append(r.Equations, Equation{public, terms})
+	return r
+}
+
+// DLog is the relation Y = x * G, i.e. knowledge of the discrete log of Y.
+func DLog(Y Element, G Element) *Relation {
+	return NewRelation(1).AddEquation(Y, Term{0, G})
+}
+
+// DLEQ is the relation Y = x * G and Z = x * H, i.e. that Y and Z have the same
+// discrete log with respect to G and H. The groups may differ.
+func DLEQ(Y Element, G Element, Z Element, H Element) *Relation {
+	return NewRelation(1).AddEquation(Y, Term{0, G}).AddEquation(Z, Term{0, H})
+}
+
+// And is the conjunction of the relations, with independent witnesses. Its
+// witnesses are those of the first relation, followed by those of the second,
+// and so on. Witnesses shared between statements are instead expressed as
+// multiple equations of one relation.
+func And(relations ...*Relation) *Relation {
+	and := &Relation{}
+	for _, r := range relations {
+		for _, eq := range r.Equations {
+			terms := make([]Term, len(eq.Terms))
+			for i, term := range eq.Terms {
+				terms[i] = Term{term.Witness + and.Witnesses, term.Base}
+			}
+			and.Equations = append(and.Equations, Equation{eq.Public, terms})
+		}
+		and.Witnesses += r.Witnesses
+	}
+	return and
+}
+
+// wellFormed checks that every equation is in one group, and only refers to
+// the relation's witnesses.
+func (r *Relation) wellFormed() bool {
+	if r.Witnesses < 1 || len(r.Equations) == 0 {
+		return false
+	}
+	for _, eq := range r.Equations {
+		if !eq.Public.valid() || len(eq.Terms) == 0 {
+			return false
+		}
+		for _, term := range eq.Terms {
+			if term.Witness < 0 || term.Witness >= r.Witnesses ||
+				!term.Base.valid() || term.Base.group != eq.Public.group {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// evaluate returns the sum of the equation's terms for the scalars.
+func (eq Equation) evaluate(curve CurveSystem, scalars []*big.Int) Element {
+	sum := identity(curve, eq.Public.group)
+	for _, term := range eq.Terms {
+		sum, _ = sum.add(term.Base.mul(scalars[term.Witness]))
+	}
+	return sum
+}
+
+// appendTo appends the relation's structure and public elements to the transcript.
+func (r *Relation) appendTo(t *Transcript) {
+	buf := encoding.AppendUint32(nil, uint32(r.Witnesses))
+	buf = encoding.AppendUint32(buf, uint32(len(r.Equations)))
+	for _, eq := range r.Equations {
+		buf = encoding.AppendUint32(buf, uint32(eq.Public.group))
+		buf = encoding.AppendLengthPrefixed(buf, eq.Public.Marshal())
+		buf = encoding.AppendUint32(buf, uint32(len(eq.Terms)))
+		for _, term := range eq.Terms {
+			buf = encoding.AppendUint32(buf, uint32(term.Witness))
+			buf = encoding.AppendLengthPrefixed(buf, term.Base.Marshal())
+		}
+	}
+	t.Append("relation", buf)
+}
+
+func appendCommitments(t *Transcript, commitments []Element) {
+	var buf []byte
+	for _, T := range commitments {
+		buf = encoding.AppendLengthPrefixed(buf, T.Marshal())
+	}
+	t.Append("commitments", buf)
+}
+
+// Prove proves knowledge of witnesses satisfying the relation. The relation
+// and the commitments are appended to the transcript, which the verifier must
+// have in the same state as the prover's was.
+func Prove(curve CurveSystem, r *Relation, witnesses []*big.Int, t *Transcript) (*Proof, error) {
+	if !r.wellFormed() || len(witnesses) != r.Witnesses {
+		return nil, errors.New("malformed relation")
+	}
+	for _, eq := range r.Equations {
+		if !eq.evaluate(curve, witnesses).equals(eq.Public) {
+			return nil, errors.New("witnesses don't satisfy the relation")
+		}
+	}
+	order := curve.GetG1Order()
+	nonces := make([]*big.Int, r.Witnesses)
+	for i := range nonces {
+		k, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, err
+		}
+		nonces[i] = k
+	}
+	proof := &Proof{Commitments: make([]Element, len(r.Equations)), Responses: make([]*big.Int, r.Witnesses)}
+	for i, eq := range r.Equations {
+		proof.Commitments[i] = eq.evaluate(curve, nonces)
+	}
+	r.appendTo(t)
+	appendCommitments(t, proof.Commitments)
+	c := t.Challenge(curve, "challenge")
+	for i, x := range witnesses {
+		z := new(big.Int).Mul(c, x)
+		z.Add(z, nonces[i])
+		proof.Responses[i] = z.Mod(z, order)
+	}
+	return proof, nil
+}
+
+// Verify checks a proof of knowledge of witnesses satisfying the relation,
+// against a transcript in the state the prover's was in.
+func Verify(curve CurveSystem, r *Relation, proof *Proof, t *Transcript) bool {
+	c, ok := challenge(curve, r, proof, t)
+	if !ok {
+		return false
+	}
+	for i, eq := range r.Equations {
+		expected, _ := proof.Commitments[i].add(eq.Public.mul(c))
+		if !eq.evaluate(curve, proof.Responses).equals(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// challenge checks the proof's shape, and derives its challenge.
+func challenge(curve CurveSystem, r *Relation, proof *Proof, t *Transcript) (*big.Int, bool) {
+	if !r.wellFormed() || len(proof.Commitments) != len(r.Equations) || len(proof.Responses) != r.Witnesses {
+		return nil, false
+	}
+	for i, T := range proof.Commitments {
+		if !T.valid() || T.group != r.Equations[i].Public.group || !T.inSubgroup(curve) {
+			return nil, false
+		}
+	}
+	for _, z := range proof.Responses {
+		if z == nil || z.Sign() < 0 || z.Cmp(curve.GetG1Order()) >= 0 {
+			return nil, false
+		}
+	}
+	r.appendTo(t)
+	appendCommitments(t, proof.Commitments)
+	return t.Challenge(curve, "challenge"), true
+}
+
+// BatchVerify checks many proofs at once, each against its own relation and
+// transcript. Every equation sum z_w * G - T - c * Y = 0 is multiplied by a
+// random 128 bit scalar, and the results are summed per group, so an invalid
+// proof is only accepted with probability about 2^-128. It returns false if
+// any proof is invalid, without saying which.
+func BatchVerify(curve CurveSystem, relations []*Relation, proofs []*Proof, transcripts []*Transcript) bool {
+	if len(relations) != len(proofs) || len(proofs) != len(transcripts) {
+		return false
+	}
+	order := curve.GetG1Order()
+	bound := new(big.Int).Lsh(big.NewInt(1), 128)
+	sums := make(map[Group]Element)
+	for i, r := range relations {
+		c, ok := challenge(curve, r, proofs[i], transcripts[i])
+		if !ok {
+			return false
+		}
+		for k, eq := range r.Equations {
+			rho, err := rand.Int(rand.Reader, bound)
+			if err != nil {
+				return false
+			}
+			// rho * (sum z_w * G) - rho * T - rho * c * Y
+			scaled := make([]*big.Int, len(proofs[i].Responses))
+			for j, z := range proofs[i].Responses {
+				scaled[j] = new(big.Int).Mul(rho, z)
+				scaled[j].Mod(scaled[j], order)
+			}
+			negRho := new(big.Int).Sub(order, rho)
+			negRhoC := new(big.Int).Mul(negRho, c)
+			negRhoC.Mod(negRhoC, order)
+
+			sum, ok := sums[eq.Public.group]
+			if !ok {
+				sum = identity(curve, eq.Public.group)
+			}
+			sum, _ = sum.add(eq.evaluate(curve, scaled))
+			sum, _ = sum.add(proofs[i].Commitments[k].mul(negRho))
+			sum, _ = sum.add(eq.Public.mul(negRhoC))
+			sums[eq.Public.group] = sum
+		}
+	}
+	for group, sum := range sums {
+		if !sum.equals(identity(curve, group)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal serializes the proof compactly, as its commitments followed by its
+// responses, each of fixed length. The number and groups of the commitments,
+// and the number of responses, come from the relation when unmarshalling.
+func (proof *Proof) Marshal(curve CurveSystem) []byte {
+	var buf []byte
+	for _, T := range proof.Commitments {
+		buf = append(buf, T.Marshal()...)
+	}
+	for _, z := range proof.Responses {
+		buf = encoding.AppendScalar(buf, curve, z)
+	}
+	return buf
+}
+
+// UnmarshalProof deserializes a proof for the relation created with Marshal.
+func UnmarshalProof(curve CurveSystem, r *Relation, data []byte) (*Proof, bool) {
+	if !r.wellFormed() {
+		return nil, false
+	}
+	proof := &Proof{Commitments: make([]Element, len(r.Equations)), Responses: make([]*big.Int, r.Witnesses)}
+	for i, eq := range r.Equations {
+		n := elementSize(curve, eq.Public.group)
+		if len(data) < n {
+			return nil, false
+		}
+		T, ok := unmarshalElement(curve, eq.Public.group, data[:n])
+		if !ok || !T.inSubgroup(curve) {
+			return nil, false
+		}
+		proof.Commitments[i], data = T, data[n:]
+	}
+	size := encoding.ScalarSize(curve)
+	if len(data) != size*r.Witnesses {
+		return nil, false
+	}
+	for i := range proof.Responses {
+		z := new(big.Int).SetBytes(data[i*size : (i+1)*size])
+		if z.Cmp(curve.GetG1Order()) >= 0 {
+			return nil, false
+		}
+		proof.Responses[i] = z
+	}
+	return proof, true
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package sigma
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+func randomScalar(curve CurveSystem) *big.Int {
+	x, _ := rand.Int(rand.Reader, curve.GetG1Order())
+	return x
+}
+
+// statements returns a relation in each group, and a witness for it.
+func statements(curve CurveSystem) ([]*Relation, [][]*big.Int) {
+	x, y, m, r := randomScalar(curve), randomScalar(curve), randomScalar(curve), randomScalar(curve)
+	g1, g2, gt := curve.GetG1(), curve.GetG2(), curve.GetGT()
+	h := curve.HashToG1([]byte("pedersen H"))
+	pedersen, _ := g1.Mul(m).Add(h.Mul(r))
+	relations := []*Relation{
+		DLog(G1Element(g1.Mul(x)), G1Element(g1)),
+		DLog(G2Element(g2.Mul(x)), G2Element(g2)),
+		DLog(GTElement(gt.Mul(x)), GTElement(gt)),
+		DLEQ(G1Element(g1.Mul(y)), G1Element(g1), G2Element(g2.Mul(y)), G2Element(g2)),
+		NewRelation(2).AddEquation(G1Element(pedersen), Term{0, G1Element(g1)}, Term{1, G1Element(h)}),
+	}
+	witnesses := [][]*big.Int{{x}, {x}, {x}, {y}, {m, r}}
+	return relations, witnesses
+}
+
+func TestProveVerify(t *testing.T) {
+	for _, curve := range curves {
+		relations, witnesses := statements(curve)
+		for i, r := range relations {
+			proof, err := Prove(curve, r, witnesses[i], NewTranscript("test"))
+			assert.Nil(t, err)
+			assert.True(t, Verify(curve, r, proof, NewTranscript("test")), "Valid proof failed verification")
+			assert.False(t, Verify(curve, r, proof, NewTranscript("other protocol")),
+				"Proof verified with another protocol label")
+
+			unmarshalled, ok := UnmarshalProof(curve, r, proof.Marshal(curve))
+			assert.True(t, ok)
+			assert.True(t, Verify(curve, r, unmarshalled, NewTranscript("test")))
+			_, ok = UnmarshalProof(curve, r, append(proof.Marshal(curve), 0))
+			assert.False(t, ok, "Unmarshalled a proof with trailing data")
+
+			tampered := &Proof{proof.Commitments, append([]*big.Int{}, proof.Responses...)}
+			tampered.Responses[0] = new(big.Int).Add(proof.Responses[0], big.NewInt(1))
+			tampered.Responses[0].Mod(tampered.Responses[0], curve.GetG1Order())
+			assert.False(t, Verify(curve, r, tampered, NewTranscript("test")), "Tampered proof verified")
+		}
+
+		_, err := Prove(curve, relations[0], []*big.Int{big.NewInt(5)}, NewTranscript("test"))
+		assert.NotNil(t, err, "Proved a relation with a wrong witness")
+		// A DLEQ proof with different discrete logs can't be made.
+		g1, g2 := curve.GetG1(), curve.GetG2()
+		unequal := DLEQ(G1Element(g1.Mul(big.NewInt(2))), G1Element(g1), G2Element(g2.Mul(big.NewInt(3))), G2Element(g2))
+		_, err = Prove(curve, unequal, []*big.Int{big.NewInt(2)}, NewTranscript("test"))
+		assert.NotNil(t, err, "Proved equality of unequal discrete logs")
+		mixed := NewRelation(1).AddEquation(G1Element(g1), Term{0, G2Element(g2)})
+		_, err = Prove(curve, mixed, []*big.Int{big.NewInt(1)}, NewTranscript("test"))
+		assert.NotNil(t, err, "Proved a relation mixing groups")
+	}
+}
+
+// gtMinusOne returns -1 in F_p12, which has order 2, so it isn't in GT.
+func gtMinusOne(curve CurveSystem) PointT {
+	data := curve.GetGTIdentity().Marshal()
+	n := len(data) / 12
+	for i := 0; i < len(data); i += n {
+		if new(big.Int).SetBytes(data[i:i+n]).Cmp(big.NewInt(1)) == 0 {
+			b := new(big.Int).Sub(curve.GetG1Q(), big.NewInt(1)).Bytes()
+			copy(data[i+n-len(b):i+n], b)
+		}
+	}
+	minusOne, _ := curve.UnmarshalGT(data)
+	return minusOne
+}
+
+func TestGTSubgroup(t *testing.T) {
+	for _, curve := range curves {
+		gt := curve.GetGT()
+		r := DLog(GTElement(gt.Mul(big.NewInt(7))), GTElement(gt))
+		proof, err := Prove(curve, r, []*big.Int{big.NewInt(7)}, NewTranscript("test"))
+		assert.Nil(t, err)
+
+		minusOne := gtMinusOne(curve)
+		assert.NotNil(t, minusOne)
+		squared, _ := minusOne.Add(minusOne)
+		assert.True(t, squared.Equals(curve.GetGTIdentity()), "-1 doesn't have order 2")
+
+		T, _ := proof.Commitments[0].PointT().Add(minusOne)
+		forged := &Proof{[]Element{GTElement(T)}, proof.Responses}
+		_, ok := UnmarshalProof(curve, r, forged.Marshal(curve))
+		assert.False(t, ok, "Unmarshalled a commitment outside GT")
+		assert.False(t, Verify(curve, r, forged, NewTranscript("test")), "Proof with a commitment outside GT verified")
+		assert.False(t, BatchVerify(curve, []*Relation{r}, []*Proof{forged}, []*Transcript{NewTranscript("test")}),
+			"Batch with a commitment outside GT verified")
+	}
+}
+
+func TestTranscriptBinding(t *testing.T) {
+	for _, curve := range curves {
+		relations, witnesses := statements(curve)
+		prover := NewTranscript("test")
+		prover.Append("context", []byte("session 1"))
+		proof, _ := Prove(curve, relations[0], witnesses[0], prover)
+
+		verifier := NewTranscript("test")
+		verifier.Append("context", []byte("session 1"))
+		assert.True(t, Verify(curve, relations[0], proof, verifier.Clone()))
+		replayed := NewTranscript("test")
+		replayed.Append("context", []byte("session 2"))
+		assert.False(t, Verify(curve, relations[0], proof, replayed), "Proof verified in another context")
+
+		// The proof is bound to the statement, not only to the witness.
+		other := DLog(G1Element(curve.GetG1().Mul(big.NewInt(7))), G1Element(curve.GetG1()))
+		assert.False(t, Verify(curve, other, proof, verifier.Clone()), "Proof verified for another statement")
+
+		a, b := verifier.Clone(), verifier.Clone()
+		assert.Equal(t, a.Challenge(curve, "c"), b.Challenge(curve, "c"))
+		assert.NotEqual(t, a.Challenge(curve, "c"), verifier.Challenge(curve, "d"))
+	}
+}
+
+func TestAnd(t *testing.T) {
+	for _, curve := range curves {
+		relations, witnesses := statements(curve)
+		and := And(relations...)
+		var all []*big.Int
+		for _, w := range witnesses {
+			all = append(all, w...)
+		}
+		assert.Equal(t, len(all), and.Witnesses)
+		proof, err := Prove(curve, and, all, NewTranscript("and"))
+		assert.Nil(t, err)
+		assert.True(t, Verify(curve, and, proof, NewTranscript("and")), "Valid AND proof failed verification")
+
+		// Knowing the witnesses of all but one statement isn't enough.
+		all[len(all)-1] = big.NewInt(1)
+		_, err = Prove(curve, and, all, NewTranscript("and"))
+		assert.NotNil(t, err, "Proved an AND with one wrong witness")
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	for _, curve := range curves {
+		relations, witnesses := statements(curve)
+		proofs := make([]*Proof, len(relations))
+		transcripts := func() []*Transcript {
+			ts := make([]*Transcript, len(relations))
+			for i := range ts {
+				ts[i] = NewTranscript("batch")
+			}
+			return ts
+		}
+		for i, r := range relations {
+			proofs[i], _ = Prove(curve, r, witnesses[i], NewTranscript("batch"))
+		}
+		assert.True(t, BatchVerify(curve, relations, proofs, transcripts()), "Valid batch failed verification")
+
+		// A proof from another transcript is valid on its own, but not here.
+		replayed := append([]*Proof{}, proofs...)
+		replayed[2], _ = Prove(curve, relations[2], witnesses[2], NewTranscript("other"))
+		assert.False(t, BatchVerify(curve, relations, replayed, transcripts()), "Batch with a replayed proof verified")
+
+		bad := append([]*Proof{}, proofs...)
+		bad[4] = &Proof{[]Element{G1Element(curve.GetG1())}, proofs[4].Responses}
+		assert.False(t, BatchVerify(curve, relations, bad, transcripts()), "Batch with one invalid proof verified")
+		assert.False(t, BatchVerify(curve, relations, proofs[1:], transcripts()), "Batch with a missing proof verified")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package sigma
+
+import (
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+// Transcript accumulates the messages of a protocol, and derives Fiat-Shamir
+// challenges from everything appended so far. The prover and verifier must
+// append the same messages, in the same order, for a proof to verify.
+type Transcript struct {
+	data []byte
+}
+
+// NewTranscript returns a transcript for the protocol with the given label,
+// which separates its challenges from those of other protocols.
+func NewTranscript(label string) *Transcript {
+	t := &Transcript{}
+	t.Append("protocol", []byte(label))
+	return t
+}
+
+// Append adds a labelled message to the transcript, such as a public input or
+// a context string the proof should be bound to.
+func (t *Transcript) Append(label string, msg []byte) {
+	t.data = encoding.AppendLengthPrefixed(t.data, []byte(label))
+	t.data = encoding.AppendLengthPrefixed(t.data, msg)
+}
+
+// Challenge derives a scalar from the transcript, and appends it, so that
+// later challenges depend on it.
+func (t *Transcript) Challenge(curve CurveSystem, label string) *big.Int {
+	t.data = encoding.AppendLengthPrefixed(t.data, []byte(label))
+	digest := blake2b.Sum512(t.data)
+	c := new(big.Int).SetBytes(digest[:])
+	c.Mod(c, curve.GetG1Order())
+	t.Append("challenge", c.Bytes())
+	return c
+}
+
+// Clone returns an independent copy of the transcript.
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{append([]byte{}, t.data...)}
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package tdh2 implements chosen ciphertext secure threshold public key
+// encryption, in a pairing based variant of Shoup and Gennaro's TDH2. A
+// message is encrypted to a committee's group key, and is only decrypted once
+// t members publish decryption shares for it, e.g. after a transaction's
+// position in a block has been fixed.
+//
+// The committee's key is shared exactly as for threshold BLS signatures, with
+// bgls.ThresholdKeyGen or the dkg package, so the group key is s * g2 and
+// member i holds the share s_i. To encrypt msg with a label L, pick a random
+// r, and send
+//
+//	U = r * g2, W = msg xor KDF(e(g1, s * g2)^r), L, Ubar = r * H(U, W, L)
+//
+// where H hashes onto G1. Ubar proves that the sender knows r, and binds it
+// to W and L, so anyone can check that a ciphertext is valid with
+// e(Ubar, g2) = e(H(U, W, L), U). Members only create decryption shares for
+// valid ciphertexts, so a modified ciphertext can't be used to get shares
+// which decrypt the original.
+//
+// Member i's decryption share is D_i = s_i * U. Since the ciphertext is valid,
+// e(H, D_i) = e(Ubar, s_i * g2), which is checked against the share's public
+// key from the verification vector. Any t valid shares are interpolated into
+// s * U, and e(g1, s * U) is the encryption key.
+package tdh2
+
+import (
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/internal/encoding"
+)
+
+const (
+	hashDomain = "BGLS-TDH2-H"
+	kdfDomain  = "BGLS-TDH2-KDF"
+)
+
+// Ciphertext is a message encrypted to a committee.
+type Ciphertext struct {
+	U     Point
+	W     []byte
+	Label []byte
+	Ubar  Point
+}
+
+// DecryptionShare is a committee member's share of the decryption of a
+// ciphertext. Index is the index of the member's secret share.
+type DecryptionShare struct {
+	Index int
+	Share Point
+}
+
+// Encrypt encrypts msg to the group key, along with a public label which is
+// bound to the ciphertext, such as the sender's address.
+func Encrypt(curve CurveSystem, groupKey Point, msg []byte, label []byte) (*Ciphertext, error) {
+	r, err := encoding.RandomNonzeroScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	paired, ok := curve.Pair(curve.GetG1(), groupKey.Mul(r))
+	if !ok {
+		return nil, errors.New("group key isn't on G2")
+	}
+	mask, err := kdf(paired, len(msg))
+	if err != nil {
+		return nil, err
+	}
+	c := &Ciphertext{U: curve.GetG2().Mul(r), W: encoding.Xor(msg, mask), Label: append([]byte{}, label...)}
+	c.Ubar = c.hash(curve).Mul(r)
+	return c, nil
+}
+
+// Verify checks that the ciphertext is valid, i.e. that its sender knew the
+// randomness it was encrypted with, and that it hasn't been modified.
+func (c *Ciphertext) Verify(curve CurveSystem) bool {
+	if c.U.Equals(curve.GetG2Infinity()) {
+		return false
+	}
+	negUbar := c.Ubar.Mul(new(big.Int).SetInt64(-1))
+	paired, _ := curve.PairingProduct([]Point{c.hash(curve), negUbar}, []Point{c.U, curve.GetG2()})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// hash returns H(U, W, L) on G1.
+func (c *Ciphertext) hash(curve CurveSystem) Point {
+	buf := encoding.AppendLengthPrefixed([]byte(hashDomain), c.U.Marshal())
+	buf = encoding.AppendLengthPrefixed(buf, c.W)
+	return curve.HashToG1(encoding.AppendLengthPrefixed(buf, c.Label))
+}
+
+// CreateDecryptionShare returns the member's decryption share for the
+// ciphertext. It returns false if the ciphertext isn't valid, in which case
+// no share may be released.
+func CreateDecryptionShare(curve CurveSystem, share bgls.SecretShare, c *Ciphertext) (*DecryptionShare, bool) {
+	if !c.Verify(curve) {
+		return nil, false
+	}
+	return &DecryptionShare{share.Index, c.U.Mul(share.Value)}, true
+}
+
+// VerifyDecryptionShare checks that a decryption share for a valid ciphertext
+// is from the secret share with its index, against the verification vector.
+func VerifyDecryptionShare(curve CurveSystem, vvec []Point, c *Ciphertext, ds *DecryptionShare) bool {
+	if ds.Index < 1 || len(vvec) == 0 {
+		return false
+	}
+	negShare := ds.Share.Mul(new(big.Int).SetInt64(-1))
+	pubKey := bgls.GetSharePublicKey(curve, vvec, ds.Index)
+	paired, _ := curve.PairingProduct([]Point{c.Ubar, c.hash(curve)}, []Point{pubKey, negShare})
+	return curve.GetGTIdentity().Equals(paired)
+}
+
+// Combine decrypts the ciphertext from the decryption shares. The threshold t
+// is the length of the verification vector. Invalid shares, and shares from a
+// repeated index, are skipped, and it returns false if fewer than t valid
+// shares remain, or the ciphertext isn't valid.
+func Combine(curve CurveSystem, vvec []Point, c *Ciphertext, shares []*DecryptionShare) ([]byte, bool) {
+	if !c.Verify(curve) {
+		return nil, false
+	}
+	t := len(vvec)
+	points := make([]Point, 0, t)
+	indices := make([]int, 0, t)
+	seen := make(map[int]bool)
+	for _, ds := range shares {
+		if len(indices) == t {
+			break
+		}
+		if seen[ds.Index] || !VerifyDecryptionShare(curve, vvec, c, ds) {
+			continue
+		}
+		seen[ds.Index] = true
+		points = append(points, ds.Share)
+		indices = append(indices, ds.Index)
+	}
+	if t == 0 || len(indices) < t {
+		return nil, false
+	}
+	sU, ok := bgls.ThresholdCombineSignatures(curve, points, indices)
+	if !ok {
+		return nil, false
+	}
+	paired, _ := curve.Pair(curve.GetG1(), sU)
+	mask, err := kdf(paired, len(c.W))
+	if err != nil {
+		return nil, false
+	}
+	return encoding.Xor(c.W, mask), true
+}
+
+// kdf expands a pairing result into n bytes.
+func kdf(gt PointT, n int) ([]byte, error) {
+	xof, err := blake2b.NewXOF(blake2b.OutputLengthUnknown, nil)
+	if err != nil {
+		return nil, err
+	}
+	xof.Write([]byte(kdfDomain))
+	xof.Write(gt.Marshal())
+	out := make([]byte, n)
+	if _, err := xof.Read(out); err != nil {
+		return nil, errors.New("message is too long")
+	}
+	return out, nil
+}
+
+// Marshal serializes the ciphertext.
+func (c *Ciphertext) Marshal() []byte {
+	buf := encoding.AppendLengthPrefixed(nil, c.U.Marshal())
+	buf = encoding.AppendLengthPrefixed(buf, c.W)
+	buf = encoding.AppendLengthPrefixed(buf, c.Label)
+	return encoding.AppendLengthPrefixed(buf, c.Ubar.Marshal())
+}
+
+// UnmarshalCiphertext deserializes a ciphertext created with Marshal. The
+// result still needs to be checked with Verify.
+func UnmarshalCiphertext(curve CurveSystem, data []byte) (*Ciphertext, bool) {
+	r := encoding.NewReader(data)
+	c := &Ciphertext{U: r.G2(curve), W: r.LengthPrefixed(), Label: r.LengthPrefixed(), Ubar: r.G1(curve)}
+	if !r.Done() {
+		return nil, false
+	}
+	return c, true
+}
+
+// Marshal serializes the decryption share.
+func (ds *DecryptionShare) Marshal() []byte {
+	return encoding.AppendLengthPrefixed(encoding.AppendUint32(nil, uint32(ds.Index)), ds.Share.Marshal())
+}
+
+// UnmarshalDecryptionShare deserializes a decryption share created with Marshal.
+func UnmarshalDecryptionShare(curve CurveSystem, data []byte) (*DecryptionShare, bool) {
+	r := encoding.NewReader(data)
+	index := r.Uint32()
+	share := r.G2(curve)
+	if !r.Done() || index == 0 || index > 1<<31-1 {
+		return nil, false
+	}
+	return &DecryptionShare{int(index), share}, true
+}
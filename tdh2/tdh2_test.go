@@ -0,0 +1,145 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package tdh2
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/Project-Arda/bgls/dkg"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+// decryptAsCommittee has every member decrypt the marshalled ciphertext in
+// its own goroutine, as a committee's nodes would, and returns the shares
+// which they publish in member order.
+func decryptAsCommittee(curve CurveSystem, shares []bgls.SecretShare, data []byte) []*DecryptionShare {
+	published := make([]*DecryptionShare, len(shares))
+	var wg sync.WaitGroup
+	for i, share := range shares {
+		wg.Add(1)
+		go func(i int, share bgls.SecretShare) {
+			defer wg.Done()
+			c, ok := UnmarshalCiphertext(curve, data)
+			if !ok {
+				return
+			}
+			if ds, ok := CreateDecryptionShare(curve, share, c); ok {
+				published[i], _ = UnmarshalDecryptionShare(curve, ds.Marshal())
+			}
+		}(i, share)
+	}
+	wg.Wait()
+	return published
+}
+
+func TestThresholdDecryption(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		shares, vvec, _ := bgls.ThresholdKeyGen(curve, threshold, n)
+		msg := []byte("swap 10 ETH for DAI")
+		label := []byte("sender 0xabc")
+		c, err := Encrypt(curve, vvec[0], msg, label)
+		assert.Nil(t, err)
+		assert.True(t, c.Verify(curve), "Valid ciphertext failed verification")
+
+		published := decryptAsCommittee(curve, shares, c.Marshal())
+		for _, ds := range published {
+			assert.True(t, VerifyDecryptionShare(curve, vvec, c, ds), "Valid decryption share failed verification")
+		}
+		wrongIndex := &DecryptionShare{2, published[0].Share}
+		assert.False(t, VerifyDecryptionShare(curve, vvec, c, wrongIndex), "Decryption share verified for the wrong index")
+
+		decrypted, ok := Combine(curve, vvec, c, published[2:])
+		assert.True(t, ok, "Failed to decrypt with t valid shares")
+		assert.Equal(t, msg, decrypted)
+		_, ok = Combine(curve, vvec, c, published[:threshold-1])
+		assert.False(t, ok, "Decrypted with fewer than t shares")
+		_, ok = Combine(curve, vvec, c, []*DecryptionShare{published[0], published[0], published[1]})
+		assert.False(t, ok, "Decrypted with a repeated share")
+
+		// Invalid shares are skipped, as long as t valid ones remain.
+		bad := &DecryptionShare{published[0].Index, published[0].Share.Mul(big.NewInt(2))}
+		decrypted, ok = Combine(curve, vvec, c, []*DecryptionShare{bad, wrongIndex, published[1], published[3], published[4]})
+		assert.True(t, ok, "Failed to decrypt with invalid shares mixed in")
+		assert.Equal(t, msg, decrypted)
+		_, ok = Combine(curve, vvec, c, []*DecryptionShare{bad, published[1], published[3]})
+		assert.False(t, ok, "Decrypted with an invalid share")
+
+		other, _ := Encrypt(curve, vvec[0], msg, label)
+		_, ok = Combine(curve, vvec, other, published)
+		assert.False(t, ok, "Decrypted with another ciphertext's shares")
+	}
+}
+
+func TestCiphertextValidity(t *testing.T) {
+	for _, curve := range curves {
+		shares, vvec, _ := bgls.ThresholdKeyGen(curve, 2, 3)
+		c, _ := Encrypt(curve, vvec[0], []byte("bid: 42"), []byte("auction 1"))
+		flipped := append([]byte{}, c.W...)
+		flipped[0] ^= 1
+
+		modified := []*Ciphertext{
+			{c.U, flipped, c.Label, c.Ubar},
+			{c.U, c.W, []byte("auction 2"), c.Ubar},
+			{c.U.Mul(big.NewInt(2)), c.W, c.Label, c.Ubar},
+			{c.U, c.W, c.Label, c.Ubar.Mul(big.NewInt(2))},
+			{curve.GetG2Infinity(), c.W, c.Label, curve.GetG1Infinity()},
+		}
+		for _, m := range modified {
+			assert.False(t, m.Verify(curve), "Modified ciphertext verified")
+			_, ok := CreateDecryptionShare(curve, shares[0], m)
+			assert.False(t, ok, "Created a decryption share for a modified ciphertext")
+		}
+
+		unmarshalled, ok := UnmarshalCiphertext(curve, c.Marshal())
+		assert.True(t, ok)
+		assert.True(t, unmarshalled.Verify(curve))
+		assert.Equal(t, c.Marshal(), unmarshalled.Marshal())
+		_, ok = UnmarshalCiphertext(curve, append(c.Marshal(), 0))
+		assert.False(t, ok, "Unmarshalled a ciphertext with trailing data")
+		_, ok = UnmarshalDecryptionShare(curve, []byte{0, 0, 0, 0})
+		assert.False(t, ok, "Unmarshalled a truncated decryption share")
+	}
+}
+
+// TestDKGCommittee decrypts with a committee whose key came from a
+// distributed key generation, rather than a trusted dealer.
+func TestDKGCommittee(t *testing.T) {
+	threshold, n := 3, 4
+	for _, curve := range curves {
+		transport := dkg.NewMemoryTransport(n)
+		results := make([]*dkg.Result, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			p, err := dkg.NewParticipant(curve, i+1, threshold, n)
+			assert.Nil(t, err)
+			wg.Add(1)
+			go func(i int, p *dkg.Participant) {
+				defer wg.Done()
+				results[i], _ = dkg.Run(context.Background(), p, transport)
+			}(i, p)
+		}
+		wg.Wait()
+		shares := make([]bgls.SecretShare, n)
+		for i, result := range results {
+			assert.NotNil(t, result)
+			shares[i] = result.Share
+		}
+		vvec := results[0].VerificationVector
+
+		c, err := Encrypt(curve, results[0].GroupKey, []byte("sealed"), nil)
+		assert.Nil(t, err)
+		published := decryptAsCommittee(curve, shares, c.Marshal())
+		decrypted, ok := Combine(curve, vvec, c, published[1:])
+		assert.True(t, ok, "Failed to decrypt with a DKG committee")
+		assert.Equal(t, []byte("sealed"), decrypted)
+	}
+}
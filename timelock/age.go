@@ -0,0 +1,254 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package timelock
+
+// Ciphertexts are age v1 files (https://age-encryption.org/v1) with a single
+// recipient stanza, as tlock writes them:
+//
+//	age-encryption.org/v1
+//	-> tlock <round> <hex chain hash>
+//	<base64 stanza body, wrapped at 64 columns>
+//	--- <base64 header MAC>
+//	<16 byte nonce><payload>
+//
+// The stanza body is the IBE encryption of the 16 byte file key to the
+// round. The header MAC is HMAC-SHA256 of the header up to and including
+// "---", keyed with HKDF-SHA256(file key, "header"). The payload is split
+// into 64 KiB chunks, each sealed with ChaCha20-Poly1305 under
+// HKDF-SHA256(file key, salt = nonce, "payload"), with a nonce of the 11 byte
+// big endian chunk counter and a byte which is 1 for the last chunk.
+//
+// The envelope follows age, but the stanza body is this library's IBE
+// ciphertext, which differs from tlock's RFC 9380 based one. So files have
+// tlock's structure, but tlock can't decrypt them, nor this package tlock's.
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/ibe"
+)
+
+const (
+	ageVersionLine = "age-encryption.org/v1"
+	stanzaType     = "tlock"
+	fileKeySize    = 16
+	nonceSize      = 16
+	chunkSize      = 64 * 1024
+	columnsPerLine = 64
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// Header is the age header of a timelock ciphertext.
+type Header struct {
+	Round     uint64
+	ChainHash []byte
+	// FileKey is the file key, encrypted to the round.
+	FileKey *ibe.Ciphertext
+
+	macInput []byte
+	mac      []byte
+}
+
+// ParseHeader reads the header of a timelock ciphertext, so that the caller
+// can find which round's signature decrypts it. The header isn't
+// authenticated until the ciphertext is decrypted.
+func ParseHeader(curve CurveSystem, data []byte) (*Header, bool) {
+	h, _, ok := parseHeader(curve, data)
+	return h, ok
+}
+
+// marshal writes the header, with its MAC under the file key.
+func (h *Header) marshal(fileKey []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(ageVersionLine + "\n")
+	buf.WriteString("-> " + stanzaType + " " + strconv.FormatUint(h.Round, 10) + " " + hex.EncodeToString(h.ChainHash) + "\n")
+	body := b64.EncodeToString(h.FileKey.Marshal())
+	for len(body) >= columnsPerLine {
+		buf.WriteString(body[:columnsPerLine] + "\n")
+		body = body[columnsPerLine:]
+	}
+	buf.WriteString(body + "\n")
+	buf.WriteString("---")
+	mac, err := headerMAC(fileKey, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(" " + b64.EncodeToString(mac) + "\n")
+	return buf.Bytes(), nil
+}
+
+// parseHeader reads the header, and returns the rest of the data.
+func parseHeader(curve CurveSystem, data []byte) (*Header, []byte, bool) {
+	r := &lineReader{data: data}
+	if line, ok := r.next(); !ok || line != ageVersionLine {
+		return nil, nil, false
+	}
+	line, ok := r.next()
+	args := strings.Split(line, " ")
+	if !ok || len(args) != 4 || args[0] != "->" || args[1] != stanzaType {
+		return nil, nil, false
+	}
+	round, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil || strconv.FormatUint(round, 10) != args[2] {
+		return nil, nil, false
+	}
+	chainHash, err := hex.DecodeString(args[3])
+	if err != nil {
+		return nil, nil, false
+	}
+	var body strings.Builder
+	for {
+		line, ok := r.next()
+		if !ok || len(line) > columnsPerLine {
+			return nil, nil, false
+		}
+		body.WriteString(line)
+		if len(line) < columnsPerLine {
+			break
+		}
+	}
+	wrapped, err := b64.DecodeString(body.String())
+	if err != nil {
+		return nil, nil, false
+	}
+	fileKey, ok := ibe.UnmarshalCiphertext(curve, wrapped)
+	if !ok {
+		return nil, nil, false
+	}
+	macInput := r.offset + 3
+	line, ok = r.next()
+	if !ok || !strings.HasPrefix(line, "--- ") {
+		return nil, nil, false
+	}
+	mac, err := b64.DecodeString(line[4:])
+	if err != nil {
+		return nil, nil, false
+	}
+	h := &Header{round, chainHash, fileKey, data[:macInput], mac}
+	return h, data[r.offset:], true
+}
+
+// verifyMAC checks the header's MAC under the file key.
+func (h *Header) verifyMAC(fileKey []byte) bool {
+	mac, err := headerMAC(fileKey, h.macInput)
+	return err == nil && hmac.Equal(mac, h.mac)
+}
+
+func headerMAC(fileKey []byte, header []byte) ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nil, []byte("header")), key); err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(header)
+	return mac.Sum(nil), nil
+}
+
+// lineReader splits data into lines ending in "\n".
+type lineReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *lineReader) next() (string, bool) {
+	end := bytes.IndexByte(r.data[r.offset:], '\n')
+	if end < 0 {
+		return "", false
+	}
+	line := string(r.data[r.offset : r.offset+end])
+	r.offset += end + 1
+	return line, true
+}
+
+// encryptPayload encrypts the payload with age's STREAM construction.
+func encryptPayload(fileKey []byte, payload []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	out := nonce
+	for counter := uint64(0); ; counter++ {
+		n := len(payload)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		last := n == len(payload)
+		out = aead.Seal(out, chunkNonce(counter, last), payload[:n], nil)
+		payload = payload[n:]
+		if last {
+			return out, nil
+		}
+	}
+}
+
+// decryptPayload decrypts a payload from encryptPayload. Only the last chunk
+// is sealed with the last chunk flag, so the payload can't be truncated at a
+// chunk boundary. The last chunk may only be empty if it is the only chunk.
+func decryptPayload(fileKey []byte, data []byte) ([]byte, bool) {
+	if len(data) < nonceSize {
+		return nil, false
+	}
+	aead, err := payloadAEAD(fileKey, data[:nonceSize])
+	if err != nil {
+		return nil, false
+	}
+	data = data[nonceSize:]
+	sealedSize := chunkSize + aead.Overhead()
+	out := make([]byte, 0, len(data))
+	for counter := uint64(0); ; counter++ {
+		n := len(data)
+		if n > sealedSize {
+			n = sealedSize
+		}
+		last := n == len(data)
+		chunk, err := aead.Open(nil, chunkNonce(counter, last), data[:n], nil)
+		if err != nil || (last && counter > 0 && len(chunk) == 0) {
+			return nil, false
+		}
+		out = append(out, chunk...)
+		data = data[n:]
+		if last {
+			return out, true
+		}
+	}
+}
+
+func payloadAEAD(fileKey []byte, nonce []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nonce, []byte("payload")), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// chunkNonce returns the 11 byte big endian counter, followed by the last chunk flag.
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
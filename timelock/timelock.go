@@ -0,0 +1,93 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package timelock encrypts payloads to a future round of a threshold BLS
+// randomness beacon, so that nobody can decrypt them until the beacon's
+// committee signs that round. After that, anyone can.
+//
+// A round's beacon signature is bgls.Sign on the round's message, under the
+// group key, which is exactly the Boneh-Franklin decryption key for the
+// identity "round message" with the group key as the master public key. So
+// encrypting to a round is IBE encryption to its message, and no party,
+// including the committee members, learns the decryption key until t of them
+// have signed the round.
+//
+// As in tlock, the IBE ciphertext only encrypts a random file key, and the
+// payload is encrypted under the file key in the age v1 format, with a
+// "tlock" recipient stanza. See age.go for the format.
+package timelock
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/Project-Arda/bgls/beacon"
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+	"github.com/Project-Arda/bgls/ibe"
+)
+
+// RoundMessage returns the message which the beacon signs for a round. This
+// is the unchained drand message, SHA-256 of the big endian round.
+func RoundMessage(round uint64) []byte {
+	return beacon.PedersenBLSUnchained.Message(round, nil)
+}
+
+// SignRound returns a beacon's signature for a round. A threshold committee
+// signs the round message with bgls.Sign using their shares instead, and
+// combines the partial signatures with bgls.ThresholdCombineSignatures.
+func SignRound(curve CurveSystem, sk *big.Int, round uint64) Point {
+	return bgls.Sign(curve, sk, RoundMessage(round))
+}
+
+// VerifyRound checks a beacon's signature for a round under its group key.
+func VerifyRound(curve CurveSystem, groupKey Point, round uint64, sig Point) bool {
+	return bgls.VerifySingleSignature(curve, sig, groupKey, RoundMessage(round))
+}
+
+// ChainHash identifies a beacon by the SHA-256 of its marshalled group key.
+// It is included in ciphertexts, so that decryption with the signature of a
+// different beacon fails early.
+func ChainHash(groupKey Point) []byte {
+	hash := sha256.Sum256(groupKey.Marshal())
+	return hash[:]
+}
+
+// Encrypt encrypts the payload to a round of the beacon with the given group
+// key, and returns it as an age file.
+func Encrypt(curve CurveSystem, groupKey Point, round uint64, payload []byte) ([]byte, error) {
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := ibe.Encrypt(curve, &ibe.Params{PublicKey: groupKey}, RoundMessage(round), fileKey)
+	if err != nil {
+		return nil, err
+	}
+	h := &Header{Round: round, ChainHash: ChainHash(groupKey), FileKey: wrapped}
+	header, err := h.marshal(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := encryptPayload(fileKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// Decrypt decrypts an age file from Encrypt, with the beacon's signature for
+// its round. The round can be read from the header with ParseHeader. It
+// returns false if the signature isn't valid, or the file was modified.
+func Decrypt(curve CurveSystem, groupKey Point, sig Point, data []byte) ([]byte, bool) {
+	h, rest, ok := parseHeader(curve, data)
+	if !ok || !VerifyRound(curve, groupKey, h.Round, sig) || string(h.ChainHash) != string(ChainHash(groupKey)) {
+		return nil, false
+	}
+	fileKey, ok := ibe.Decrypt(curve, sig, h.FileKey)
+	if !ok || len(fileKey) != fileKeySize || !h.verifyMAC(fileKey) {
+		return nil, false
+	}
+	return decryptPayload(fileKey, rest)
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package timelock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+// thresholdSignRound signs a round with the first t shares, as a beacon's committee would.
+func thresholdSignRound(curve CurveSystem, shares []bgls.SecretShare, t int, round uint64) Point {
+	partials := make([]Point, t)
+	indices := make([]int, t)
+	for i := 0; i < t; i++ {
+		partials[i] = bgls.Sign(curve, shares[i].Value, RoundMessage(round))
+		indices[i] = shares[i].Index
+	}
+	sig, _ := bgls.ThresholdCombineSignatures(curve, partials, indices)
+	return sig
+}
+
+func TestTimelock(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		shares, vvec, _ := bgls.ThresholdKeyGen(curve, threshold, n)
+		groupKey := vvec[0]
+		round := uint64(1000)
+
+		for _, size := range []int{0, 100, chunkSize, 2*chunkSize + 1} {
+			payload := make([]byte, size)
+			rand.Read(payload)
+			data, err := Encrypt(curve, groupKey, round, payload)
+			assert.Nil(t, err)
+			assert.True(t, bytes.HasPrefix(data, []byte(fmt.Sprintf("age-encryption.org/v1\n-> tlock %d ", round))))
+
+			h, ok := ParseHeader(curve, data)
+			assert.True(t, ok)
+			assert.Equal(t, round, h.Round)
+			assert.Equal(t, ChainHash(groupKey), h.ChainHash)
+
+			sig := thresholdSignRound(curve, shares, threshold, round)
+			assert.True(t, VerifyRound(curve, groupKey, round, sig), "Beacon signature failed verification")
+			decrypted, ok := Decrypt(curve, groupKey, sig, data)
+			assert.True(t, ok, "Failed to decrypt with the round's signature")
+			assert.Equal(t, payload, decrypted)
+		}
+
+		data, _ := Encrypt(curve, groupKey, round, []byte("sealed bid"))
+		earlier := thresholdSignRound(curve, shares, threshold, round-1)
+		_, ok := Decrypt(curve, groupKey, earlier, data)
+		assert.False(t, ok, "Decrypted with an earlier round's signature")
+		otherSk, otherKey, _ := bgls.KeyGen(curve)
+		_, ok = Decrypt(curve, otherKey, SignRound(curve, otherSk, round), data)
+		assert.False(t, ok, "Decrypted with another beacon's signature")
+	}
+}
+
+func TestTimelockIntegrity(t *testing.T) {
+	for _, curve := range curves {
+		sk, groupKey, _ := bgls.KeyGen(curve)
+		round := uint64(7)
+		sig := SignRound(curve, sk, round)
+		payload := make([]byte, chunkSize+10)
+		data, _ := Encrypt(curve, groupKey, round, payload)
+		h, _ := ParseHeader(curve, data)
+		headerSize := len(h.macInput)
+
+		// Flipping a byte anywhere in the body, the MAC or the payload breaks decryption.
+		for _, i := range []int{headerSize - 10, headerSize + 5, headerSize + 40, len(data) - 1} {
+			modified := append([]byte{}, data...)
+			modified[i] ^= 1
+			_, ok := Decrypt(curve, groupKey, sig, modified)
+			assert.False(t, ok, "Modified ciphertext decrypted")
+		}
+		// Dropping the last chunk leaves a full chunk which isn't flagged as the last.
+		truncated := data[:len(data)-(10+16)]
+		_, ok := Decrypt(curve, groupKey, sig, truncated)
+		assert.False(t, ok, "Truncated ciphertext decrypted")
+
+		otherRound := bytes.Replace(data, []byte("tlock 7 "), []byte("tlock 8 "), 1)
+		_, ok = Decrypt(curve, groupKey, SignRound(curve, sk, 8), otherRound)
+		assert.False(t, ok, "Ciphertext decrypted after changing its round")
+		_, ok = ParseHeader(curve, bytes.Replace(data, []byte("tlock 7 "), []byte("tlock 07 "), 1))
+		assert.False(t, ok, "Parsed a non-canonical round")
+		_, ok = ParseHeader(curve, data[:20])
+		assert.False(t, ok, "Parsed a truncated header")
+	}
+}
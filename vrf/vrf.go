@@ -0,0 +1,100 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package vrf implements a verifiable random function from BLS signatures.
+//
+// BLS signatures are unique: for a given key and message, only one signature
+// verifies. So the signature on an input alpha is a proof, which anyone can
+// verify under the public key, and hashing it gives an output beta which is
+// pseudorandom to anyone without the secret key. This is built the same way
+// as the RSA full domain hash VRF of draft-irtf-cfrg-vrf.
+//
+// Inputs are hashed onto G1 with HashToG1WithDST under a domain separation
+// tag, rather than with the curve's HashToG1 which plain bgls signing uses. So
+// no message has a plain signature equal to a VRF proof, and a signing oracle
+// for the key doesn't reveal VRF outputs. Outputs are SHA-256 of a second tag
+// and the marshalled proof.
+//
+// Since BLS signing is linear in the secret key, the VRF can be evaluated by
+// a threshold of holders of Shamir shares of the key, e.g. from
+// bgls.ThresholdKeyGen or the dkg package. Each holder computes a partial
+// proof, and any t valid partial proofs are combined into the proof which the
+// group's secret key would have produced. Its output doesn't depend on which
+// holders took part.
+package vrf
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves" // nolint: golint
+)
+
+const (
+	// HashToCurveDomain is the domain separation tag with which inputs are
+	// hashed onto G1.
+	HashToCurveDomain = "BGLS-VRF-hash-to-curve-v2"
+	// ProofToHashDomain is prepended to proofs before they're hashed to outputs.
+	ProofToHashDomain = "BGLS-VRF-proof-to-hash-v1"
+)
+
+// OutputSize is the length of a VRF output in bytes.
+const OutputSize = sha256.Size
+
+// Hasher returns the Hasher which hashes VRF inputs onto G1, with the domain
+// separation tag. It can be passed to the bgls CustHash functions.
+func Hasher(curve CurveSystem) bgls.Hasher {
+	return bgls.HashFunc(func(alpha []byte) Point {
+		return HashToG1WithDST(curve, alpha, []byte(HashToCurveDomain))
+	})
+}
+
+// Prove returns the proof for the input alpha, under the secret key sk.
+func Prove(curve CurveSystem, sk *big.Int, alpha []byte) Point {
+	return bgls.SignCustHash(sk, alpha, Hasher(curve))
+}
+
+// ProofToHash returns the VRF output for a proof. The proof must have been
+// verified first.
+func ProofToHash(proof Point) []byte {
+	h := sha256.New()
+	h.Write([]byte(ProofToHashDomain))
+	h.Write(proof.Marshal())
+	return h.Sum(nil)
+}
+
+// Verify checks the proof for alpha under the public key, and returns the VRF
+// output if it is valid. The identity is rejected as a public key, since its
+// proofs would verify for every input.
+func Verify(curve CurveSystem, pubKey Point, alpha []byte, proof Point) ([]byte, bool) {
+	if pubKey.Equals(curve.GetG2Infinity()) ||
+		!bgls.VerifySingleSignatureCustHash(curve, proof, pubKey, alpha, Hasher(curve)) {
+		return nil, false
+	}
+	return ProofToHash(proof), true
+}
+
+// ProvePartial returns the partial proof for the input alpha, from a share of
+// the secret key.
+func ProvePartial(curve CurveSystem, share bgls.SecretShare, alpha []byte) Point {
+	return Prove(curve, share.Value, alpha)
+}
+
+// VerifyPartial checks that a partial proof for alpha is from the share with
+// the given index, against the verification vector of the sharing.
+func VerifyPartial(curve CurveSystem, vvec []Point, index int, alpha []byte, partial Point) bool {
+	if index < 1 || len(vvec) == 0 {
+		return false
+	}
+	pubKey := bgls.GetSharePublicKey(curve, vvec, index)
+	return bgls.VerifySingleSignatureCustHash(curve, partial, pubKey, alpha, Hasher(curve))
+}
+
+// CombinePartials combines the partial proofs from the shares with the given
+// indices into the group's proof. If at least t of them are valid, the result
+// verifies under the group public key, vvec[0]. It returns false if the
+// indices are invalid.
+func CombinePartials(curve CurveSystem, partials []Point, indices []int) (Point, bool) {
+	return bgls.ThresholdCombineSignatures(curve, partials, indices)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package vrf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/Project-Arda/bgls/bgls"
+	. "github.com/Project-Arda/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+var curves = []CurveSystem{Altbn128, Bls12}
+
+func TestVRF(t *testing.T) {
+	for _, curve := range curves {
+		sk, pk, _ := bgls.KeyGen(curve)
+		alpha := []byte("epoch 42")
+
+		proof := Prove(curve, sk, alpha)
+		beta, ok := Verify(curve, pk, alpha, proof)
+		assert.True(t, ok, "Valid proof failed verification")
+		assert.Equal(t, OutputSize, len(beta))
+		assert.Equal(t, ProofToHash(proof), beta)
+		assert.True(t, Prove(curve, sk, alpha).Equals(proof), "Proofs aren't unique")
+
+		unmarshalled, ok := curve.UnmarshalG1(proof.Marshal())
+		assert.True(t, ok)
+		unmarshalledBeta, ok := Verify(curve, pk, alpha, unmarshalled)
+		assert.True(t, ok)
+		assert.Equal(t, beta, unmarshalledBeta)
+
+		otherProof := Prove(curve, sk, []byte("epoch 43"))
+		otherBeta, ok := Verify(curve, pk, []byte("epoch 43"), otherProof)
+		assert.True(t, ok)
+		assert.False(t, bytes.Equal(beta, otherBeta), "Different inputs gave the same output")
+
+		_, ok = Verify(curve, pk, []byte("epoch 43"), proof)
+		assert.False(t, ok, "Proof verified for the wrong input")
+		_, otherPk, _ := bgls.KeyGen(curve)
+		_, ok = Verify(curve, otherPk, alpha, proof)
+		assert.False(t, ok, "Proof verified under the wrong key")
+		_, ok = Verify(curve, curve.GetG2Infinity(), alpha, curve.GetG1Infinity())
+		assert.False(t, ok, "Proof verified under the identity key")
+	}
+}
+
+func TestDomainSeparation(t *testing.T) {
+	for _, curve := range curves {
+		sk, pk, _ := bgls.KeyGen(curve)
+		alpha := []byte("epoch 42")
+		proof := Prove(curve, sk, alpha)
+		assert.False(t, bgls.VerifySingleSignature(curve, proof, pk, alpha), "VRF proof verified as a signature")
+		_, ok := Verify(curve, pk, alpha, bgls.Sign(curve, sk, alpha))
+		assert.False(t, ok, "Signature verified as a VRF proof")
+
+		// No plain signature, in particular none on the tagged input, is a proof.
+		tag := []byte(HashToCurveDomain)
+		for _, msg := range [][]byte{nil, alpha, tag, append(append([]byte{}, tag...), alpha...),
+			append(append([]byte{}, alpha...), tag...), proof.Marshal(), ProofToHash(proof)} {
+			sig := bgls.Sign(curve, sk, msg)
+			assert.False(t, sig.Equals(proof), "Plain signature equals a VRF proof")
+			_, ok = Verify(curve, pk, alpha, sig)
+			assert.False(t, ok, "Plain signature verified as a VRF proof")
+		}
+		for i := 0; i < 32; i++ {
+			msg := make([]byte, i)
+			rand.Read(msg)
+			assert.False(t, bgls.Sign(curve, sk, msg).Equals(proof), "Plain signature equals a VRF proof")
+		}
+	}
+}
+
+func TestThresholdVRF(t *testing.T) {
+	threshold, n := 3, 5
+	for _, curve := range curves {
+		shares, vvec, _ := bgls.ThresholdKeyGen(curve, threshold, n)
+		alpha := []byte("round 7")
+
+		partials := make([]Point, n)
+		for i, share := range shares {
+			partials[i] = ProvePartial(curve, share, alpha)
+			assert.True(t, VerifyPartial(curve, vvec, share.Index, alpha, partials[i]),
+				"Valid partial proof failed verification")
+		}
+		assert.False(t, VerifyPartial(curve, vvec, 2, alpha, partials[0]), "Partial proof verified for the wrong share")
+		assert.False(t, VerifyPartial(curve, vvec, 0, alpha, partials[0]), "Partial proof verified for index 0")
+
+		proof, ok := CombinePartials(curve, partials[:3], []int{1, 2, 3})
+		assert.True(t, ok)
+		beta, ok := Verify(curve, vvec[0], alpha, proof)
+		assert.True(t, ok, "Combined proof failed verification")
+		otherProof, _ := CombinePartials(curve, []Point{partials[4], partials[1], partials[3]}, []int{5, 2, 4})
+		otherBeta, ok := Verify(curve, vvec[0], alpha, otherProof)
+		assert.True(t, ok)
+		assert.Equal(t, beta, otherBeta, "Output depends on which shares were combined")
+
+		proof, _ = CombinePartials(curve, partials[:2], []int{1, 2})
+		_, ok = Verify(curve, vvec[0], alpha, proof)
+		assert.False(t, ok, "Proof from too few partials verified")
+		_, ok = CombinePartials(curve, partials[:2], []int{1, 1})
+		assert.False(t, ok, "Combined partials with repeated indices")
+	}
+}